@@ -20,8 +20,12 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,18 +36,23 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/selection"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 	toolscache "k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
+	"k8s.io/utils/lru"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	filteredcache "github.com/IBM/controller-filtered-cache/filteredcache"
 )
 
 // NewCSCache implements a customized cache with a for CS
-func NewCSCache(clusterGVKList []schema.GroupVersionKind, gvkLabelMap map[schema.GroupVersionKind]filteredcache.Selector, watchNamespaceList []string) cache.NewCacheFunc {
+func NewCSCache(clusterGVKList []schema.GroupVersionKind, gvkLabelMap map[schema.GroupVersionKind]filteredcache.Selector, watchNamespaceList []string, options ...CSCacheOption) cache.NewCacheFunc {
+	csOpts := buildCSCacheOptions(options)
 	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
 
 		// Get the frequency that informers are resynced
@@ -52,10 +61,38 @@ func NewCSCache(clusterGVKList []schema.GroupVersionKind, gvkLabelMap map[schema
 			resync = *opts.Resync
 		}
 
+		// Track the resourceVersion of the objects in strict mode
+		var rvTracker *resourceVersionTracker
+		if csOpts.StrictResourceVersion {
+			rvTracker = newResourceVersionTracker()
+		}
+
+		// The informers and getFromClient share the config with the customized transport
+		clientConfig, err := buildClientConfig(config, csOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the client config: %v", err)
+		}
+
+		// Watch the GVKs of the CRDs installed in the cluster as well
+		gvkList := clusterGVKList
+		if csOpts.AutoDiscoverCRDs {
+			discovered, err := DiscoverCRDGVKs(context.TODO(), clientConfig, opts.Scheme, csOpts.CRDLabelSelector)
+			if err != nil {
+				klog.Warningf("Failed to discover the GVKs of the CRDs: %v", err)
+			}
+			gvkList = mergeGVKs(clusterGVKList, discovered)
+		}
+
 		// Generate informermap to contain the gvks and their informers
-		informerMap, err := buildInformerMap(config, opts, resync, clusterGVKList)
+		// The informers of the lazy GVKs are built on their first access
+		eagerGVKList, lazyGVKs := splitLazyGVKs(gvkList, csOpts.LazyGVKs, csOpts.LazyInitTimeouts)
+		informerMap, err := buildInformerMap(clientConfig, opts, resync, eagerGVKList, rvTracker, csOpts, nil)
 		if err != nil {
-			return nil, err
+			// A misconfigured GVK doesn't prevent the other GVKs from being watched
+			if len(informerMap) == 0 {
+				return nil, err
+			}
+			klog.Warningf("Failed to watch some of the cluster GVKs: %v", err)
 		}
 
 		var NewCache cache.NewCacheFunc
@@ -70,63 +107,369 @@ func NewCSCache(clusterGVKList []schema.GroupVersionKind, gvkLabelMap map[schema
 		if err != nil {
 			return nil, fmt.Errorf("failed to init fallback cache: %v", err)
 		}
+		if csOpts.ReadOnly {
+			fallback = readOnlyCache{Cache: fallback}
+		}
+
+		csCache := &CSCache{
+			config:      clientConfig,
+			cacheOpts:   opts,
+			resync:      resync,
+			options:     csOpts,
+			informerMap: informerMap,
+			rvTracker:   rvTracker,
+			fallback:    fallback,
+			Scheme:      opts.Scheme,
+
+			lazyGVKs:          lazyGVKs,
+			watchNamespaces:   append([]string{}, watchNamespaceList...),
+			newNamespaceCache: newNamespaceCacheFunc(gvkLabelMap, config, opts),
+		}
+
+		for gvk, informer := range informerMap {
+			if !isListGVK(gvk) {
+				csCache.enableDiffEviction(gvk, informer)
+				csCache.enableAccessLog(gvk, informer)
+			}
+		}
+
+		if csOpts.SecondaryConfig != nil && csOpts.RegionFallbackCondition != nil {
+			regionalFallback, err := newRegionalFallback(csOpts.SecondaryConfig, csOpts, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to init the secondary regional cache: %v", err)
+			}
+			csCache.regionalFallback = regionalFallback
+		}
+
+		// The events are not processed until the operator is elected
+		if csOpts.ElectedCh != nil {
+			csCache.SuspendReconcile()
+		}
+
+		for gvk := range csOpts.TTLEvictions {
+			if err := csCache.enableTTLEviction(gvk); err != nil {
+				return nil, err
+			}
+		}
+
+		for gvk := range csOpts.GVKObjectLimit {
+			if err := csCache.enableObjectLimit(gvk); err != nil {
+				return nil, err
+			}
+		}
+
+		for gvk, dependsOn := range csOpts.CrossGVKDependencies {
+			if err := csCache.enableCrossGVKConsistency(gvk, dependsOn); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, gvk := range csOpts.DeltaBasedGVKs {
+			if err := csCache.enableDeltaEvents(gvk); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := csCache.registerFieldIndexes(); err != nil {
+			return nil, err
+		}
+
+		for gvk := range csOpts.GVKKeyFuncs {
+			if informer, ok := informerMap[gvk]; ok {
+				if err := csCache.addKeyFuncIndex(gvk, informer); err != nil {
+					return nil, fmt.Errorf("failed to index the custom keys of %s: %v", gvk, err)
+				}
+			}
+		}
+
+		if csOpts.SyncMetricRegistry != nil {
+			if csCache.syncHistogram, err = newSyncDurationHistogram(csOpts.SyncMetricRegistry); err != nil {
+				return nil, fmt.Errorf("failed to register informer sync metric: %v", err)
+			}
+		}
+
+		if csOpts.CostModelRegistry != nil {
+			if csCache.costModel, err = newCostModel(csOpts.CostModelRegistry, csOpts.CostModelAllocEvery); err != nil {
+				return nil, fmt.Errorf("failed to register cost model metrics: %v", err)
+			}
+		}
+
+		if csOpts.DebugHTTPMux != nil {
+			csOpts.DebugHTTPMux.Handle(DebugCachePath, csCache.debugHandler())
+		}
+
+		if csOpts.Metrics {
+			if err := registerCacheMetrics(csCache); err != nil {
+				return nil, fmt.Errorf("failed to register cache metrics: %v", err)
+			}
+			if csCache.queueDepthGauge, err = newQueueDepthGauge(metrics.Registry); err != nil {
+				return nil, fmt.Errorf("failed to register cache metrics: %v", err)
+			}
+		}
 
 		// Return the customized cache
-		return CSCache{config: config, informerMap: informerMap, fallback: fallback, Scheme: opts.Scheme}, nil
+		return csCache, nil
 	}
 }
 
-//buildInformerMap generates informerMap of the specified resource
-func buildInformerMap(config *rest.Config, opts cache.Options, resync time.Duration, clusterGVKList []schema.GroupVersionKind) (map[schema.GroupVersionKind]toolscache.SharedIndexInformer, error) {
+// buildInformerMap generates informerMap of the specified resource. The GVKs failed to build
+// are skipped, and their errors are returned as an aggregate along with the other informers.
+// The informers of the resources known to the InformerFactory are shared from the factory.
+// The namespace-scoped informers watch the namespaces returned by namespaces, or else the
+// namespace of the options if it is nil.
+func buildInformerMap(config *rest.Config, opts cache.Options, resync time.Duration, clusterGVKList []schema.GroupVersionKind, rvTracker *resourceVersionTracker, csOpts CSCacheOptions, namespaces func(schema.GroupVersionKind) []string) (map[schema.GroupVersionKind]toolscache.SharedIndexInformer, error) {
+	if namespaces == nil {
+		namespaces = func(gvk schema.GroupVersionKind) []string {
+			return []string{informerNamespace(opts, gvk)}
+		}
+	}
 	// Initialize informerMap
 	informerMap := make(map[schema.GroupVersionKind]toolscache.SharedIndexInformer)
 
+	var errs []error
 	for _, gvk := range clusterGVKList {
-
-		// Create ListerWatcher by NewFilteredListWatchFromClient
-		client, err := getClientForGVK(gvk, config, opts.Scheme)
-		if err != nil {
-			return nil, err
+		informer, ok := factoryInformer(csOpts.InformerFactory, gvk)
+		if !ok {
+			// The standalone informers can be rebuilt with their handlers, e.g. by the watchdog
+			gvk := gvk
+			rebuildable, err := newRebuildableInformer(func() (toolscache.SharedIndexInformer, *watchHealth, error) {
+				return buildStandaloneInformer(config, opts, resync, gvk, csOpts, namespaces(gvk))
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to build informer for %s: %v", gvk, err))
+				continue
+			}
+			informer = rebuildable
 		}
 
-		// Get the plural type of the kind as resource
-		plural := kindToResource(gvk.Kind)
-		listerWatcher := toolscache.NewFilteredListWatchFromClient(client, plural, opts.Namespace, func(options *metav1.ListOptions) {})
-
-		// Build typed runtime object for informer
-		objType := &unstructured.Unstructured{}
-		objType.GetObjectKind().SetGroupVersionKind(gvk)
-		typed, err := opts.Scheme.New(gvk)
-		if err != nil {
-			return nil, err
-		}
-		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objType.UnstructuredContent(), typed); err != nil {
-			return nil, err
+		if rvTracker != nil {
+			informer.AddEventHandler(rvTracker.eventHandler(gvk))
 		}
-
-		// Create new inforemer with the listerwatcher
-		informer := toolscache.NewSharedIndexInformer(listerWatcher, typed, resync, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
 		informerMap[gvk] = informer
 		// Build list type for the GVK
 		gvkList := schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind + "List"}
 		informerMap[gvkList] = informer
 	}
 
-	return informerMap, nil
+	return informerMap, utilerrors.NewAggregate(errs)
+}
+
+// buildStandaloneInformer builds the informer of the GVK with its own list and watch in the
+// namespaces, and the health of the list and watch calls
+func buildStandaloneInformer(config *rest.Config, opts cache.Options, resync time.Duration, gvk schema.GroupVersionKind, csOpts CSCacheOptions, namespaces []string) (toolscache.SharedIndexInformer, *watchHealth, error) {
+	// Get the plural type of the kind as resource
+	plural := kindToResource(gvk.Kind)
+	tweak := informerTweak(opts, gvk, csOpts)
+
+	// Create ListerWatcher by NewFilteredListWatchFromClient
+	client, err := getClientForGVK(context.Background(), gvk, config, opts.Scheme, csOpts.GVKCodecFactories)
+	if err != nil {
+		// The DynamicClient lists and watches the unstructured objects when the REST client can't be built
+		if csOpts.DynamicClient == nil {
+			return nil, nil, err
+		}
+		klog.Warningf("Failed to build the REST client of %s, watching it with the dynamic client: %v", gvk, err)
+		health := &watchHealth{}
+		listerWatcher := healthListWatch{ListerWatcher: namespacesListWatch(namespaces, func(namespace string) toolscache.ListerWatcher {
+			return newDynamicListWatch(csOpts.DynamicClient, gvk, namespace, tweak)
+		}), health: health}
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		return toolscache.NewSharedIndexInformer(listerWatcher, u, resync, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc}), health, nil
+	}
+	health := &watchHealth{}
+	listerWatcher := healthListWatch{ListerWatcher: namespacesListWatch(namespaces, func(namespace string) toolscache.ListerWatcher {
+		return toolscache.NewFilteredListWatchFromClient(client, plural, namespace, tweak)
+	}), health: health}
+
+	// Build typed runtime object for informer, the GVKs not in the scheme are unstructured
+	typed, err := newInformerObject(opts.Scheme, gvk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Create new inforemer with the listerwatcher
+	return toolscache.NewSharedIndexInformer(listerWatcher, typed, resync, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc}), health, nil
+}
+
+// informerTweak tweaks the list options the informer of the GVK is listed and watched with
+func informerTweak(opts cache.Options, gvk schema.GroupVersionKind, csOpts CSCacheOptions) TweakListOptionsFunc {
+	gvkTweak := csOpts.InformerTweakOptions[gvk]
+	return func(options *metav1.ListOptions) {
+		if gvkTweak != nil {
+			gvkTweak(options)
+		}
+		excludeNamespaces(opts, gvk, csOpts.ExcludeNamespaces, options)
+	}
 }
 
 // CSCache is the customized cache for CS
 type CSCache struct {
-	config      *rest.Config
-	informerMap map[schema.GroupVersionKind]toolscache.SharedIndexInformer
-	fallback    cache.Cache
-	Scheme      *runtime.Scheme
+	// Counters of the cache requests, accessed atomically
+	getHits   uint64
+	getMisses uint64
+	listHits  uint64
+	getErrors uint64
+
+	config    *rest.Config
+	cacheOpts cache.Options
+	resync    time.Duration
+	options   CSCacheOptions
+
+	// mu guards informerMap, which is updated when an informer is rebuilt or registered,
+	// and the contexts the informers run with
+	mu              sync.RWMutex
+	informerMap     map[schema.GroupVersionKind]toolscache.SharedIndexInformer
+	startCtx        context.Context
+	informerCancels map[schema.GroupVersionKind]context.CancelFunc
+	// rvTracker is only set in strict resourceVersion mode
+	rvTracker *resourceVersionTracker
+	fallback  cache.Cache
+	Scheme    *runtime.Scheme
+
+	// statsMu guards the sync latency tracking
+	statsMu        sync.Mutex
+	startTime      time.Time
+	syncStartTimes map[schema.GroupVersionKind]time.Time
+	syncDurations  map[schema.GroupVersionKind]time.Duration
+	syncHistogram  *prometheus.HistogramVec
+	// syncTimes, runningInformers and informerErrors are the state of the informers
+	syncTimes        map[schema.GroupVersionKind]time.Time
+	runningInformers map[schema.GroupVersionKind]int
+	informerErrors   map[schema.GroupVersionKind]error
+	// watchdogRestarts counts the informers restarted by the watchdog
+	watchdogRestarts map[schema.GroupVersionKind]uint64
+
+	// costModel measures the event handlers added through GetInformer
+	costModel *costModel
+	// gates hold back the events of the GVKs depending on other GVKs
+	gates map[schema.GroupVersionKind]*consistencyGate
+
+	// patchMu guards the handlers of the delta based GVKs
+	patchMu       sync.RWMutex
+	patchHandlers map[schema.GroupVersionKind][]PatchEventHandler
+
+	// resyncCounts counts the resynced objects of each started GVK for the resync hook,
+	// guarded by mu
+	resyncCounts map[schema.GroupVersionKind]*uint64
+
+	// ttlMu guards the creation timestamps of the objects evicted after a TTL
+	ttlMu      sync.Mutex
+	ttlEntries map[schema.GroupVersionKind]map[string]time.Time
+
+	// indexMu guards the field indexes removed by RemoveIndexField
+	indexMu        sync.RWMutex
+	removedIndexes map[schema.GroupVersionKind]map[string]bool
+
+	// clientCache holds the REST client of each GVK
+	clientCache sync.Map
+
+	// selectorCache holds the compiled label selectors of List
+	selectorCacheOnce sync.Once
+	selectorCache     *lru.Cache
+
+	// accessTimes tracks the last access of the objects of the limited GVKs
+	accessTimes map[schema.GroupVersionKind]*sync.Map
+
+	// healthMu guards the informers exited while the cache is running
+	healthMu        sync.Mutex
+	exitedInformers map[schema.GroupVersionKind]bool
+	// cacheSynced is 1 once WaitForCacheSync has returned true
+	cacheSynced int32
+
+	// suspended is 1 while the event processing is suspended by the leader election
+	suspended           int32
+	leaderMu            sync.Mutex
+	suspendableHandlers []*suspendableHandler
+
+	// accessLog holds the time the objects of each GVK were last added or updated
+	accessLogMu sync.Mutex
+	accessLog   map[schema.GroupVersionKind]map[string]time.Time
+
+	// casMu serializes the CompareAndSwap calls
+	casMu sync.Mutex
+
+	// clientErrorCounts counts the consecutive getFromClient failures of each GVK
+	clientErrorMu     sync.Mutex
+	clientErrorCounts map[schema.GroupVersionKind]int
+
+	// forbiddenCounts counts the getFromClient requests of each GVK forbidden by the api server
+	forbiddenMu     sync.Mutex
+	forbiddenCounts map[schema.GroupVersionKind]uint64
+
+	// regionalFallback serves getFromClient when the primary cluster is unavailable
+	regionalFallback *CSCache
+
+	// routeMu guards the caches registered by RegisterRoute
+	routeMu sync.RWMutex
+	routes  map[string]cache.Cache
+
+	// lazyGVKs are the GVKs whose informers are not built until their first access. The
+	// GVKs are removed once their informers are registered.
+	lazyMu   sync.RWMutex
+	lazyGVKs map[schema.GroupVersionKind]*lazyInformer
+
+	// observers are the handlers observed before Start, guarded by mu
+	observers []observer
+
+	// prepopulated are the GVKs whose informer stores are seeded by Prepopulate, guarded by mu
+	prepopulated map[schema.GroupVersionKind]bool
+
+	// warmUp is the progress reported by WarmUpProgress
+	warmUpMu sync.Mutex
+	warmUp   *warmUp
+
+	// queueDepthGauge is the cs_cache_event_queue_depth gauge, updated while the cache runs.
+	// queueDepths count the events queued for the handlers of each GVK.
+	queueMu         sync.Mutex
+	queueDepthGauge *prometheus.GaugeVec
+	queueDepths     map[schema.GroupVersionKind]*int64
+
+	// diffs holds the previous version of the objects returned by Get. The deleted objects of
+	// the informer GVKs are evicted.
+	diffs DiffStore
+
+	// writeThrough holds the objects recently fetched by getFromClient
+	writeThrough sync.Map
+
+	// namespaceAddMu serializes AddNamespaceWatch, and guards the informers of the fallback
+	// GVKs spanning the caches of the namespaces
+	namespaceAddMu          sync.Mutex
+	multiNamespaceInformers map[schema.GroupVersionKind]*multiNamespaceInformer
+
+	// namespaceMu guards the watched namespaces and the caches of the namespaces
+	// added by AddNamespaceWatch
+	namespaceMu       sync.RWMutex
+	watchNamespaces   []string
+	namespaceCaches   map[string]cache.Cache
+	newNamespaceCache func(namespace string) (cache.Cache, error)
+
+	// gvkConfigMu guards the GVKs registered by WatchGVKConfig
+	gvkConfigMu sync.Mutex
+	configGVKs  map[schema.GroupVersionKind]bool
+}
+
+// getInformer returns the informer of the GVK from the informerMap
+func (c *CSCache) getInformer(gvk schema.GroupVersionKind) (toolscache.SharedIndexInformer, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	informer, ok := c.informerMap[gvk]
+	return informer, ok
 }
 
 // Get implements Reader
 // If the resource is in the cache, Get function get fetch in from the informer
 // Otherwise, resource will be get by the k8s client
-func (c CSCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+func (c *CSCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return c.GetWithOptions(ctx, key, obj)
+}
+
+// GetWithOptions is Get with the GetOptions
+func (c *CSCache) GetWithOptions(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...GetOption) error {
+	getOpts := GetOptions{}
+	for _, opt := range opts {
+		opt(&getOpts)
+	}
 
 	// Get the GVK of the client object
 	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
@@ -134,22 +477,69 @@ func (c CSCache) Get(ctx context.Context, key client.ObjectKey, obj client.Objec
 		return err
 	}
 
-	if informer, ok := c.informerMap[gvk]; ok {
+	if err := c.get(ctx, key, obj, gvk, getOpts); err != nil {
+		return err
+	}
+	if err := c.obfuscate(ctx, gvk, obj); err != nil {
+		return err
+	}
+	c.diffs.record(gvk, diffKey(key), obj)
+	return nil
+}
+
+// get looks up the object in the routed cache, the informer store, or the fallback cache
+func (c *CSCache) get(ctx context.Context, key client.ObjectKey, obj client.Object, gvk schema.GroupVersionKind, getOpts GetOptions) error {
+	if routeCache, ok := c.routeFor(ctx, gvk, key); ok {
+		return routeCache.Get(ctx, key, obj)
+	}
+
+	c.initLazyInformer(ctx, gvk)
+
+	if informer, ok := c.getInformer(gvk); ok {
+		// Looking for object recently fetched from k8s apiserver
+		if found, err := c.getFromWriteThrough(gvk, key, obj); err != nil {
+			return err
+		} else if found && getOpts.isFresh(obj) {
+			atomic.AddUint64(&c.getHits, 1)
+			return nil
+		}
 		// Looking for object from the cache
-		if err := c.getFromStore(informer, key, obj, gvk); err == nil {
+		if err := c.getFromStore(informer, key, obj, gvk); err == nil && getOpts.isFresh(obj) {
+			atomic.AddUint64(&c.getHits, 1)
+			// Looking for object from the cache of the parent
+		} else if c.getFromParent(key, obj, gvk, getOpts) {
+			atomic.AddUint64(&c.getHits, 1)
+			// The object is not fetched from k8s apiserver without fallback
+		} else if c.options.FallbackPolicy == FallbackNever {
+			return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.String())
+			// Waiting for the object to be added to the cache
+		} else if c.options.FallbackPolicy == FallbackOnTimeout && c.waitForStore(ctx, informer, key, obj, gvk, getOpts) {
+			atomic.AddUint64(&c.getHits, 1)
 			// If not found the object from cache, then fetch it from k8s apiserver
-		} else if err := c.getFromClient(ctx, key, obj, gvk); err != nil {
+		} else if err := c.getFromClientAtVersion(ctx, key, obj, gvk, getOpts.MinResourceVersion); err != nil {
+			atomic.AddUint64(&c.getMisses, 1)
+			atomic.AddUint64(&c.getErrors, 1)
 			return err
+		} else {
+			atomic.AddUint64(&c.getMisses, 1)
 		}
 		return nil
 	}
 
 	// Passthrough
-	return c.fallback.Get(ctx, key, obj)
+	return c.fallbackFor(key.Namespace).Get(ctx, key, obj)
 }
 
 // getFromStore gets the resource from the cache
-func (c CSCache) getFromStore(informer toolscache.SharedIndexInformer, key client.ObjectKey, obj runtime.Object, gvk schema.GroupVersionKind) error {
+func (c *CSCache) getFromStore(informer toolscache.SharedIndexInformer, key client.ObjectKey, obj runtime.Object, gvk schema.GroupVersionKind) error {
+
+	// The cluster-scoped resources are stored without namespace, whatever the caller passed
+	if key.Namespace != "" && c.cacheOpts.Mapper != nil {
+		if clusterScoped, err := isClusterScoped(c.cacheOpts.Mapper, gvk); err == nil && clusterScoped {
+			klog.Warningf("Namespace %s is ignored in the key of the cluster-scoped %s %s", key.Namespace, gvk, key.Name)
+			key.Namespace = ""
+		}
+	}
 
 	// Different key for cluster scope resource and namespaced resource
 	var keyString string
@@ -172,13 +562,36 @@ func (c CSCache) getFromStore(informer toolscache.SharedIndexInformer, key clien
 		return fmt.Errorf("cache contained %T, which is not an Object", item)
 	}
 
+	// In strict mode, the object older than the one already seen is a cache miss
+	if c.rvTracker != nil {
+		meta, err := apimeta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if c.rvTracker.isStale(gvk, keyString, meta.GetResourceVersion()) {
+			return fmt.Errorf("cache had stale %s %s at resourceVersion %s", gvk.Kind, keyString, meta.GetResourceVersion())
+		}
+	}
+
+	c.recordAccess(gvk, keyString)
+
 	// deep copy to avoid mutating cache
 	item = item.(runtime.Object).DeepCopyObject()
+	item, err = c.migrate(gvk, item.(runtime.Object))
+	if err != nil {
+		return err
+	}
 
 	// Copy the value of the item in the cache to the returned value
+	if converted, err := convertUnstructured(item.(runtime.Object), obj); err != nil {
+		return err
+	} else if converted {
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		return nil
+	}
 	objVal := reflect.ValueOf(obj)
 	itemVal := reflect.ValueOf(item)
-	if !objVal.Type().AssignableTo(objVal.Type()) {
+	if !itemVal.Type().AssignableTo(objVal.Type()) {
 		return fmt.Errorf("cache had type %s, but %s was asked for", itemVal.Type(), objVal.Type())
 	}
 	reflect.Indirect(objVal).Set(reflect.Indirect(itemVal))
@@ -188,36 +601,87 @@ func (c CSCache) getFromStore(informer toolscache.SharedIndexInformer, key clien
 }
 
 // getFromClient gets the resource by the k8s client
-func (c CSCache) getFromClient(ctx context.Context, key client.ObjectKey, obj runtime.Object, gvk schema.GroupVersionKind) error {
+func (c *CSCache) getFromClient(ctx context.Context, key client.ObjectKey, obj runtime.Object, gvk schema.GroupVersionKind) error {
+	return c.getFromClientAtVersion(ctx, key, obj, gvk, "")
+}
+
+// getFromClientAtVersion gets the resource at least as new as the resourceVersion from the
+// api server. The latest resource is got if the resourceVersion is empty.
+func (c *CSCache) getFromClientAtVersion(ctx context.Context, key client.ObjectKey, obj runtime.Object, gvk schema.GroupVersionKind, resourceVersion string) error {
+	if c.options.CacheMissCallback != nil {
+		go c.options.CacheMissCallback(ctx, gvk, key)
+	}
 
 	// Get resource by the kubeClient
 	resource := kindToResource(gvk.Kind)
 
-	client, err := getClientForGVK(gvk, c.config, c.Scheme)
+	var result runtime.Object
+	client, err := c.clientForGVK(ctx, gvk)
 	if err != nil {
-		return err
+		// The DynamicClient gets the resource when the REST client can't be built
+		if c.options.DynamicClient == nil {
+			return c.clientError(gvk, key, err)
+		}
+		klog.V(2).Infof("Failed to build the REST client of %s, getting %s with the dynamic client: %v", gvk, key, err)
+		result, err = getFromDynamicClient(ctx, c.options.DynamicClient, key, gvk, resourceVersion)
+	} else {
+		req := client.
+			Get().
+			NamespaceIfScoped(key.Namespace, key.Namespace != "").
+			Name(key.Name).
+			Resource(resource).
+			VersionedParams(&metav1.GetOptions{ResourceVersion: resourceVersion}, metav1.ParameterCodec)
+		if c.options.DryRunAPIClient {
+			req = req.Param("dryRun", metav1.DryRunAll)
+		}
+		result, err = req.Do(ctx).Get()
 	}
-	result, err := client.
-		Get().
-		NamespaceIfScoped(key.Namespace, key.Namespace != "").
-		Name(key.Name).
-		Resource(resource).
-		VersionedParams(&metav1.GetOptions{}, metav1.ParameterCodec).
-		Do(ctx).
-		Get()
 
-	if apierrors.IsNotFound(err) {
-		return err
-	} else if err != nil {
-		klog.Info("Failed to retrieve resource list", "error", err)
-		return err
+	if err != nil {
+		if apierrors.IsForbidden(err) {
+			c.recordForbidden(gvk, key, "get", err)
+		} else if !apierrors.IsNotFound(err) {
+			klog.Infof("Failed to retrieve %s %s from %s: %v", gvk, key, c.config.Host, err)
+		}
+		if err := c.getFromRegionalFallback(ctx, key, obj, gvk, err); err != nil {
+			if apierrors.IsNotFound(err) {
+				c.recordClientResult(gvk, nil)
+			} else {
+				c.recordClientResult(gvk, err)
+			}
+			return c.clientError(gvk, key, err)
+		}
+		c.recordClientResult(gvk, nil)
+		return nil
+	}
+	c.recordClientResult(gvk, nil)
+
+	// Don't accept the stale resource
+	if accessor, err := apimeta.Accessor(result); err == nil && resourceVersionLess(accessor.GetResourceVersion(), resourceVersion) {
+		return c.clientError(gvk, key, fmt.Errorf("resourceVersion %s is older than the requested %s", accessor.GetResourceVersion(), resourceVersion))
+	}
+
+	// The dry-run results don't affect the state of the cache
+	if !c.options.DryRunAPIClient {
+		// Raise the high watermark, so that the older object in the store is not returned
+		if c.rvTracker != nil {
+			c.rvTracker.observe(gvk, result)
+		}
+
+		c.storeWriteThrough(gvk, key, result)
 	}
 
 	// Copy the value of the item in the cache to the returned value
+	if converted, err := convertUnstructured(result, obj); err != nil {
+		return c.clientError(gvk, key, err)
+	} else if converted {
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		return nil
+	}
 	objVal := reflect.ValueOf(obj)
 	itemVal := reflect.ValueOf(result)
-	if !objVal.Type().AssignableTo(objVal.Type()) {
-		return fmt.Errorf("cache had type %s, but %s was asked for", itemVal.Type(), objVal.Type())
+	if !itemVal.Type().AssignableTo(objVal.Type()) {
+		return c.clientError(gvk, key, fmt.Errorf("api server returned type %s, but %s was asked for", itemVal.Type(), objVal.Type()))
 	}
 	reflect.Indirect(objVal).Set(reflect.Indirect(itemVal))
 	obj.GetObjectKind().SetGroupVersionKind(gvk)
@@ -225,13 +689,31 @@ func (c CSCache) getFromClient(ctx context.Context, key client.ObjectKey, obj ru
 	return nil
 }
 
+// clientError wraps the error of getFromClient with the identity of the resource and the cluster
+func (c *CSCache) clientError(gvk schema.GroupVersionKind, key client.ObjectKey, err error) error {
+	return fmt.Errorf("getFromClient GVK=%s namespace=%s name=%s cluster=%s: %w", gvk, key.Namespace, key.Name, c.config.Host, err)
+}
+
 // List lists items out of the indexer and writes them to list
-func (c CSCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+func (c *CSCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
 	gvk, err := apiutil.GVKForObject(list, c.Scheme)
 	if err != nil {
 		return err
 	}
-	if informer, ok := c.informerMap[gvk]; ok {
+	if routeCache, ok := c.routeList(ctx, gvk, opts); ok {
+		if err := routeCache.List(ctx, list, opts...); err != nil {
+			return err
+		}
+		return c.obfuscateList(ctx, gvk, list)
+	}
+	c.initLazyInformer(ctx, gvk)
+	if informer, ok := c.getInformer(gvk); ok {
+		atomic.AddUint64(&c.listHits, 1)
+
+		itemGVK, err := listToGVK(gvk)
+		if err != nil {
+			return err
+		}
 
 		var objList []interface{}
 
@@ -241,19 +723,26 @@ func (c CSCache) List(ctx context.Context, list client.ObjectList, opts ...clien
 		// Check the labelSelector
 		var labelSel labels.Selector
 		if listOpts.LabelSelector != nil {
-			labelSel = listOpts.LabelSelector
+			labelSel = c.compiledSelector(listOpts.LabelSelector)
 		}
 
 		if listOpts.FieldSelector != nil {
-			// combining multiple indices, GetIndexers, etc
-			field, val, requiresExact := requiresExactMatch(listOpts.FieldSelector)
+			fieldVals, requiresExact := requiresExactMatch(listOpts.FieldSelector)
 			if !requiresExact {
 				return fmt.Errorf("non-exact field matches are not supported by the cache")
 			}
+			for _, fv := range fieldVals {
+				if c.isIndexRemoved(itemGVK, fv.Field) {
+					return fmt.Errorf("index %s of %s has been removed", fv.Field, itemGVK)
+				}
+			}
 			// list all objects by the field selector.  If this is namespaced and we have one, ask for the
 			// namespaced index key.  Otherwise, ask for the non-namespaced variant by using the fake "all namespaces"
 			// namespace.
-			objList, err = informer.GetIndexer().ByIndex(FieldIndexName(field), KeyToNamespacedKey(listOpts.Namespace, val))
+			objList, err = byFieldIndexes(informer.GetIndexer(), listOpts.Namespace, fieldVals)
+		} else if key, value, ok := singleLabelValue(labelSel); ok && c.hasLabelIndex(informer, itemGVK, key) {
+			// look up the objects with the label value in the label index
+			objList, err = informer.GetIndexer().ByIndex(FieldIndexName(LabelIndexField(key)), KeyToNamespacedKey(listOpts.Namespace, value))
 		} else if listOpts.Namespace != "" {
 			objList, err = informer.GetIndexer().ByIndex(toolscache.NamespaceIndex, listOpts.Namespace)
 		} else {
@@ -292,89 +781,464 @@ func (c CSCache) List(ctx context.Context, list client.ObjectList, opts ...clien
 				}
 			}
 
-			outObj := obj.DeepCopyObject()
-			outObj.GetObjectKind().SetGroupVersionKind(listToGVK(gvk))
+			outObj, err := c.migrate(itemGVK, obj.DeepCopyObject())
+			if err != nil {
+				return err
+			}
+			if _, ok := list.(*unstructured.UnstructuredList); ok {
+				u := &unstructured.Unstructured{}
+				if _, err := convertUnstructured(outObj, u); err != nil {
+					return err
+				}
+				outObj = u
+			}
+			if fields, ok := projectedFields(opts); ok {
+				if outObj, err = project(outObj, fields); err != nil {
+					return err
+				}
+			}
+			outObj.GetObjectKind().SetGroupVersionKind(itemGVK)
+			if err := c.obfuscate(ctx, itemGVK, outObj); err != nil {
+				return err
+			}
 			runtimeObjList = append(runtimeObjList, outObj)
 		}
+		if hasSortedListOption(opts) {
+			sortObjects(runtimeObjList)
+		}
 		return apimeta.SetList(list, runtimeObjList)
 	}
 
 	// Passthrough
-	return c.fallback.List(ctx, list, opts...)
+	if err := c.listFallback(ctx, list, opts...); err != nil {
+		return err
+	}
+	if err := c.obfuscateList(ctx, gvk, list); err != nil {
+		return err
+	}
+	if hasSortedListOption(opts) {
+		return sortList(list)
+	}
+	return nil
+}
+
+// migrate migrates the object copied from the informer store to the newer schema of the GVK
+func (c *CSCache) migrate(gvk schema.GroupVersionKind, obj runtime.Object) (runtime.Object, error) {
+	migrateFunc, ok := c.options.SchemaMigrations[gvk]
+	if !ok {
+		return obj, nil
+	}
+	migrated, err := migrateFunc(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate %s to the new schema: %v", gvk, err)
+	}
+	return migrated, nil
 }
 
 // GetInformer fetches or constructs an informer for the given object that corresponds to a single
 // API kind and resource.
-func (c CSCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+func (c *CSCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
 	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
 	if err != nil {
 		return nil, err
 	}
 
-	if informer, ok := c.informerMap[gvk]; ok {
-		return informer, nil
+	// The informer of a lazy GVK is built here rather than by the fallback
+	c.registerLazyInformer(gvk)
+	if informer, ok := c.getInformer(gvk); ok {
+		return c.informerFor(gvk, informer), nil
 	}
 	// Passthrough
-	return c.fallback.GetInformer(ctx, obj)
+	return c.multiNamespaceInformerFor(ctx, gvk, func(fallback cache.Cache) (cache.Informer, error) {
+		return fallback.GetInformer(ctx, obj)
+	})
 }
 
 // GetInformerForKind is similar to GetInformer, except that it takes a group-version-kind, instead
 // of the underlying object.
-func (c CSCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
-	if informer, ok := c.informerMap[gvk]; ok {
-		return informer, nil
+func (c *CSCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	c.registerLazyInformer(gvk)
+	if informer, ok := c.getInformer(gvk); ok {
+		return c.informerFor(gvk, informer), nil
 	}
 	// Passthrough
-	return c.fallback.GetInformerForKind(ctx, gvk)
+	return c.multiNamespaceInformerFor(ctx, gvk, func(fallback cache.Cache) (cache.Informer, error) {
+		return fallback.GetInformerForKind(ctx, gvk)
+	})
+}
+
+// InformerForKey returns the informer serving the object of the GVK and true if the GVK is
+// watched by the informers of the cache, or false if the object is served by the fallback
+func (c *CSCache) InformerForKey(key client.ObjectKey, gvk schema.GroupVersionKind) (cache.Informer, bool) {
+	informer, ok := c.getInformer(gvk)
+	if !ok {
+		return nil, false
+	}
+	return c.informerFor(gvk, informer), true
 }
 
 // Start runs all the informers known to this cache until the given channel is closed.
 // It blocks.
-func (c CSCache) Start(ctx context.Context) error {
+func (c *CSCache) Start(ctx context.Context) error {
 	klog.Info("Start filtered cache")
-	for _, informer := range c.informerMap {
-		informer := informer
-		go informer.Run(ctx.Done())
+	c.statsMu.Lock()
+	c.startTime = time.Now()
+	c.statsMu.Unlock()
+
+	c.mu.Lock()
+	c.startCtx = ctx
+	c.addObserversLocked()
+	for gvk, informer := range c.informerMap {
+		// The List GVK shares the informer with its item GVK
+		if isListGVK(gvk) {
+			continue
+		}
+		c.startInformerLocked(gvk, informer)
+	}
+	c.mu.Unlock()
+
+	if c.options.ResyncHook != nil {
+		go c.runResyncHook(ctx)
 	}
+
+	if c.options.ElectedCh != nil || c.options.FollowerCh != nil {
+		go c.runLeaderElection(ctx)
+	}
+
+	if c.queueDepthGauge != nil {
+		go utilwait.UntilWithContext(ctx, c.updateQueueDepth, queueDepthPeriod)
+	}
+
+	for gvk, policy := range c.options.TTLEvictions {
+		go c.runTTLEviction(ctx, gvk, policy)
+	}
+	if c.options.WatchdogInterval > 0 {
+		go c.runWatchdog(ctx, c.options.WatchdogInterval)
+	}
+	c.namespaceMu.RLock()
+	for namespace, nsCache := range c.namespaceCaches {
+		namespace, nsCache := namespace, nsCache
+		go func() {
+			if err := nsCache.Start(ctx); err != nil {
+				klog.Errorf("Failed to start cache for namespace %s: %v", namespace, err)
+			}
+		}()
+	}
+	c.namespaceMu.RUnlock()
 	return c.fallback.Start(ctx)
 }
 
+// runInformer runs the informer until the context is done. If auto recovery is enabled for
+// the GVK, the informer is run by runRecoverable.
+func (c *CSCache) runInformer(ctx context.Context, gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) {
+	defer c.recordInformerExit(ctx, gvk)
+	c.setInformerRunning(gvk, true)
+	defer c.setInformerRunning(gvk, false)
+	// The informers shared from the factory are run once by the factory until the cache
+	// stops, they are not stopped with the context of the GVK
+	if c.isFactoryInformer(gvk, informer) {
+		c.mu.RLock()
+		startCtx := c.startCtx
+		c.mu.RUnlock()
+		c.options.InformerFactory.Start(startCtx.Done())
+		<-ctx.Done()
+		return
+	}
+
+	policy, ok := c.options.AutoRecovery[gvk]
+	if !ok {
+		informer.Run(ctx.Done())
+		return
+	}
+	// Only the rebuildable informers keep their event handlers and indexes once rebuilt
+	rebuildable, ok := informer.(*rebuildableInformer)
+	if !ok {
+		informer.Run(ctx.Done())
+		if ctx.Err() == nil {
+			klog.Errorf("Informer for %s exited, it can't be recovered without losing its event handlers", gvk)
+		}
+		return
+	}
+	c.runRecoverable(ctx, gvk, rebuildable, policy)
+}
+
+// runRecoverable runs the rebuildable informer until the context is done. The reflector of the
+// informer retries the failed list and watch calls without exiting, so the informer is rebuilt
+// and restarted with the event handlers, indexes and watch error handler of the failed one
+// once its list and watch have failed for the backoff of the policy, or once it has exited.
+// The informer is given up after MaxAttempts recoveries without a successful list or watch.
+func (c *CSCache) runRecoverable(ctx context.Context, gvk schema.GroupVersionKind, rebuildable *rebuildableInformer, policy RecoveryPolicy) {
+	backoff := policy.Backoff
+	attempts := 0
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		exited := make(chan struct{})
+		go func() {
+			defer close(exited)
+			rebuildable.Run(runCtx.Done())
+		}()
+		healthy := c.awaitWatchFailure(runCtx, rebuildable.watchHealth(), exited, backoff.Duration)
+		cancel()
+		<-exited
+		if ctx.Err() != nil {
+			return
+		}
+		if healthy {
+			backoff, attempts = policy.Backoff, 0
+		}
+		attempts++
+		if attempts > policy.MaxAttempts {
+			klog.Errorf("Informer for %s failed, giving up after %d recovery attempts", gvk, policy.MaxAttempts)
+			return
+		}
+		klog.Warningf("Informer for %s failed, recovering it (attempt %d/%d)", gvk, attempts, policy.MaxAttempts)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff.Step()):
+		}
+
+		next, health, err := rebuildable.rebuild()
+		if err != nil {
+			klog.Errorf("Failed to rebuild informer for %s: %v", gvk, err)
+			continue
+		}
+		// The informer is replaced under the lock of the cache, so that it isn't replaced
+		// again by a restart which has already stopped it
+		c.mu.Lock()
+		if ctx.Err() != nil {
+			c.mu.Unlock()
+			return
+		}
+		err = rebuildable.replace(next, health)
+		c.mu.Unlock()
+		if err != nil {
+			klog.Errorf("Failed to rebuild informer for %s: %v", gvk, err)
+		}
+	}
+}
+
+// awaitWatchFailure waits until the list and watch calls tracked by the health have failed
+// for the period, or the informer has exited, and reports whether a list or watch call has
+// succeeded meanwhile. The watch error handler of the informer records the failures as well.
+func (c *CSCache) awaitWatchFailure(ctx context.Context, health *watchHealth, exited <-chan struct{}, period time.Duration) bool {
+	if period <= 0 {
+		period = time.Second
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return health.succeeded()
+		case <-exited:
+			return health.succeeded()
+		case now := <-ticker.C:
+			if failingSince, _ := health.failing(); !failingSince.IsZero() && now.Sub(failingSince) >= period {
+				return health.succeeded()
+			}
+		}
+	}
+}
+
 // WaitForCacheSync waits for all the caches to sync.  Returns false if it could not sync a cache.
-func (c CSCache) WaitForCacheSync(ctx context.Context) bool {
+func (c *CSCache) WaitForCacheSync(ctx context.Context) bool {
 	// Wait for informer to sync
-	waiting := true
-	for waiting {
+	c.mu.RLock()
+	gvks := make([]schema.GroupVersionKind, 0, len(c.informerMap))
+	for gvk := range c.informerMap {
+		if !isListGVK(gvk) {
+			gvks = append(gvks, gvk)
+		}
+	}
+	c.mu.RUnlock()
+
+	var errs []error
+	for _, gvk := range gvks {
+		if _, err := c.WaitForGVKSync(ctx, gvk, 0); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.reportWarmUp(gvk)
+	}
+	if len(errs) > 0 {
+		klog.Errorf("Failed to sync %d of %d informers: %v", len(errs), len(gvks), utilerrors.NewAggregate(errs))
+		return false
+	}
+
+	// Wait for the caches of the added namespaces to sync
+	c.namespaceMu.RLock()
+	for _, nsCache := range c.namespaceCaches {
+		if !nsCache.WaitForCacheSync(ctx) {
+			c.namespaceMu.RUnlock()
+			return false
+		}
+	}
+	c.namespaceMu.RUnlock()
+	// Wait for fallback cache to sync
+	if !c.fallback.WaitForCacheSync(ctx) {
+		return false
+	}
+	atomic.StoreInt32(&c.cacheSynced, 1)
+	return true
+}
+
+// WaitForGVKSync waits for the informer of the GVK to sync, at most for the timeout if it is
+// not zero. The error wraps the error of the context, e.g. context.DeadlineExceeded.
+func (c *CSCache) WaitForGVKSync(ctx context.Context, gvk schema.GroupVersionKind, timeout time.Duration) (bool, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		c.mu.RLock()
+		informer, ok := c.informerMap[gvk]
+		synced := ok && c.hasSyncedLocked(gvk, informer)
+		c.mu.RUnlock()
+		if !ok {
+			return false, fmt.Errorf("failed to wait for %s to sync: it is not in the cache", gvk)
+		}
+		if synced {
+			c.recordSyncDuration(gvk)
+			return true, nil
+		}
+
 		select {
 		case <-ctx.Done():
-			waiting = false
+			return false, fmt.Errorf("failed to wait for %s to sync: %w", gvk, ctx.Err())
 		case <-time.After(time.Second):
-			if len(c.informerMap) == 0 {
-				waiting = false
-			} else {
-				currentWaiting := false
-				for _, informer := range c.informerMap {
-					currentWaiting = !informer.HasSynced() || currentWaiting
-				}
-				waiting = currentWaiting
-			}
 		}
 	}
-	// Wait for fallback cache to sync
-	return c.fallback.WaitForCacheSync(ctx)
+}
+
+// Len returns the number of objects held in the informer store of each GVK
+func (c *CSCache) Len() map[schema.GroupVersionKind]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	counts := make(map[schema.GroupVersionKind]int)
+	for gvk, informer := range c.informerMap {
+		// The List GVK shares the informer with its item GVK
+		if isListGVK(gvk) {
+			continue
+		}
+		counts[gvk] = len(informer.GetStore().List())
+	}
+	return counts
+}
+
+// GetAll returns a copy of all the objects of the GVK in the informer store. Unlike List, it
+// doesn't build a list of the GVK, so it works for the GVKs not registered in the scheme.
+func (c *CSCache) GetAll(ctx context.Context, gvk schema.GroupVersionKind) ([]runtime.Object, error) {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return nil, fmt.Errorf("failed to get all %s: it is not in the cache", gvk)
+	}
+	return c.copyObjects(ctx, informer.GetStore().List(), gvk)
+}
+
+// ListChunked calls fn sequentially with the objects of the GVK in the informer store in
+// chunks of at most chunkSize, so that only one chunk is copied at a time. The iteration
+// stops at the first error of fn, which is returned.
+func (c *CSCache) ListChunked(ctx context.Context, gvk schema.GroupVersionKind, chunkSize int, fn func(chunk []runtime.Object) error) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("failed to list %s in chunks: invalid chunk size %d", gvk, chunkSize)
+	}
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return fmt.Errorf("failed to list %s in chunks: it is not in the cache", gvk)
+	}
+	items := informer.GetStore().List()
+	for start := 0; start < len(items); start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := start + chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+		chunk, err := c.copyObjects(ctx, items[start:end], gvk)
+		if err != nil {
+			return err
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEach calls fn with a copy of each object of the GVK in the informer store, without
+// building a list of them, until fn returns false
+func (c *CSCache) ForEach(ctx context.Context, gvk schema.GroupVersionKind, fn func(runtime.Object) bool) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return fmt.Errorf("failed to iterate %s: it is not in the cache", gvk)
+	}
+	for _, item := range informer.GetStore().List() {
+		obj, err := c.copyObject(ctx, item, gvk)
+		if err != nil {
+			return err
+		}
+		if !fn(obj) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// copyObject returns a copy of the object of the GVK from the informer store, with the
+// secret fields redacted unless the context allows reading them
+func (c *CSCache) copyObject(ctx context.Context, item interface{}, gvk schema.GroupVersionKind) (runtime.Object, error) {
+	obj, isObj := item.(runtime.Object)
+	if !isObj {
+		return nil, fmt.Errorf("cache contained %T, which is not an Object", item)
+	}
+	obj = obj.DeepCopyObject()
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	if err := c.obfuscate(ctx, gvk, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// copyObjects returns a copy of the objects of the GVK from the informer store
+func (c *CSCache) copyObjects(ctx context.Context, items []interface{}, gvk schema.GroupVersionKind) ([]runtime.Object, error) {
+	objs := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		obj, err := c.copyObject(ctx, item, gvk)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
 }
 
 // IndexField adds an indexer to the underlying cache, using extraction function to get
 // value(s) from the given field. The filtered cache doesn't support the index yet.
-func (c CSCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+func (c *CSCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
 	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
 	if err != nil {
 		return err
 	}
 
-	if informer, ok := c.informerMap[gvk]; ok {
+	c.registerLazyInformer(gvk)
+	if informer, ok := c.getInformer(gvk); ok {
 		return indexByField(informer, field, extractValue)
 	}
 
+	informer, err := c.multiNamespaceInformerFor(ctx, gvk, func(fallback cache.Cache) (cache.Informer, error) {
+		return fallback.GetInformer(ctx, obj)
+	})
+	if err != nil {
+		return err
+	}
+	if informer, ok := informer.(*multiNamespaceInformer); ok {
+		return informer.indexField(ctx, obj, field, extractValue)
+	}
 	return c.fallback.IndexField(ctx, obj, field, extractValue)
 }
 
@@ -417,31 +1281,118 @@ func indexByField(indexer cache.Informer, field string, extractor client.Indexer
 	return indexer.AddIndexers(toolscache.Indexers{FieldIndexName(field): indexFunc})
 }
 
+// informerNamespace returns the namespace watched by the informer of the GVK. The cluster-scoped
+// GVKs are watched in all the namespaces, the namespace-scoped ones in the cache namespace.
+// The scope is looked up in the REST mapper, the cache namespace is used if it is unknown.
+func informerNamespace(opts cache.Options, gvk schema.GroupVersionKind) string {
+	if opts.Namespace == "" || opts.Mapper == nil {
+		return opts.Namespace
+	}
+	clusterScoped, err := isClusterScoped(opts.Mapper, gvk)
+	if err != nil {
+		klog.V(2).Infof("Failed to get the scope of %s, watching namespace %s: %v", gvk, opts.Namespace, err)
+		return opts.Namespace
+	}
+	if clusterScoped {
+		return ""
+	}
+	klog.Infof("%s in the cluster GVK list is namespace-scoped, watching namespace %s", gvk, opts.Namespace)
+	return opts.Namespace
+}
+
+// isClusterScoped looks up the scope of the GVK in the REST mapper
+func isClusterScoped(mapper apimeta.RESTMapper, gvk schema.GroupVersionKind) (bool, error) {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, err
+	}
+	return mapping.Scope.Name() == apimeta.RESTScopeNameRoot, nil
+}
+
 // kindToResource converts kind to resource
 func kindToResource(kind string) string {
 	kindToResourceMap := map[string]string{
 		"MutatingWebhookConfiguration":   "mutatingwebhookconfigurations",
 		"ValidatingWebhookConfiguration": "validatingwebhookconfigurations",
 	}
-	return kindToResourceMap[kind]
+	if resource, ok := kindToResourceMap[kind]; ok {
+		return resource
+	}
+	// Guess the resource of the kinds registered at runtime
+	plural, _ := apimeta.UnsafeGuessKindToResource(schema.GroupVersionKind{Kind: kind})
+	return plural.Resource
+}
+
+// isListGVK checks if the GVK is the List type of a resource
+func isListGVK(gvk schema.GroupVersionKind) bool {
+	return strings.HasSuffix(gvk.Kind, "List")
 }
 
 // listToGVK converts GVK list to GVK
-func listToGVK(list schema.GroupVersionKind) schema.GroupVersionKind {
-	return schema.GroupVersionKind{Group: list.Group, Version: list.Version, Kind: list.Kind[:len(list.Kind)-2]}
+func listToGVK(list schema.GroupVersionKind) (schema.GroupVersionKind, error) {
+	kind := strings.TrimSuffix(list.Kind, "List")
+	if !isListGVK(list) || kind == "" {
+		return schema.GroupVersionKind{}, fmt.Errorf("%s is not a list kind", list.Kind)
+	}
+	return schema.GroupVersionKind{Group: list.Group, Version: list.Version, Kind: kind}, nil
+}
+
+// fieldVal is a field and its value required by an exact match field selector
+type fieldVal struct {
+	Field, Value string
 }
 
-// requiresExactMatch checks if the given field selector is of the form `k=v` or `k==v`.
-func requiresExactMatch(sel fields.Selector) (field, val string, required bool) {
+// requiresExactMatch checks if every requirement of the given field selector is of the form `k=v` or `k==v`.
+func requiresExactMatch(sel fields.Selector) ([]fieldVal, bool) {
 	reqs := sel.Requirements()
-	if len(reqs) != 1 {
-		return "", "", false
+	if len(reqs) == 0 {
+		return nil, false
+	}
+	fieldVals := make([]fieldVal, 0, len(reqs))
+	for _, req := range reqs {
+		if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals {
+			return nil, false
+		}
+		fieldVals = append(fieldVals, fieldVal{Field: req.Field, Value: req.Value})
 	}
-	req := reqs[0]
-	if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals {
-		return "", "", false
+	return fieldVals, true
+}
+
+// byFieldIndexes looks up the objects matching every field value in the field indexes,
+// and returns the intersection of the results
+func byFieldIndexes(indexer toolscache.Indexer, namespace string, fieldVals []fieldVal) ([]interface{}, error) {
+	var objList []interface{}
+	for i, fv := range fieldVals {
+		indexed, err := indexer.ByIndex(FieldIndexName(fv.Field), KeyToNamespacedKey(namespace, fv.Value))
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			objList = indexed
+			continue
+		}
+
+		indexedKeys := make(map[string]bool, len(indexed))
+		for _, obj := range indexed {
+			key, err := toolscache.MetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return nil, err
+			}
+			indexedKeys[key] = true
+		}
+		intersection := make([]interface{}, 0, len(objList))
+		for _, obj := range objList {
+			key, err := toolscache.MetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return nil, err
+			}
+			if indexedKeys[key] {
+				intersection = append(intersection, obj)
+			}
+		}
+		objList = intersection
 	}
-	return req.Field, req.Value, true
+	return objList, nil
 }
 
 // FieldIndexName constructs the name of the index over the given field,
@@ -462,14 +1413,37 @@ func KeyToNamespacedKey(ns string, baseKey string) string {
 	return allNamespacesNamespace + "/" + baseKey
 }
 
-func getClientForGVK(gvk schema.GroupVersionKind, config *rest.Config, scheme *runtime.Scheme) (toolscache.Getter, error) {
+// getClientForGVK builds the REST client of the GVK. The codec factory of the GVK in
+// codecFactories is used if any, e.g. for the resources encoded in Protobuf, and the
+// impersonation set in the context by WithImpersonation is applied.
+func getClientForGVK(ctx context.Context, gvk schema.GroupVersionKind, config *rest.Config, scheme *runtime.Scheme, codecFactories map[schema.GroupVersionKind]runtime.NegotiatedSerializer) (*rest.RESTClient, error) {
 	gv := gvk.GroupVersion()
 	cfg := rest.CopyConfig(config)
+	if impersonate, ok := impersonationFrom(ctx); ok {
+		cfg.Impersonate = impersonate
+	}
 	cfg.GroupVersion = &gv
 	cfg.APIPath = "/apis"
+	if gvk.Group == "" {
+		// The resources of the core group are served under the legacy path
+		cfg.APIPath = "/api"
+	}
 	if cfg.UserAgent == "" {
 		cfg.UserAgent = rest.DefaultKubernetesUserAgent()
 	}
+	if codecFactory, ok := codecFactories[gvk]; ok {
+		cfg.NegotiatedSerializer = codecFactory
+		if cfg.AcceptContentTypes == "" {
+			var mediaTypes []string
+			for _, info := range codecFactory.SupportedMediaTypes() {
+				mediaTypes = append(mediaTypes, info.MediaType)
+			}
+			cfg.AcceptContentTypes = strings.Join(mediaTypes, ",")
+		}
+	}
+	if cfg.NegotiatedSerializer == nil && !scheme.Recognizes(gvk) {
+		cfg.NegotiatedSerializer = unstructuredNegotiatedSerializer
+	}
 	if cfg.NegotiatedSerializer == nil {
 		cfg.NegotiatedSerializer = serializer.WithoutConversionCodecFactory{CodecFactory: serializer.NewCodecFactory(scheme)}
 	}
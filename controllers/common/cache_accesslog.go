@@ -0,0 +1,108 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"sort"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// DefaultAccessLogRetention is how long the changes of the objects are kept by default
+const DefaultAccessLogRetention = time.Hour
+
+// enableAccessLog records the time the objects of the GVK are added or updated in the
+// informer store. The resyncs don't change the objects, so they are not recorded.
+func (c *CSCache) enableAccessLog(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) {
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.touch(gvk, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, err := apimeta.Accessor(oldObj)
+			if err != nil {
+				return
+			}
+			newMeta, err := apimeta.Accessor(newObj)
+			if err != nil {
+				return
+			}
+			if oldMeta.GetResourceVersion() != newMeta.GetResourceVersion() {
+				c.touch(gvk, newObj)
+			}
+		},
+	})
+}
+
+// touch records the object of the GVK as changed now
+func (c *CSCache) touch(gvk schema.GroupVersionKind, obj interface{}) {
+	key, err := toolscache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Failed to get the key of %s: %v", gvk, err)
+		return
+	}
+	c.recordTouch(gvk, key, time.Now())
+}
+
+// recordTouch records the object key of the GVK as changed at the time
+func (c *CSCache) recordTouch(gvk schema.GroupVersionKind, key string, at time.Time) {
+	c.accessLogMu.Lock()
+	defer c.accessLogMu.Unlock()
+	if c.accessLog == nil {
+		c.accessLog = make(map[schema.GroupVersionKind]map[string]time.Time)
+	}
+	if c.accessLog[gvk] == nil {
+		c.accessLog[gvk] = make(map[string]time.Time)
+	}
+	c.accessLog[gvk][key] = at
+}
+
+// forgetTouches forgets the changes of the objects of the GVK
+func (c *CSCache) forgetTouches(gvk schema.GroupVersionKind) {
+	c.accessLogMu.Lock()
+	defer c.accessLogMu.Unlock()
+	delete(c.accessLog, gvk)
+}
+
+// TouchedSince returns the sorted keys of the objects of the GVK added or updated in the
+// informer store after since. The changes older than the AccessLogRetention are purged.
+func (c *CSCache) TouchedSince(gvk schema.GroupVersionKind, since time.Time) []string {
+	retention := c.options.AccessLogRetention
+	if retention <= 0 {
+		retention = DefaultAccessLogRetention
+	}
+	expiry := time.Now().Add(-retention)
+
+	c.accessLogMu.Lock()
+	defer c.accessLogMu.Unlock()
+	var keys []string
+	for key, touched := range c.accessLog[gvk] {
+		if touched.Before(expiry) {
+			delete(c.accessLog[gvk], key)
+			continue
+		}
+		if touched.After(since) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
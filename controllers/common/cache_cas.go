@@ -0,0 +1,134 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// CompareAndSwap replaces the object of the GVK in the informer store with updated if the
+// stored object still has the resourceVersion of expected, and returns false on a stale read.
+// The swaps are serialized with each other, but not with the events of the informer. With
+// WriteThroughUpdates, the object is updated on the api server first, with the
+// resourceVersion of expected so that the api server rejects the update of an object changed
+// in the meantime, and the store is only updated with the result once the update succeeds.
+func (c *CSCache) CompareAndSwap(ctx context.Context, gvk schema.GroupVersionKind, expected runtime.Object, updated runtime.Object) (bool, error) {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return false, fmt.Errorf("failed to swap %s: it is not in the cache", gvk)
+	}
+	expectedMeta, err := apimeta.Accessor(expected)
+	if err != nil {
+		return false, err
+	}
+	key, err := toolscache.MetaNamespaceKeyFunc(expected)
+	if err != nil {
+		return false, err
+	}
+	if updatedKey, err := toolscache.MetaNamespaceKeyFunc(updated); err != nil {
+		return false, err
+	} else if updatedKey != key {
+		return false, fmt.Errorf("failed to swap %s %s with %s: the keys differ", gvk, key, updatedKey)
+	}
+	if c.options.WriteThroughUpdates && c.options.ReadOnly {
+		return false, ErrReadOnlyCache
+	}
+
+	store := informer.GetStore()
+	if !c.options.WriteThroughUpdates {
+		c.casMu.Lock()
+		defer c.casMu.Unlock()
+		if swappable, err := hasResourceVersion(store, key, expectedMeta.GetResourceVersion()); err != nil || !swappable {
+			return false, err
+		}
+		if err := store.Update(updated.DeepCopyObject()); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	// The mutex is not held during the update, the api server rejects the concurrent swaps
+	c.casMu.Lock()
+	swappable, err := hasResourceVersion(store, key, expectedMeta.GetResourceVersion())
+	c.casMu.Unlock()
+	if err != nil || !swappable {
+		return false, err
+	}
+	body := updated.DeepCopyObject()
+	bodyMeta, err := apimeta.Accessor(body)
+	if err != nil {
+		return false, err
+	}
+	bodyMeta.SetResourceVersion(expectedMeta.GetResourceVersion())
+	result, err := c.updateToClient(ctx, gvk, body)
+	if err != nil {
+		return false, err
+	}
+
+	// Keep the resourceVersion given by the api server, unless the informer has already
+	// stored a newer object
+	c.casMu.Lock()
+	defer c.casMu.Unlock()
+	if item, exists, err := store.GetByKey(key); err != nil {
+		return false, err
+	} else if exists && !resourceVersionLess(resourceVersionOf(item), resourceVersionOf(result)) {
+		return true, nil
+	}
+	if err := store.Update(result); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// hasResourceVersion checks if the object of the key in the store has the resourceVersion
+func hasResourceVersion(store toolscache.Store, key, resourceVersion string) (bool, error) {
+	item, exists, err := store.GetByKey(key)
+	if err != nil || !exists {
+		return false, err
+	}
+	return resourceVersionOf(item) == resourceVersion, nil
+}
+
+// updateToClient updates the object of the GVK on the api server and returns the result
+func (c *CSCache) updateToClient(ctx context.Context, gvk schema.GroupVersionKind, obj runtime.Object) (runtime.Object, error) {
+	meta, err := apimeta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	client, err := c.clientForGVK(ctx, gvk)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.
+		Put().
+		NamespaceIfScoped(meta.GetNamespace(), meta.GetNamespace() != "").
+		Resource(kindToResource(gvk.Kind)).
+		Name(meta.GetName()).
+		Body(obj).
+		Do(ctx).
+		Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update %s %s/%s on %s: %w", gvk, meta.GetNamespace(), meta.GetName(), c.config.Host, err)
+	}
+	return result, nil
+}
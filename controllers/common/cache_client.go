@@ -0,0 +1,75 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// pooledClient is a REST client in the client cache, with the host it was created for
+type pooledClient struct {
+	host   string
+	client *rest.RESTClient
+}
+
+// clientForGVK returns the REST client of the GVK from the client cache, so that the
+// connections of the client are reused across the requests. The cached client is
+// replaced when the host of the config has changed. The clients impersonating the
+// user set in the context are not cached.
+func (c *CSCache) clientForGVK(ctx context.Context, gvk schema.GroupVersionKind) (*rest.RESTClient, error) {
+	if _, ok := impersonationFrom(ctx); ok {
+		return getClientForGVK(ctx, gvk, c.config, c.Scheme, c.options.GVKCodecFactories)
+	}
+
+	if cached, ok := c.clientCache.Load(gvk); ok {
+		if pooled := cached.(pooledClient); pooled.host == c.config.Host {
+			return pooled.client, nil
+		}
+	}
+
+	client, err := getClientForGVK(ctx, gvk, c.config, c.Scheme, c.options.GVKCodecFactories)
+	if err != nil {
+		return nil, err
+	}
+	c.clientCache.Store(gvk, pooledClient{host: c.config.Host, client: client})
+	return client, nil
+}
+
+type impersonationKey struct{}
+
+// WithImpersonation makes the requests sent to the api server by the cache with the context
+// impersonate the user, e.g. the service account of a tenant
+func WithImpersonation(ctx context.Context, impersonate rest.ImpersonationConfig) context.Context {
+	return context.WithValue(ctx, impersonationKey{}, impersonate)
+}
+
+// impersonationFrom returns the impersonation set in the context by WithImpersonation
+func impersonationFrom(ctx context.Context) (rest.ImpersonationConfig, bool) {
+	impersonate, ok := ctx.Value(impersonationKey{}).(rest.ImpersonationConfig)
+	return impersonate, ok
+}
+
+// ClearClientCache drops all the cached REST clients, so that they are recreated on the next request
+func (c *CSCache) ClearClientCache() {
+	c.clientCache.Range(func(key, _ interface{}) bool {
+		c.clientCache.Delete(key)
+		return true
+	})
+}
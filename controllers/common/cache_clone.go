@@ -0,0 +1,243 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Clone returns a view of the cache that only shows the objects of the watchNamespaces and the
+// cluster-scoped objects. The view shares the informers of the cache, including the ones
+// registered later, and doesn't start or stop them.
+func (c *CSCache) Clone(watchNamespaces []string) (cache.Cache, error) {
+	if len(watchNamespaces) == 0 {
+		return nil, fmt.Errorf("failed to clone cache: no namespace to watch")
+	}
+	namespaces := make(map[string]bool, len(watchNamespaces))
+	for _, ns := range watchNamespaces {
+		namespaces[ns] = true
+	}
+	return &namespacedView{cache: c, namespaces: namespaces}, nil
+}
+
+// ErrNamespaceForbidden is returned when a NamespacedCSCache is asked for the objects of
+// another namespace
+var ErrNamespaceForbidden = errors.New("access to the namespace is forbidden")
+
+// NamespacedCSCache is the view of the CSCache scoped to a single namespace. The namespace is
+// injected into the Get and List calls without one, and the informers only deliver the events
+// of the namespace.
+type NamespacedCSCache struct {
+	*namespacedView
+	namespace string
+}
+
+// ScopeToNamespace returns the view of the cache scoped to the namespace
+func (c *CSCache) ScopeToNamespace(namespace string) *NamespacedCSCache {
+	return &NamespacedCSCache{
+		namespacedView: &namespacedView{cache: c, namespaces: map[string]bool{namespace: true}},
+		namespace:      namespace,
+	}
+}
+
+// Namespace returns the namespace of the view
+func (n *NamespacedCSCache) Namespace() string {
+	return n.namespace
+}
+
+// Get implements Reader, the objects of the other namespaces are forbidden. The cluster-scoped
+// objects are not scoped.
+func (n *NamespacedCSCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, n.cache.Scheme)
+	if err != nil {
+		return err
+	}
+	if n.clusterScoped(gvk) {
+		return n.cache.Get(ctx, key, obj)
+	}
+	if key.Namespace == corev1.NamespaceAll {
+		key.Namespace = n.namespace
+	}
+	if key.Namespace != n.namespace {
+		return fmt.Errorf("failed to get %s: %w", key, ErrNamespaceForbidden)
+	}
+	return n.cache.Get(ctx, key, obj)
+}
+
+// List implements Reader, the objects of the other namespaces are forbidden. The cluster-scoped
+// objects are not scoped.
+func (n *NamespacedCSCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listGVK, err := apiutil.GVKForObject(list, n.cache.Scheme)
+	if err != nil {
+		return err
+	}
+	gvk, err := listToGVK(listGVK)
+	if err != nil {
+		return err
+	}
+	if n.clusterScoped(gvk) {
+		return n.cache.List(ctx, list, opts...)
+	}
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.Namespace == corev1.NamespaceAll {
+		opts = append(opts, client.InNamespace(n.namespace))
+	} else if listOpts.Namespace != n.namespace {
+		return fmt.Errorf("failed to list namespace %s: %w", listOpts.Namespace, ErrNamespaceForbidden)
+	}
+	return n.cache.List(ctx, list, opts...)
+}
+
+// clusterScoped checks if the GVK is cluster-scoped in the REST mapper of the cache. The GVKs
+// of unknown scope are scoped to the namespace.
+func (n *NamespacedCSCache) clusterScoped(gvk schema.GroupVersionKind) bool {
+	if n.cache.cacheOpts.Mapper == nil {
+		return false
+	}
+	clusterScoped, err := isClusterScoped(n.cache.cacheOpts.Mapper, gvk)
+	return err == nil && clusterScoped
+}
+
+// namespacedView is the view of the CSCache filtered by the namespaces
+type namespacedView struct {
+	cache      *CSCache
+	namespaces map[string]bool
+}
+
+// inView checks if the objects of the namespace are in the view
+func (v *namespacedView) inView(namespace string) bool {
+	return namespace == corev1.NamespaceAll || v.namespaces[namespace]
+}
+
+// Get implements Reader
+func (v *namespacedView) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if !v.inView(key.Namespace) {
+		gvk, err := apiutil.GVKForObject(obj, v.cache.Scheme)
+		if err != nil {
+			return err
+		}
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.String())
+	}
+	return v.cache.Get(ctx, key, obj)
+}
+
+// List implements Reader
+func (v *namespacedView) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if !v.inView(listOpts.Namespace) {
+		return apimeta.SetList(list, nil)
+	}
+	if err := v.cache.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	if listOpts.Namespace != corev1.NamespaceAll {
+		return nil
+	}
+
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var filtered []runtime.Object
+	for _, item := range items {
+		meta, err := apimeta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if v.inView(meta.GetNamespace()) {
+			filtered = append(filtered, item)
+		}
+	}
+	return apimeta.SetList(list, filtered)
+}
+
+// GetInformer returns the informer of the obj delivering the events of the view
+func (v *namespacedView) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+	informer, err := v.cache.GetInformer(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedInformer{Informer: informer, view: v}, nil
+}
+
+// GetInformerForKind returns the informer of the GVK delivering the events of the view
+func (v *namespacedView) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	informer, err := v.cache.GetInformerForKind(ctx, gvk)
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedInformer{Informer: informer, view: v}, nil
+}
+
+// IndexField adds the index to the shared informers
+func (v *namespacedView) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	return v.cache.IndexField(ctx, obj, field, extractValue)
+}
+
+// Start doesn't start the shared informers, it blocks until the context is done
+func (v *namespacedView) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// WaitForCacheSync waits for the shared informers to sync
+func (v *namespacedView) WaitForCacheSync(ctx context.Context) bool {
+	return v.cache.WaitForCacheSync(ctx)
+}
+
+// namespacedInformer only delivers the events of the objects in the view to the handlers
+type namespacedInformer struct {
+	cache.Informer
+	view *namespacedView
+}
+
+// AddEventHandler adds the handler behind the namespace filter
+func (i *namespacedInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.Informer.AddEventHandler(i.wrap(handler))
+}
+
+// AddEventHandlerWithResyncPeriod adds the handler behind the namespace filter
+func (i *namespacedInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.Informer.AddEventHandlerWithResyncPeriod(i.wrap(handler), resyncPeriod)
+}
+
+func (i *namespacedInformer) wrap(handler toolscache.ResourceEventHandler) toolscache.ResourceEventHandler {
+	return toolscache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			meta, err := apimeta.Accessor(obj)
+			return err == nil && i.view.inView(meta.GetNamespace())
+		},
+		Handler: handler,
+	}
+}
@@ -0,0 +1,250 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// CorrelationIDAnnotation correlates the objects of a dependent GVK with the object of the
+// GVK they depend on
+const CorrelationIDAnnotation = "operator.ibm.com/correlation-id"
+
+const (
+	// consistencyGateTimeout is how long the events of a correlation ID are held back before
+	// they are dropped
+	consistencyGateTimeout = 5 * time.Minute
+	// maxPendingEvents is the number of events held back per correlation ID, the oldest
+	// events are dropped beyond it
+	maxPendingEvents = 100
+)
+
+// consistencyGate holds back the events of a dependent GVK until the event of the GVK it
+// depends on with the same correlation ID has been processed. The events are delivered under
+// the lock of the gate, so that the buffered events delivered on release are not delivered
+// concurrently with, or after, the following events of the dependent GVK.
+type consistencyGate struct {
+	dependsOn  schema.GroupVersionKind
+	timeout    time.Duration
+	maxPending int
+
+	mu sync.Mutex
+	// released holds the correlation IDs of the objects of the GVK depended on, they are
+	// removed once the objects are deleted or no longer use them
+	released map[string]bool
+	pending  map[string]*pendingEvents
+	// open lets all the events through once the GVK depended on has been deregistered
+	open bool
+}
+
+// pendingEvents are the events held back for a correlation ID, dropped when the timer fires
+type pendingEvents struct {
+	deliverFuncs []func()
+	dropped      int
+	timer        *time.Timer
+}
+
+func newConsistencyGate(dependsOn schema.GroupVersionKind, timeout time.Duration, maxPending int) *consistencyGate {
+	return &consistencyGate{
+		dependsOn:  dependsOn,
+		timeout:    timeout,
+		maxPending: maxPending,
+		released:   make(map[string]bool),
+		pending:    make(map[string]*pendingEvents),
+	}
+}
+
+// deliver delivers the event of the dependent GVK, or buffers it until the correlation ID is released
+func (g *consistencyGate) deliver(obj interface{}, deliverFunc func()) {
+	id := correlationID(obj)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if id == "" || g.open || g.released[id] {
+		deliverFunc()
+		return
+	}
+	p, ok := g.pending[id]
+	if !ok {
+		p = &pendingEvents{}
+		p.timer = time.AfterFunc(g.timeout, func() { g.expire(id, p) })
+		g.pending[id] = p
+	}
+	if len(p.deliverFuncs) >= g.maxPending {
+		p.deliverFuncs = p.deliverFuncs[1:]
+		p.dropped++
+	}
+	p.deliverFuncs = append(p.deliverFuncs, deliverFunc)
+}
+
+// expire drops the events of the correlation ID still held back after the timeout
+func (g *consistencyGate) expire(id string, p *pendingEvents) {
+	g.mu.Lock()
+	if g.pending[id] != p {
+		g.mu.Unlock()
+		return
+	}
+	delete(g.pending, id)
+	g.mu.Unlock()
+	klog.Warningf("Dropped %d events of correlation ID %s held back for %v without the event they depend on", len(p.deliverFuncs)+p.dropped, id, g.timeout)
+}
+
+// release delivers the buffered events of the correlation ID, and lets the following ones
+// through. The buffer is drained before the following events are let through.
+func (g *consistencyGate) release(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if p, ok := g.pending[id]; ok {
+		delete(g.pending, id)
+		p.timer.Stop()
+		if p.dropped > 0 {
+			klog.Warningf("Dropped %d events of correlation ID %s beyond the %d held back", p.dropped, id, g.maxPending)
+		}
+		for _, deliverFunc := range p.deliverFuncs {
+			deliverFunc()
+		}
+	}
+	g.released[id] = true
+}
+
+// reset holds back the following events of the correlation ID again
+func (g *consistencyGate) reset(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.released, id)
+}
+
+// clear drops the buffered events and the released correlation IDs. The gate lets all the
+// events through once the GVK depended on is gone.
+func (g *consistencyGate) clear(open bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for id, p := range g.pending {
+		p.timer.Stop()
+		delete(g.pending, id)
+	}
+	g.released = make(map[string]bool)
+	g.open = g.open || open
+}
+
+// parentHandler releases the correlation IDs of the events of the GVK depended on
+func (g *consistencyGate) parentHandler() toolscache.ResourceEventHandlerFuncs {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if id := correlationID(obj); id != "" {
+				g.release(id)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			id := correlationID(newObj)
+			// The correlation ID no longer used by the object is not released anymore
+			if oldID := correlationID(oldObj); oldID != "" && oldID != id {
+				g.reset(oldID)
+			}
+			if id != "" {
+				g.release(id)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if id := correlationID(obj); id != "" {
+				g.reset(id)
+			}
+		},
+	}
+}
+
+func correlationID(obj interface{}) string {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	meta, err := apimeta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return meta.GetAnnotations()[CorrelationIDAnnotation]
+}
+
+// enableCrossGVKConsistency gates the events of the GVK on the events of the GVK it depends on
+func (c *CSCache) enableCrossGVKConsistency(gvk, dependsOn schema.GroupVersionKind) error {
+	if _, ok := c.getInformer(gvk); !ok {
+		return fmt.Errorf("failed to enable cross GVK consistency: %s is not in the cluster GVK list", gvk)
+	}
+	parent, ok := c.getInformer(dependsOn)
+	if !ok {
+		return fmt.Errorf("failed to enable cross GVK consistency: %s is not in the cluster GVK list", dependsOn)
+	}
+
+	gate := newConsistencyGate(dependsOn, consistencyGateTimeout, maxPendingEvents)
+	if c.gates == nil {
+		c.gates = make(map[schema.GroupVersionKind]*consistencyGate)
+	}
+	c.gates[gvk] = gate
+	parent.AddEventHandler(gate.parentHandler())
+	return nil
+}
+
+// clearGates clears the consistency gate of the deregistered GVK, and opens the gates of the
+// GVKs depending on it
+func (c *CSCache) clearGates(gvk schema.GroupVersionKind) {
+	if gate, ok := c.gates[gvk]; ok {
+		gate.clear(false)
+	}
+	for dependent, gate := range c.gates {
+		if gate.dependsOn == gvk {
+			klog.Warningf("%s has been deregistered, the events of %s are no longer held back", gvk, dependent)
+			gate.clear(true)
+		}
+	}
+}
+
+// gatedInformer delivers the events to the handlers through the consistency gate. The
+// buffered events are delivered by the goroutine processing the event they depend on, under
+// the lock of the gate.
+type gatedInformer struct {
+	toolscache.SharedIndexInformer
+	gate *consistencyGate
+}
+
+// AddEventHandler adds the handler behind the consistency gate
+func (i *gatedInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.SharedIndexInformer.AddEventHandler(i.wrap(handler))
+}
+
+// AddEventHandlerWithResyncPeriod adds the handler behind the consistency gate
+func (i *gatedInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.SharedIndexInformer.AddEventHandlerWithResyncPeriod(i.wrap(handler), resyncPeriod)
+}
+
+func (i *gatedInformer) wrap(handler toolscache.ResourceEventHandler) toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			i.gate.deliver(obj, func() { handler.OnAdd(obj) })
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			i.gate.deliver(newObj, func() { handler.OnUpdate(oldObj, newObj) })
+		},
+		DeleteFunc: func(obj interface{}) {
+			i.gate.deliver(obj, func() { handler.OnDelete(obj) })
+		},
+	}
+}
@@ -0,0 +1,163 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"errors"
+	goruntime "runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// DefaultCostModelAllocEvery is the period in events of the heap allocation samples by default
+const DefaultCostModelAllocEvery = 100
+
+// costModel records the cost of processing the informer events of each GVK
+type costModel struct {
+	// events counts the events measured, accessed atomically
+	events uint64
+	// allocEvery is the period in events of the allocation samples, 0 if not sampled
+	allocEvery uint64
+
+	cpuSeconds *prometheus.HistogramVec
+	allocBytes *prometheus.HistogramVec
+}
+
+// newCostModel registers the histograms of the cost model. The heap allocation is sampled
+// on one of every allocEvery events, since reading it stops the world, DefaultCostModelAllocEvery
+// if 0, and it is not sampled if negative.
+func newCostModel(registry prometheus.Registerer, allocEvery int) (*costModel, error) {
+	cpuSeconds := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cs_cache_event_cpu_seconds",
+		Help:    "Time spent by the event handlers processing an informer event",
+		Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+	}, []string{"gvk"})
+	allocBytes := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cs_cache_event_alloc_bytes",
+		Help:    "Heap allocated by the event handlers processing an informer event",
+		Buckets: prometheus.ExponentialBuckets(256, 4, 10),
+	}, []string{"gvk"})
+
+	var err error
+	if cpuSeconds, err = registerHistogram(registry, cpuSeconds); err != nil {
+		return nil, err
+	}
+	model := &costModel{cpuSeconds: cpuSeconds}
+	if allocEvery == 0 {
+		allocEvery = DefaultCostModelAllocEvery
+	}
+	if allocEvery > 0 {
+		if allocBytes, err = registerHistogram(registry, allocBytes); err != nil {
+			return nil, err
+		}
+		model.allocEvery = uint64(allocEvery)
+		model.allocBytes = allocBytes
+	}
+	return model, nil
+}
+
+// registerHistogram registers the histogram, or returns the one already registered
+func registerHistogram(registry prometheus.Registerer, histogram *prometheus.HistogramVec) (*prometheus.HistogramVec, error) {
+	if err := registry.Register(histogram); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(*prometheus.HistogramVec); ok {
+				return existing, nil
+			}
+		}
+		return nil, err
+	}
+	return histogram, nil
+}
+
+// measure runs the handler of the event and records its cost. The time spent is measured
+// by the wall clock of the handler goroutine. The allocation is sampled on the first event
+// and then one of every allocEvery events, and measured by the growth of the heap
+// allocation total, which also counts the concurrent allocations of other goroutines.
+func (m *costModel) measure(gvk schema.GroupVersionKind, handler func()) {
+	sampled := m.allocEvery > 0 && (atomic.AddUint64(&m.events, 1)-1)%m.allocEvery == 0
+	var before, after goruntime.MemStats
+	if sampled {
+		goruntime.ReadMemStats(&before)
+	}
+	start := time.Now()
+
+	handler()
+
+	m.cpuSeconds.WithLabelValues(gvk.String()).Observe(time.Since(start).Seconds())
+	if sampled {
+		goruntime.ReadMemStats(&after)
+		m.allocBytes.WithLabelValues(gvk.String()).Observe(float64(after.TotalAlloc - before.TotalAlloc))
+	}
+}
+
+// costInformer measures the event handlers added to the informer
+type costInformer struct {
+	toolscache.SharedIndexInformer
+	gvk   schema.GroupVersionKind
+	model *costModel
+}
+
+var _ cache.Informer = &costInformer{}
+
+// AddEventHandler adds the handler measured by the cost model
+func (i *costInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.SharedIndexInformer.AddEventHandler(i.wrap(handler))
+}
+
+// AddEventHandlerWithResyncPeriod adds the handler measured by the cost model
+func (i *costInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.SharedIndexInformer.AddEventHandlerWithResyncPeriod(i.wrap(handler), resyncPeriod)
+}
+
+func (i *costInformer) wrap(handler toolscache.ResourceEventHandler) toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			i.model.measure(i.gvk, func() { handler.OnAdd(obj) })
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			i.model.measure(i.gvk, func() { handler.OnUpdate(oldObj, newObj) })
+		},
+		DeleteFunc: func(obj interface{}) {
+			i.model.measure(i.gvk, func() { handler.OnDelete(obj) })
+		},
+	}
+}
+
+// informerFor returns the informer handed out to the callers of the cache, which wraps the
+// event handlers with the consistency gate and the cost model of the GVK, and queues their
+// events when the metrics are enabled
+func (c *CSCache) informerFor(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) cache.Informer {
+	if c.options.ElectedCh != nil || c.options.FollowerCh != nil {
+		informer = &suspendableInformer{SharedIndexInformer: informer, gvk: gvk, cache: c}
+	}
+	if gate, ok := c.gates[gvk]; ok {
+		informer = &gatedInformer{SharedIndexInformer: informer, gate: gate}
+	}
+	if c.costModel != nil {
+		informer = &costInformer{SharedIndexInformer: informer, gvk: gvk, model: c.costModel}
+	}
+	if c.queueDepthGauge != nil {
+		informer = &queueInformer{SharedIndexInformer: informer, depth: c.queueDepthFor(gvk)}
+	}
+	return informer
+}
@@ -0,0 +1,125 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// PatchEventHandler handles the UPDATE events of a delta based GVK
+type PatchEventHandler interface {
+	// OnPatch is called with the key of the updated object and the JSON merge patch
+	// from the old object to the new one
+	OnPatch(gvk schema.GroupVersionKind, key string, patch []byte)
+}
+
+// PatchEventHandlerFunc is an adapter to use a function as PatchEventHandler
+type PatchEventHandlerFunc func(gvk schema.GroupVersionKind, key string, patch []byte)
+
+// OnPatch calls f(gvk, key, patch)
+func (f PatchEventHandlerFunc) OnPatch(gvk schema.GroupVersionKind, key string, patch []byte) {
+	f(gvk, key, patch)
+}
+
+// AddPatchEventHandler adds a handler for the UPDATE events of the delta based GVK
+func (c *CSCache) AddPatchEventHandler(gvk schema.GroupVersionKind, handler PatchEventHandler) error {
+	c.patchMu.Lock()
+	defer c.patchMu.Unlock()
+	if _, ok := c.patchHandlers[gvk]; !ok {
+		return fmt.Errorf("delta based reconcile is not enabled for %s", gvk)
+	}
+	c.patchHandlers[gvk] = append(c.patchHandlers[gvk], handler)
+	return nil
+}
+
+// enableDeltaEvents registers the informer event handler computing the patches of the GVK
+func (c *CSCache) enableDeltaEvents(gvk schema.GroupVersionKind) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok {
+		return fmt.Errorf("failed to enable delta based reconcile: %s is not in the cluster GVK list", gvk)
+	}
+
+	c.patchMu.Lock()
+	if c.patchHandlers == nil {
+		c.patchHandlers = make(map[schema.GroupVersionKind][]PatchEventHandler)
+	}
+	if _, ok := c.patchHandlers[gvk]; !ok {
+		c.patchHandlers[gvk] = []PatchEventHandler{}
+	}
+	c.patchMu.Unlock()
+
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			key, err := toolscache.MetaNamespaceKeyFunc(newObj)
+			if err != nil {
+				klog.Errorf("Failed to get the key of %s: %v", gvk, err)
+				return
+			}
+			patch, err := createMergePatch(oldObj, newObj)
+			if err != nil {
+				klog.Errorf("Failed to compute the patch of %s %s: %v", gvk, key, err)
+				return
+			}
+
+			c.patchMu.RLock()
+			handlers := c.patchHandlers[gvk]
+			c.patchMu.RUnlock()
+			for _, handler := range handlers {
+				handler.OnPatch(gvk, key, patch)
+			}
+		},
+	})
+	return nil
+}
+
+// createMergePatch computes the JSON merge patch from the old object to the new one
+func createMergePatch(oldObj, newObj interface{}) ([]byte, error) {
+	oldData, err := json.Marshal(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := json.Marshal(newObj)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.CreateMergePatch(oldData, newData)
+}
+
+// ApplyPatch applies the JSON merge patch delivered to a PatchEventHandler on the base object
+func ApplyPatch(base runtime.Object, patch []byte) error {
+	baseData, err := json.Marshal(base)
+	if err != nil {
+		return err
+	}
+	patchedData, err := jsonpatch.MergePatch(baseData, patch)
+	if err != nil {
+		return err
+	}
+
+	// Reset the base object, so that the fields removed by the patch are not kept
+	baseVal := reflect.Indirect(reflect.ValueOf(base))
+	baseVal.Set(reflect.Zero(baseVal.Type()))
+	return json.Unmarshal(patchedData, base)
+}
@@ -0,0 +1,116 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DiffStore keeps the previous version of the objects returned by Get, and the JSON merge
+// patch between the previous and the latest version
+type DiffStore struct {
+	mu       sync.Mutex
+	previous map[schema.GroupVersionKind]map[string]runtime.Object
+	diffs    map[schema.GroupVersionKind]map[string][]byte
+}
+
+// record stores the object as the latest version and computes the patch from the previous one
+func (s *DiffStore) record(gvk schema.GroupVersionKind, key string, obj runtime.Object) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.previous == nil {
+		s.previous = make(map[schema.GroupVersionKind]map[string]runtime.Object)
+		s.diffs = make(map[schema.GroupVersionKind]map[string][]byte)
+	}
+	if s.previous[gvk] == nil {
+		s.previous[gvk] = make(map[string]runtime.Object)
+		s.diffs[gvk] = make(map[string][]byte)
+	}
+
+	var patch []byte
+	if prev, ok := s.previous[gvk][key]; ok {
+		var err error
+		if patch, err = createMergePatch(prev, obj); err != nil {
+			klog.Errorf("Failed to compute the diff of %s %s: %v", gvk, key, err)
+		}
+	}
+	s.previous[gvk][key] = obj.DeepCopyObject()
+	s.diffs[gvk][key] = patch
+}
+
+// get returns the last patch of the object
+func (s *DiffStore) get(gvk schema.GroupVersionKind, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	patch, ok := s.diffs[gvk][key]
+	return patch, ok
+}
+
+// evict removes the object from the store
+func (s *DiffStore) evict(gvk schema.GroupVersionKind, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.previous[gvk], key)
+	delete(s.diffs[gvk], key)
+}
+
+// evictGVK removes all the objects of the GVK from the store
+func (s *DiffStore) evictGVK(gvk schema.GroupVersionKind) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.previous, gvk)
+	delete(s.diffs, gvk)
+}
+
+// LastDiff returns the JSON merge patch between the last two versions of the object returned
+// by Get. The patch is nil if Get has returned the object only once.
+func (c *CSCache) LastDiff(key client.ObjectKey, gvk schema.GroupVersionKind) ([]byte, error) {
+	patch, ok := c.diffs.get(gvk, diffKey(key))
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.String())
+	}
+	return patch, nil
+}
+
+// enableDiffEviction evicts the deleted objects of the GVK from the DiffStore
+func (c *CSCache) enableDiffEviction(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) {
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			key, err := toolscache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				klog.Errorf("Failed to get the key of %s: %v", gvk, err)
+				return
+			}
+			c.diffs.evict(gvk, key)
+		},
+	})
+}
+
+// diffKey returns the key of the object in the same form as the informer store
+func diffKey(key client.ObjectKey) string {
+	if key.Namespace == "" {
+		return key.Name
+	}
+	return key.Namespace + "/" + key.Name
+}
@@ -0,0 +1,90 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog"
+)
+
+var crdGVK = apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition")
+
+// DiscoverCRDGVKs lists the cluster-scoped CustomResourceDefinitions matching the label selector
+// from the api server and returns the GVKs of their served versions. Only the GVKs registered
+// in the scheme are returned, since the informers are built for the typed objects.
+func DiscoverCRDGVKs(ctx context.Context, config *rest.Config, scheme *runtime.Scheme, labelSelector string) ([]schema.GroupVersionKind, error) {
+	crdScheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(crdScheme); err != nil {
+		return nil, err
+	}
+	client, err := getClientForGVK(ctx, crdGVK, config, crdScheme, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := client.
+		Get().
+		Resource("customresourcedefinitions").
+		VersionedParams(&metav1.ListOptions{LabelSelector: labelSelector}, metav1.ParameterCodec).
+		Do(ctx).
+		Into(crdList); err != nil {
+		return nil, fmt.Errorf("failed to list the CustomResourceDefinitions: %v", err)
+	}
+
+	var gvks []schema.GroupVersionKind
+	for _, crd := range crdList.Items {
+		if crd.Spec.Scope != apiextensionsv1.ClusterScoped {
+			continue
+		}
+		for _, version := range crd.Spec.Versions {
+			if !version.Served {
+				continue
+			}
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			if !scheme.Recognizes(gvk) {
+				klog.V(2).Infof("Skipped the discovered %s: it is not registered in the scheme", gvk)
+				continue
+			}
+			gvks = append(gvks, gvk)
+		}
+	}
+	return gvks, nil
+}
+
+// mergeGVKs appends the GVKs missing in the list
+func mergeGVKs(gvkList []schema.GroupVersionKind, gvks []schema.GroupVersionKind) []schema.GroupVersionKind {
+	merged := append([]schema.GroupVersionKind{}, gvkList...)
+	seen := make(map[schema.GroupVersionKind]bool, len(gvkList))
+	for _, gvk := range gvkList {
+		seen[gvk] = true
+	}
+	for _, gvk := range gvks {
+		if !seen[gvk] {
+			seen[gvk] = true
+			merged = append(merged, gvk)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,59 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Drain deletes all the objects of the GVK from the informer store and the write-through
+// cache, e.g. when the CRD is uninstalled, so that Get falls through to the api server.
+// The informer isn't stopped, the objects are added back by its next events or resync.
+func (c *CSCache) Drain(ctx context.Context, gvk schema.GroupVersionKind) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return fmt.Errorf("failed to drain %s: it is not in the cache", gvk)
+	}
+
+	store := informer.GetStore()
+	for _, key := range store.ListKeys() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item, exists, err := store.GetByKey(key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err := store.Delete(item); err != nil {
+			return fmt.Errorf("failed to drain %s %s: %v", gvk, key, err)
+		}
+	}
+
+	c.writeThrough.Range(func(k, _ interface{}) bool {
+		if k.(writeThroughKey).gvk == gvk {
+			c.writeThrough.Delete(k)
+		}
+		return true
+	})
+	return nil
+}
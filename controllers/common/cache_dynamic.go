@@ -0,0 +1,61 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dynamicResource returns the dynamic client of the resource of the GVK in the namespace
+func dynamicResource(dynamicClient dynamic.Interface, gvk schema.GroupVersionKind, namespace string) dynamic.ResourceInterface {
+	gvr := gvk.GroupVersion().WithResource(kindToResource(gvk.Kind))
+	if namespace == "" {
+		return dynamicClient.Resource(gvr)
+	}
+	return dynamicClient.Resource(gvr).Namespace(namespace)
+}
+
+// newDynamicListWatch lists and watches the unstructured objects of the GVK in the namespace
+// with the dynamic client, like NewFilteredListWatchFromClient does with the REST client
+func newDynamicListWatch(dynamicClient dynamic.Interface, gvk schema.GroupVersionKind, namespace string, tweak TweakListOptionsFunc) *toolscache.ListWatch {
+	resource := dynamicResource(dynamicClient, gvk, namespace)
+	return &toolscache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			tweak(&options)
+			return resource.List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			tweak(&options)
+			return resource.Watch(context.TODO(), options)
+		},
+	}
+}
+
+// getFromDynamicClient gets the unstructured object of the key at least as new as the
+// resourceVersion with the dynamic client
+func getFromDynamicClient(ctx context.Context, dynamicClient dynamic.Interface, key client.ObjectKey, gvk schema.GroupVersionKind, resourceVersion string) (runtime.Object, error) {
+	return dynamicResource(dynamicClient, gvk, key.Namespace).Get(ctx, key.Name, metav1.GetOptions{ResourceVersion: resourceVersion})
+}
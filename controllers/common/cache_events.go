@@ -0,0 +1,65 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// NewTypedEventHandler converts the informer events of the objects of type T to the
+// controller-runtime events sent to the channels. The events of the other types are
+// skipped, and so are the events whose channel is nil. The sends block the handler,
+// not the informer, so the channels must be drained.
+func NewTypedEventHandler[T client.Object](createCh chan<- event.CreateEvent, updateCh chan<- event.UpdateEvent, deleteCh chan<- event.DeleteEvent) toolscache.ResourceEventHandlerFuncs {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			typed, ok := obj.(T)
+			if !ok || createCh == nil {
+				return
+			}
+			createCh <- event.CreateEvent{Object: typed}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			typedOld, ok := oldObj.(T)
+			if !ok || updateCh == nil {
+				return
+			}
+			typedNew, ok := newObj.(T)
+			if !ok {
+				return
+			}
+			updateCh <- event.UpdateEvent{ObjectOld: typedOld, ObjectNew: typedNew}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if deleteCh == nil {
+				return
+			}
+			deleteStateUnknown := false
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+				deleteStateUnknown = true
+			}
+			typed, ok := obj.(T)
+			if !ok {
+				return
+			}
+			deleteCh <- event.DeleteEvent{Object: typed, DeleteStateUnknown: deleteStateUnknown}
+		},
+	}
+}
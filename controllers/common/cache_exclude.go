@@ -0,0 +1,63 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// excludeNamespacesSelector selects the objects out of the excluded namespaces
+func excludeNamespacesSelector(excludeNamespaces []string) fields.Selector {
+	selectors := make([]fields.Selector, 0, len(excludeNamespaces))
+	for _, ns := range excludeNamespaces {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.namespace", ns))
+	}
+	return fields.AndSelectors(selectors...)
+}
+
+// excludeNamespaces adds the selector of the excluded namespaces to the field selector of
+// the list options. The cluster-scoped GVKs have no metadata.namespace field to select on,
+// so the selector is only added when the mapper knows the GVK is namespace-scoped.
+func excludeNamespaces(opts cache.Options, gvk schema.GroupVersionKind, excludeNamespaces []string, options *metav1.ListOptions) {
+	if len(excludeNamespaces) == 0 || opts.Mapper == nil {
+		return
+	}
+	if clusterScoped, err := isClusterScoped(opts.Mapper, gvk); err != nil || clusterScoped {
+		return
+	}
+	selector := excludeNamespacesSelector(excludeNamespaces).String()
+	if options.FieldSelector != "" {
+		selector = options.FieldSelector + "," + selector
+	}
+	options.FieldSelector = selector
+}
+
+// isExcludedNamespace checks if the namespace is kept out of the informer stores
+func (c *CSCache) isExcludedNamespace(namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+	for _, ns := range c.options.ExcludeNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ObjectExists checks if the object exists in the informer store of the GVK, or else in the
+// api server. The object found in the store is not copied.
+func (c *CSCache) ObjectExists(ctx context.Context, key client.ObjectKey, gvk schema.GroupVersionKind) (bool, error) {
+	c.initLazyInformer(ctx, gvk)
+	if informer, ok := c.getInformer(gvk); ok {
+		// Different key for cluster scope resource and namespaced resource
+		keyString := key.Name
+		if key.Namespace != "" {
+			keyString = key.Namespace + "/" + key.Name
+		}
+		_, exists, err := informer.GetStore().GetByKey(keyString)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	obj, err := c.Scheme.New(gvk)
+	if err != nil {
+		return false, err
+	}
+	if err := c.getFromClient(ctx, key, obj, gvk); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
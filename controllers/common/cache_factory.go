@@ -0,0 +1,42 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// factoryInformer returns the informer of the GVK shared by the factory, if the factory
+// knows the resource of the GVK
+func factoryInformer(factory informers.SharedInformerFactory, gvk schema.GroupVersionKind) (toolscache.SharedIndexInformer, bool) {
+	if factory == nil {
+		return nil, false
+	}
+	generic, err := factory.ForResource(gvk.GroupVersion().WithResource(kindToResource(gvk.Kind)))
+	if err != nil {
+		return nil, false
+	}
+	return generic.Informer(), true
+}
+
+// isFactoryInformer checks if the informer of the GVK is shared by the InformerFactory
+func (c *CSCache) isFactoryInformer(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) bool {
+	shared, ok := factoryInformer(c.options.InformerFactory, gvk)
+	return ok && shared == informer
+}
@@ -0,0 +1,66 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FallbackPolicy controls when Get falls back to the api server on a store miss
+type FallbackPolicy int
+
+const (
+	// FallbackAlways gets the object from the api server on every store miss
+	FallbackAlways FallbackPolicy = iota
+	// FallbackNever returns NotFound on a store miss without calling the api server
+	FallbackNever
+	// FallbackOnTimeout waits for the object to be added to the store, e.g. by a watch event
+	// in flight, and gets it from the api server if it is still missing after FallbackDelay
+	FallbackOnTimeout
+)
+
+// DefaultFallbackDelay is how long FallbackOnTimeout waits for the store by default
+const DefaultFallbackDelay = time.Second
+
+// fallbackPollInterval is the interval the store is checked while waiting for the object
+const fallbackPollInterval = 50 * time.Millisecond
+
+// waitForStore waits for the object to be added to the informer store until the fallback
+// delay, and returns true if it was found
+func (c *CSCache) waitForStore(ctx context.Context, informer toolscache.SharedIndexInformer, key client.ObjectKey, obj client.Object, gvk schema.GroupVersionKind, getOpts GetOptions) bool {
+	delay := c.options.FallbackDelay
+	if delay <= 0 {
+		delay = DefaultFallbackDelay
+	}
+	interval := fallbackPollInterval
+	if interval > delay {
+		interval = delay
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, delay)
+	defer cancel()
+	err := utilwait.PollImmediateUntil(interval, func() (bool, error) {
+		return c.getFromStore(informer, key, obj, gvk) == nil && getOpts.isFresh(obj), nil
+	}, ctx.Done())
+	return err == nil
+}
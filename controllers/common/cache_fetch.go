@@ -0,0 +1,61 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// FetchFunc fetches the object missing in the cache, e.g. from a secondary store
+type FetchFunc func(ctx context.Context, key client.ObjectKey) (client.Object, error)
+
+// GetOrFetch gets the object like Get, and only if it is not found calls fetch for it instead.
+// The fetched object is kept in the write-through cache, if enabled by WithWriteThroughPolicy,
+// so that the following Gets are served without calling fetch again. Like the objects got
+// from the cache, the secret fields of the fetched object are redacted.
+func (c *CSCache) GetOrFetch(ctx context.Context, key client.ObjectKey, obj client.Object, fetch FetchFunc) error {
+	err := c.Get(ctx, key, obj)
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
+	if err != nil {
+		return err
+	}
+	fetched, err := fetch(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	objVal := reflect.ValueOf(obj)
+	itemVal := reflect.ValueOf(fetched)
+	if !itemVal.Type().AssignableTo(objVal.Type()) {
+		return fmt.Errorf("fetched type %s, but %s was asked for", itemVal.Type(), objVal.Type())
+	}
+	reflect.Indirect(objVal).Set(reflect.Indirect(reflect.ValueOf(fetched.DeepCopyObject())))
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+	c.storeWriteThrough(gvk, key, fetched)
+	return c.obfuscate(ctx, gvk, obj)
+}
@@ -0,0 +1,90 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GenerationIndexField is the field of the index on the generation of the objects
+const GenerationIndexField = "metadata.generation"
+
+// RegisterGenerationIndex indexes the objects of the GVK by their generation, so that
+// ObjectGenerationSince looks up the generations above the threshold in the index instead
+// of scanning the objects. Like AnnotationIndex, it must be called before the informer of
+// the GVK has objects, and registering it twice is a no-op.
+func (c *CSCache) RegisterGenerationIndex(gvk schema.GroupVersionKind) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return fmt.Errorf("failed to index the generation of %s: it is not in the cache", gvk)
+	}
+	if c.hasFieldIndex(informer, gvk, GenerationIndexField) {
+		return nil
+	}
+	return indexByField(informer, GenerationIndexField, func(obj client.Object) []string {
+		return []string{strconv.FormatInt(obj.GetGeneration(), 10)}
+	})
+}
+
+// ObjectGenerationSince returns a copy of the objects of the GVK in all the namespaces whose
+// generation is greater than the generation
+func (c *CSCache) ObjectGenerationSince(ctx context.Context, gvk schema.GroupVersionKind, generation int64) ([]runtime.Object, error) {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return nil, fmt.Errorf("failed to list %s by generation: it is not in the cache", gvk)
+	}
+	indexer := informer.GetIndexer()
+
+	var items []interface{}
+	if c.hasFieldIndex(informer, gvk, GenerationIndexField) {
+		// Only the generations indexed for all the namespaces are looked up
+		indexName := FieldIndexName(GenerationIndexField)
+		prefix := KeyToNamespacedKey("", "")
+		for _, value := range indexer.ListIndexFuncValues(indexName) {
+			if !strings.HasPrefix(value, prefix) {
+				continue
+			}
+			if gen, err := strconv.ParseInt(strings.TrimPrefix(value, prefix), 10, 64); err != nil || gen <= generation {
+				continue
+			}
+			indexed, err := indexer.ByIndex(indexName, value)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, indexed...)
+		}
+	} else {
+		for _, item := range indexer.List() {
+			accessor, err := apimeta.Accessor(item)
+			if err != nil {
+				return nil, err
+			}
+			if accessor.GetGeneration() > generation {
+				items = append(items, item)
+			}
+		}
+	}
+	return c.copyObjects(ctx, items, gvk)
+}
@@ -0,0 +1,45 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetOptions are the options of CSCache.GetWithOptions. The client.Reader of the
+// controller-runtime version in use doesn't take options for Get.
+type GetOptions struct {
+	// MinResourceVersion is the oldest resourceVersion of the object accepted
+	MinResourceVersion string
+}
+
+// GetOption configures the GetOptions
+type GetOption func(*GetOptions)
+
+// MustBeFresherThan skips the informer store if the object in it is older than the
+// resourceVersion, e.g. the one returned by a prior Create, and fetches the object
+// at least as new as the resourceVersion from the api server
+func MustBeFresherThan(rv string) GetOption {
+	return func(o *GetOptions) {
+		o.MinResourceVersion = rv
+	}
+}
+
+// isFresh checks if the object is not older than the MinResourceVersion
+func (o GetOptions) isFresh(obj client.Object) bool {
+	return !resourceVersionLess(obj.GetResourceVersion(), o.MinResourceVersion)
+}
@@ -0,0 +1,83 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// recordInformerExit records the informer of the GVK as exited, unless it was stopped
+// with its context
+func (c *CSCache) recordInformerExit(ctx context.Context, gvk schema.GroupVersionKind) {
+	if ctx.Err() != nil {
+		return
+	}
+	klog.Errorf("Informer for %s exited while the cache is running", gvk)
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	if c.exitedInformers == nil {
+		c.exitedInformers = make(map[schema.GroupVersionKind]bool)
+	}
+	c.exitedInformers[gvk] = true
+}
+
+// LivenessChecker returns the healthz checker failing once an informer of the cache has
+// exited while the cache is running, e.g. for mgr.AddHealthzCheck("cache", c.LivenessChecker())
+func (c *CSCache) LivenessChecker() healthz.Checker {
+	return func(req *http.Request) error {
+		c.healthMu.Lock()
+		defer c.healthMu.Unlock()
+		if len(c.exitedInformers) == 0 {
+			return nil
+		}
+		var exited []string
+		for gvk := range c.exitedInformers {
+			exited = append(exited, gvk.String())
+		}
+		sort.Strings(exited)
+		return fmt.Errorf("the informers have exited: %v", exited)
+	}
+}
+
+// ReadinessChecker returns the healthz checker failing until WaitForCacheSync has returned
+// true, e.g. for mgr.AddReadyzCheck("cache", c.ReadinessChecker())
+func (c *CSCache) ReadinessChecker() healthz.Checker {
+	return func(req *http.Request) error {
+		if atomic.LoadInt32(&c.cacheSynced) == 0 {
+			return errors.New("the cache has not synced")
+		}
+		return nil
+	}
+}
+
+// Check is the healthz checker of the cache combining the liveness and the readiness,
+// e.g. for mgr.AddHealthzCheck("cache", c.Check)
+func (c *CSCache) Check(req *http.Request) error {
+	if err := c.LivenessChecker()(req); err != nil {
+		return err
+	}
+	return c.ReadinessChecker()(req)
+}
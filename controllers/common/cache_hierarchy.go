@@ -0,0 +1,53 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getFromParent gets the object missing in the local informer store from the informer store
+// of the parent CSCache. The object of the parent matching the override selector is not
+// returned, so that the local result takes precedence, nor the one older than the options
+// ask for. The object is only set when it is returned.
+func (c *CSCache) getFromParent(key client.ObjectKey, obj client.Object, gvk schema.GroupVersionKind, getOpts GetOptions) bool {
+	parent := c.options.ParentCache
+	if parent == nil {
+		return false
+	}
+	informer, ok := parent.getInformer(gvk)
+	if !ok {
+		return false
+	}
+	found := obj.DeepCopyObject().(client.Object)
+	if err := parent.getFromStore(informer, key, found, gvk); err != nil {
+		return false
+	}
+	overrideSelector := c.options.OverrideSelector
+	if overrideSelector != nil && !overrideSelector.Empty() && overrideSelector.Matches(labels.Set(found.GetLabels())) {
+		return false
+	}
+	if !getOpts.isFresh(found) {
+		return false
+	}
+	reflect.Indirect(reflect.ValueOf(obj)).Set(reflect.Indirect(reflect.ValueOf(found)))
+	return true
+}
@@ -0,0 +1,124 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ErrNotSupported is returned when the cache does not support the operation
+var ErrNotSupported = errors.New("operation not supported by the cache")
+
+// indexFieldRemover is implemented by the caches supporting RemoveIndexField
+type indexFieldRemover interface {
+	RemoveIndexField(ctx context.Context, obj client.Object, field string) error
+}
+
+// RemoveIndexField deregisters the field index added by IndexField. The informer indexer
+// can't drop an index, so the index is marked as removed and a List with a field selector
+// on it returns an error rather than results from the stale index.
+func (c *CSCache) RemoveIndexField(ctx context.Context, obj client.Object, field string) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
+	if err != nil {
+		return err
+	}
+
+	if informer, ok := c.getInformer(gvk); ok {
+		if _, ok := informer.GetIndexer().GetIndexers()[FieldIndexName(field)]; !ok {
+			return fmt.Errorf("index %s is not found for %s", field, gvk)
+		}
+		c.indexMu.Lock()
+		defer c.indexMu.Unlock()
+		if c.removedIndexes == nil {
+			c.removedIndexes = make(map[schema.GroupVersionKind]map[string]bool)
+		}
+		if c.removedIndexes[gvk] == nil {
+			c.removedIndexes[gvk] = make(map[string]bool)
+		}
+		c.removedIndexes[gvk][field] = true
+		return nil
+	}
+
+	if remover, ok := c.fallback.(indexFieldRemover); ok {
+		return remover.RemoveIndexField(ctx, obj, field)
+	}
+	return ErrNotSupported
+}
+
+// isIndexRemoved checks if the field index of the GVK is removed
+func (c *CSCache) isIndexRemoved(gvk schema.GroupVersionKind, field string) bool {
+	c.indexMu.RLock()
+	defer c.indexMu.RUnlock()
+	return c.removedIndexes[gvk][field]
+}
+
+// FieldIndexSpec is a field index of the GVK registered when the cache is built
+type FieldIndexSpec struct {
+	GVK       schema.GroupVersionKind
+	Field     string
+	Extractor client.IndexerFunc
+}
+
+// registerFieldIndexes adds the field indexes of the options to the informers, or to the
+// fallback cache for the GVKs without an informer. The indexes of the lazy GVKs are added
+// when their informers are registered.
+func (c *CSCache) registerFieldIndexes() error {
+	for _, spec := range c.options.FieldIndexes {
+		if informer, ok := c.informerMap[spec.GVK]; ok {
+			if err := indexByField(informer, spec.Field, spec.Extractor); err != nil {
+				return fmt.Errorf("failed to index %s of %s: %v", spec.Field, spec.GVK, err)
+			}
+			continue
+		}
+		if _, ok := c.lazyGVKs[spec.GVK]; ok {
+			continue
+		}
+		obj, err := c.Scheme.New(spec.GVK)
+		if err != nil {
+			return fmt.Errorf("failed to index %s of %s: %v", spec.Field, spec.GVK, err)
+		}
+		clientObj, ok := obj.(client.Object)
+		if !ok {
+			return fmt.Errorf("failed to index %s of %s: %T is not an Object", spec.Field, spec.GVK, obj)
+		}
+		if err := c.fallback.IndexField(context.TODO(), clientObj, spec.Field, spec.Extractor); err != nil {
+			return fmt.Errorf("failed to index %s of %s: %v", spec.Field, spec.GVK, err)
+		}
+	}
+	return nil
+}
+
+// addFieldIndexes adds the field indexes of the options to the informer of the GVK
+// registered after the cache is built
+func (c *CSCache) addFieldIndexes(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) error {
+	for _, spec := range c.options.FieldIndexes {
+		if spec.GVK != gvk {
+			continue
+		}
+		if err := indexByField(informer, spec.Field, spec.Extractor); err != nil {
+			return fmt.Errorf("failed to index %s of %s: %v", spec.Field, gvk, err)
+		}
+	}
+	return nil
+}
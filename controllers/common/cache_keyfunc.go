@@ -0,0 +1,90 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// CustomKeyIndex is the name of the index on the keys of the GVKKeyFuncs
+const CustomKeyIndex = "customKey"
+
+// addKeyFuncIndex indexes the objects of the GVK by the key of its key function in the
+// options. The informers of this client-go version key their store with
+// MetaNamespaceKeyFunc, so the custom keys are served by an index of the store instead.
+func (c *CSCache) addKeyFuncIndex(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) error {
+	keyFunc, ok := c.options.GVKKeyFuncs[gvk]
+	if !ok {
+		return nil
+	}
+	return informer.AddIndexers(toolscache.Indexers{CustomKeyIndex: func(obj interface{}) ([]string, error) {
+		key, err := keyFunc(obj)
+		if err != nil {
+			return nil, err
+		}
+		return []string{key}, nil
+	}})
+}
+
+// GetByCustomKey gets the object of the GVK by the key of its key function in GVKKeyFuncs
+// from the informer store
+func (c *CSCache) GetByCustomKey(ctx context.Context, gvk schema.GroupVersionKind, key string, obj runtime.Object) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return fmt.Errorf("failed to get %s %s: it is not in the cache", gvk, key)
+	}
+	if _, ok := c.options.GVKKeyFuncs[gvk]; !ok {
+		return fmt.Errorf("failed to get %s %s: no key function is configured", gvk, key)
+	}
+	items, err := informer.GetIndexer().ByIndex(CustomKeyIndex, key)
+	if err != nil {
+		return err
+	}
+	switch len(items) {
+	case 0:
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key)
+	case 1:
+	default:
+		return fmt.Errorf("failed to get %s %s: %d objects have the key", gvk, key, len(items))
+	}
+
+	item, err := c.copyObject(ctx, items[0], gvk)
+	if err != nil {
+		return err
+	}
+	if converted, err := convertUnstructured(item, obj); err != nil {
+		return err
+	} else if converted {
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		return nil
+	}
+	objVal := reflect.ValueOf(obj)
+	itemVal := reflect.ValueOf(item)
+	if !itemVal.Type().AssignableTo(objVal.Type()) {
+		return fmt.Errorf("cache had type %s, but %s was asked for", itemVal.Type(), objVal.Type())
+	}
+	reflect.Indirect(objVal).Set(reflect.Indirect(itemVal))
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return nil
+}
@@ -0,0 +1,110 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+)
+
+// DefaultLazyInitTimeout is the time the first Get or List of a lazy GVK waits for its
+// informer to sync, if its init timeout is not set
+const DefaultLazyInitTimeout = 30 * time.Second
+
+// lazyInformer is the state of the informer of a lazy GVK. Its mu serializes the registration
+// of the informer, so that the first accesses to the other GVKs are not blocked.
+type lazyInformer struct {
+	mu         sync.Mutex
+	registered bool
+	timeout    time.Duration
+}
+
+// splitLazyGVKs splits the lazy GVKs from the cluster GVK list
+func splitLazyGVKs(clusterGVKList []schema.GroupVersionKind, lazyGVKs []schema.GroupVersionKind, initTimeouts map[schema.GroupVersionKind]time.Duration) ([]schema.GroupVersionKind, map[schema.GroupVersionKind]*lazyInformer) {
+	lazy := make(map[schema.GroupVersionKind]*lazyInformer, len(lazyGVKs))
+	for _, gvk := range lazyGVKs {
+		timeout := initTimeouts[gvk]
+		if timeout == 0 {
+			timeout = DefaultLazyInitTimeout
+		}
+		lazy[gvk] = &lazyInformer{timeout: timeout}
+	}
+	eager := make([]schema.GroupVersionKind, 0, len(clusterGVKList))
+	for _, gvk := range clusterGVKList {
+		if _, ok := lazy[gvk]; !ok {
+			eager = append(eager, gvk)
+		}
+	}
+	return eager, lazy
+}
+
+// initLazyInformer builds and starts the informer of the lazy GVK on its first access, and
+// waits for the informer to sync if the cache has started. The request is served by the
+// api server if the informer doesn't sync within the init timeout. The GVKs already
+// initialized only take the read lock of the lazy GVKs.
+func (c *CSCache) initLazyInformer(ctx context.Context, gvk schema.GroupVersionKind) {
+	lazy, ok := c.registerLazyInformer(gvk)
+	if !ok {
+		return
+	}
+
+	// The accesses racing with the registration wait for the sync as well
+	c.mu.RLock()
+	started := c.startCtx != nil
+	c.mu.RUnlock()
+	if !started {
+		return
+	}
+	if _, err := c.WaitForGVKSync(ctx, gvk, lazy.timeout); err != nil {
+		klog.Warningf("Lazy informer for %s has not synced: %v", gvk, err)
+	}
+}
+
+// registerLazyInformer builds and starts the informer of the lazy GVK on its first access,
+// without waiting for it to sync, e.g. for the callers adding event handlers or indexes to
+// it. It returns the state of the lazy GVK, and false if the GVK is not lazy or was
+// initialized by another access already.
+func (c *CSCache) registerLazyInformer(gvk schema.GroupVersionKind) (*lazyInformer, bool) {
+	if itemGVK, err := listToGVK(gvk); err == nil {
+		gvk = itemGVK
+	}
+
+	c.lazyMu.RLock()
+	lazy, ok := c.lazyGVKs[gvk]
+	c.lazyMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	lazy.mu.Lock()
+	defer lazy.mu.Unlock()
+	if !lazy.registered {
+		if err := c.Register(gvk); err != nil {
+			klog.Errorf("Failed to init lazy informer for %s: %v", gvk, err)
+			return nil, false
+		}
+		lazy.registered = true
+		c.lazyMu.Lock()
+		delete(c.lazyGVKs, gvk)
+		c.lazyMu.Unlock()
+	}
+	return lazy, true
+}
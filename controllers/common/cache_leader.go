@@ -0,0 +1,228 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// suspendableHandler is an event handler added through GetInformer while the leader election
+// integration is enabled. The events delivered while the event processing is suspended are
+// buffered, coalesced by object, until it resumes.
+type suspendableHandler struct {
+	gvk     schema.GroupVersionKind
+	handler toolscache.ResourceEventHandler
+
+	// mu serializes the events delivered to the handler by the informer and by the resume
+	mu       sync.Mutex
+	buffered map[string]*bufferedEvent
+	order    []string
+}
+
+// bufferedEvent is the net event of an object while the event processing is suspended
+type bufferedEvent struct {
+	deleted bool
+	// added is set if the handler hasn't seen the object before the suspension
+	added  bool
+	oldObj interface{}
+	obj    interface{}
+}
+
+// onEvent delivers the event to the handler, or buffers it while the event processing is
+// suspended. A nil oldObj is an ADD event, and a deleted one a DELETE event.
+func (h *suspendableHandler) onEvent(suspended func() bool, oldObj, obj interface{}, deleted bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if suspended() {
+		h.bufferLocked(oldObj, obj, deleted)
+		return
+	}
+	h.flushLocked()
+	switch {
+	case deleted:
+		h.handler.OnDelete(obj)
+	case oldObj == nil:
+		h.handler.OnAdd(obj)
+	default:
+		h.handler.OnUpdate(oldObj, obj)
+	}
+}
+
+// bufferLocked coalesces the event with the event buffered for the object. The caller must hold h.mu.
+func (h *suspendableHandler) bufferLocked(oldObj, obj interface{}, deleted bool) {
+	key, err := toolscache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Failed to buffer the event of %s while suspended: %v", h.gvk, err)
+		return
+	}
+	if h.buffered == nil {
+		h.buffered = make(map[string]*bufferedEvent)
+	}
+	prev, ok := h.buffered[key]
+	if !ok {
+		h.buffered[key] = &bufferedEvent{deleted: deleted, added: oldObj == nil && !deleted, oldObj: oldObj, obj: obj}
+		h.order = append(h.order, key)
+		return
+	}
+	switch {
+	case deleted && prev.added:
+		// The handler never saw the object
+		delete(h.buffered, key)
+	case deleted:
+		prev.deleted, prev.obj = true, obj
+	case prev.deleted:
+		// The object deleted and added again is an update of the object the handler saw
+		prev.deleted, prev.oldObj, prev.obj = false, prev.obj, obj
+	default:
+		prev.obj = obj
+	}
+}
+
+// flushLocked delivers the buffered events in the order of their objects. The caller must hold h.mu.
+func (h *suspendableHandler) flushLocked() {
+	for _, key := range h.order {
+		event, ok := h.buffered[key]
+		if !ok {
+			continue
+		}
+		switch {
+		case event.deleted:
+			h.handler.OnDelete(event.obj)
+		case event.added:
+			h.handler.OnAdd(event.obj)
+		default:
+			h.handler.OnUpdate(event.oldObj, event.obj)
+		}
+	}
+	h.buffered = nil
+	h.order = nil
+}
+
+// SuspendReconcile stops delivering the informer events to the handlers added through
+// GetInformer, buffering them instead. The informers keep running, so that the cache stays warm.
+func (c *CSCache) SuspendReconcile() {
+	if atomic.CompareAndSwapInt32(&c.suspended, 0, 1) {
+		klog.Info("Suspended the event processing of the cache")
+	}
+}
+
+// ResumeReconcile delivers the informer events to the handlers again, starting with the
+// events buffered while suspended
+func (c *CSCache) ResumeReconcile() {
+	if !atomic.CompareAndSwapInt32(&c.suspended, 1, 0) {
+		return
+	}
+	klog.Info("Resumed the event processing of the cache")
+
+	c.leaderMu.Lock()
+	handlers := append([]*suspendableHandler{}, c.suspendableHandlers...)
+	c.leaderMu.Unlock()
+	for _, h := range handlers {
+		h.mu.Lock()
+		h.flushLocked()
+		h.mu.Unlock()
+	}
+}
+
+// dropSuspendableHandlers drops the handlers of the informer of the GVK removed from the
+// cache, with their buffered events
+func (c *CSCache) dropSuspendableHandlers(gvk schema.GroupVersionKind) {
+	c.leaderMu.Lock()
+	defer c.leaderMu.Unlock()
+	kept := c.suspendableHandlers[:0]
+	for _, h := range c.suspendableHandlers {
+		if h.gvk != gvk {
+			kept = append(kept, h)
+		}
+	}
+	for i := len(kept); i < len(c.suspendableHandlers); i++ {
+		c.suspendableHandlers[i] = nil
+	}
+	c.suspendableHandlers = kept
+}
+
+// isReconcileSuspended checks if the event processing is suspended
+func (c *CSCache) isReconcileSuspended() bool {
+	return atomic.LoadInt32(&c.suspended) == 1
+}
+
+// runLeaderElection suspends the event processing when the operator becomes a follower and
+// resumes it when the operator is elected, until the cache stops
+func (c *CSCache) runLeaderElection(ctx context.Context) {
+	electedCh, followerCh := c.options.ElectedCh, c.options.FollowerCh
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-electedCh:
+			c.ResumeReconcile()
+			// A closed channel fires only once
+			if !ok {
+				electedCh = nil
+			}
+		case _, ok := <-followerCh:
+			c.SuspendReconcile()
+			if !ok {
+				followerCh = nil
+			}
+		}
+	}
+}
+
+// suspendableInformer buffers the events delivered to the handlers while the event processing
+// of the cache is suspended
+type suspendableInformer struct {
+	toolscache.SharedIndexInformer
+	gvk   schema.GroupVersionKind
+	cache *CSCache
+}
+
+// AddEventHandler adds the handler behind the suspension check
+func (i *suspendableInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.SharedIndexInformer.AddEventHandler(i.wrap(handler))
+}
+
+// AddEventHandlerWithResyncPeriod adds the handler behind the suspension check
+func (i *suspendableInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.SharedIndexInformer.AddEventHandlerWithResyncPeriod(i.wrap(handler), resyncPeriod)
+}
+
+func (i *suspendableInformer) wrap(handler toolscache.ResourceEventHandler) toolscache.ResourceEventHandler {
+	h := &suspendableHandler{gvk: i.gvk, handler: handler}
+	i.cache.leaderMu.Lock()
+	i.cache.suspendableHandlers = append(i.cache.suspendableHandlers, h)
+	i.cache.leaderMu.Unlock()
+
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			h.onEvent(i.cache.isReconcileSuspended, nil, obj, false)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			h.onEvent(i.cache.isReconcileSuspended, oldObj, newObj, false)
+		},
+		DeleteFunc: func(obj interface{}) {
+			h.onEvent(i.cache.isReconcileSuspended, nil, obj, true)
+		},
+	}
+}
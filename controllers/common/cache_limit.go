@@ -0,0 +1,111 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// enableObjectLimit evicts the least recently accessed objects of the GVK from the informer
+// store once it holds more objects than the limit
+func (c *CSCache) enableObjectLimit(gvk schema.GroupVersionKind) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok {
+		return fmt.Errorf("failed to limit the objects: %s is not in the cluster GVK list", gvk)
+	}
+	if c.accessTimes == nil {
+		c.accessTimes = make(map[schema.GroupVersionKind]*sync.Map)
+	}
+	c.accessTimes[gvk] = &sync.Map{}
+	informer.AddEventHandler(c.objectLimitHandler(gvk))
+	return nil
+}
+
+// objectLimitHandler records the time the objects are added, and evicts the objects over the limit
+func (c *CSCache) objectLimitHandler(gvk schema.GroupVersionKind) toolscache.ResourceEventHandlerFuncs {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			key, err := toolscache.MetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+			c.recordAccess(gvk, key)
+			c.enforceObjectLimit(gvk)
+		},
+		DeleteFunc: func(obj interface{}) {
+			key, err := toolscache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+			if accessTimes, ok := c.accessTimes[gvk]; ok {
+				accessTimes.Delete(key)
+			}
+		},
+	}
+}
+
+// recordAccess records the access time of the object, if the objects of the GVK are limited
+func (c *CSCache) recordAccess(gvk schema.GroupVersionKind, key string) {
+	if accessTimes, ok := c.accessTimes[gvk]; ok {
+		accessTimes.Store(key, time.Now())
+	}
+}
+
+// enforceObjectLimit evicts the least recently accessed objects until the store is within
+// the limit. The evicted objects are fetched by getFromClient on the next access.
+func (c *CSCache) enforceObjectLimit(gvk schema.GroupVersionKind) {
+	limit := c.options.GVKObjectLimit[gvk]
+	accessTimes, ok := c.accessTimes[gvk]
+	informer, found := c.getInformer(gvk)
+	if !ok || !found || limit <= 0 {
+		return
+	}
+
+	store := informer.GetStore()
+	keys := store.ListKeys()
+	for len(keys) > limit {
+		oldest, oldestIndex := time.Time{}, -1
+		for i, key := range keys {
+			accessed, ok := accessTimes.Load(key)
+			if !ok {
+				// The object accessed before the tracking is the oldest
+				oldestIndex = i
+				break
+			}
+			if oldestIndex == -1 || accessed.(time.Time).Before(oldest) {
+				oldest, oldestIndex = accessed.(time.Time), i
+			}
+		}
+
+		key := keys[oldestIndex]
+		if item, exists, err := store.GetByKey(key); err == nil && exists {
+			if err := store.Delete(item); err != nil {
+				klog.Errorf("Failed to evict %s %s from cache: %v", gvk.Kind, key, err)
+				return
+			}
+			klog.V(2).Infof("Evicted %s %s from cache, which holds more than %d objects", gvk.Kind, key, limit)
+		}
+		accessTimes.Delete(key)
+		keys = append(keys[:oldestIndex], keys[oldestIndex+1:]...)
+	}
+}
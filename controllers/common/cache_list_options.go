@@ -0,0 +1,66 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"sort"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SortedListOption sorts the objects returned by CSCache.List by namespace and name
+type SortedListOption struct{}
+
+// ApplyToList implements client.ListOption. The option is only recognized by CSCache.List.
+func (SortedListOption) ApplyToList(*client.ListOptions) {}
+
+// hasSortedListOption checks if the SortedListOption is in the list options
+func hasSortedListOption(opts []client.ListOption) bool {
+	for _, opt := range opts {
+		if _, ok := opt.(SortedListOption); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortObjects sorts the objects by namespace and name
+func sortObjects(objs []runtime.Object) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		return objectSortKey(objs[i]) < objectSortKey(objs[j])
+	})
+}
+
+func objectSortKey(obj runtime.Object) string {
+	meta, err := apimeta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return meta.GetNamespace() + "/" + meta.GetName()
+}
+
+// sortList sorts the items of the list by namespace and name
+func sortList(list client.ObjectList) error {
+	objs, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	sortObjects(objs)
+	return apimeta.SetList(list, objs)
+}
@@ -0,0 +1,128 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	storeObjectsDesc = prometheus.NewDesc(
+		"cs_cache_store_objects",
+		"Number of objects held in the CSCache informer store",
+		[]string{"gvk"}, nil,
+	)
+	getHitsDesc = prometheus.NewDesc(
+		"cs_cache_get_hits_total",
+		"Number of Get requests served from the CSCache informer stores",
+		nil, nil,
+	)
+	getMissesDesc = prometheus.NewDesc(
+		"cs_cache_get_misses_total",
+		"Number of Get requests sent to the api server by the CSCache",
+		nil, nil,
+	)
+	syncLatencyDesc = prometheus.NewDesc(
+		"cs_cache_informer_sync_latency_seconds",
+		"Time from the start of the CSCache until the informer synced",
+		[]string{"gvk"}, nil,
+	)
+	watchdogRestartsDesc = prometheus.NewDesc(
+		"cs_cache_watchdog_restarts_total",
+		"Number of CSCache informers restarted by the watchdog without any event",
+		[]string{"gvk"}, nil,
+	)
+	forbiddenDesc = prometheus.NewDesc(
+		"cs_cache_forbidden_total",
+		"Number of CSCache requests to the api server forbidden by the RBAC",
+		[]string{"gvk"}, nil,
+	)
+)
+
+// newSyncDurationHistogram registers the histogram of the informer time-to-first-sync
+func newSyncDurationHistogram(registry prometheus.Registerer) (*prometheus.HistogramVec, error) {
+	return registerHistogram(registry, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cs_cache_informer_sync_duration_seconds",
+		Help:    "Duration from the start of the CSCache until the first sync of the informer",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+	}, []string{"gvk"}))
+}
+
+// newQueueDepthGauge registers the gauge of the events queued for the handlers of the informers
+func newQueueDepthGauge(registry prometheus.Registerer) (*prometheus.GaugeVec, error) {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cs_cache_event_queue_depth",
+		Help: "Number of informer events queued and not yet processed by the event handlers",
+	}, []string{"gvk"})
+	if err := registry.Register(gauge); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(*prometheus.GaugeVec); ok {
+				return existing, nil
+			}
+		}
+		return nil, err
+	}
+	return gauge, nil
+}
+
+// Describe implements prometheus.Collector
+func (c *CSCache) Describe(ch chan<- *prometheus.Desc) {
+	ch <- storeObjectsDesc
+	ch <- getHitsDesc
+	ch <- getMissesDesc
+	ch <- syncLatencyDesc
+	ch <- forbiddenDesc
+	ch <- watchdogRestartsDesc
+}
+
+// Collect implements prometheus.Collector, the metrics are read from the cache on every scrape
+func (c *CSCache) Collect(ch chan<- prometheus.Metric) {
+	stats := c.Stats()
+	for gvk, count := range stats.GVKStoreSizes {
+		ch <- prometheus.MustNewConstMetric(storeObjectsDesc, prometheus.GaugeValue, float64(count), gvk.String())
+	}
+	ch <- prometheus.MustNewConstMetric(getHitsDesc, prometheus.CounterValue, float64(stats.TotalGetHits))
+	ch <- prometheus.MustNewConstMetric(getMissesDesc, prometheus.CounterValue, float64(stats.TotalGetMisses))
+	for gvk, d := range stats.LastSyncDurations {
+		ch <- prometheus.MustNewConstMetric(syncLatencyDesc, prometheus.GaugeValue, d.Seconds(), gvk.String())
+	}
+	for gvk, count := range c.forbiddenCountsSnapshot() {
+		ch <- prometheus.MustNewConstMetric(forbiddenDesc, prometheus.CounterValue, float64(count), gvk.String())
+	}
+	for gvk, count := range c.watchdogRestartsSnapshot() {
+		ch <- prometheus.MustNewConstMetric(watchdogRestartsDesc, prometheus.CounterValue, float64(count), gvk.String())
+	}
+}
+
+// registerCacheMetrics registers the CSCache in the controller-runtime metrics registry. The
+// metrics of a single CSCache can be registered, the registration of another one fails rather
+// than leaving its metrics unexported.
+func registerCacheMetrics(c *CSCache) error {
+	if err := metrics.Registry.Register(c); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if errors.As(err, &are) {
+			return fmt.Errorf("the metrics of another CSCache are already registered: %v", err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	filteredcache "github.com/IBM/controller-filtered-cache/filteredcache"
+)
+
+type clusterKey struct{}
+
+// WithCluster routes the requests of the MultiClusterCSCache made with the context to the
+// cache of the cluster
+func WithCluster(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, clusterKey{}, name)
+}
+
+// MultiClusterCSCache caches the resources of the remote clusters in hub-spoke configurations
+// as well as the local one. The requests are routed to the cache of the cluster set by
+// WithCluster in their context, and to the local cache otherwise.
+type MultiClusterCSCache struct {
+	local cache.Cache
+
+	clusterGVKList     []schema.GroupVersionKind
+	gvkLabelMap        map[schema.GroupVersionKind]filteredcache.Selector
+	watchNamespaceList []string
+	cacheOpts          cache.Options
+
+	mu       sync.RWMutex
+	clusters map[string]*CSCache
+	cancels  map[string]context.CancelFunc
+	startCtx context.Context
+}
+
+// NewMultiClusterCSCache implements a multi-cluster cache whose local cache is the CSCache
+func NewMultiClusterCSCache(clusterGVKList []schema.GroupVersionKind, gvkLabelMap map[schema.GroupVersionKind]filteredcache.Selector, watchNamespaceList []string, options ...CSCacheOption) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		local, err := NewCSCache(clusterGVKList, gvkLabelMap, watchNamespaceList, options...)(config, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &MultiClusterCSCache{
+			local:              local,
+			clusterGVKList:     clusterGVKList,
+			gvkLabelMap:        gvkLabelMap,
+			watchNamespaceList: watchNamespaceList,
+			cacheOpts:          opts,
+		}, nil
+	}
+}
+
+// AddCluster adds the cache of the remote cluster. If the multi-cluster cache has started,
+// the cache of the cluster is started as well.
+func (m *MultiClusterCSCache) AddCluster(name string, config *rest.Config, opts CSCacheOptions) error {
+	m.mu.RLock()
+	_, ok := m.clusters[name]
+	m.mu.RUnlock()
+	if ok {
+		return fmt.Errorf("cluster %s is already in the cache", name)
+	}
+
+	// The RESTMapper of the local cluster doesn't apply to the remote one
+	mapper, err := apiutil.NewDynamicRESTMapper(config, apiutil.WithLazyDiscovery)
+	if err != nil {
+		return fmt.Errorf("failed to create RESTMapper for cluster %s: %v", name, err)
+	}
+	cacheOpts := cache.Options{
+		Scheme:    m.cacheOpts.Scheme,
+		Mapper:    mapper,
+		Resync:    m.cacheOpts.Resync,
+		Namespace: m.cacheOpts.Namespace,
+	}
+	newCache := NewCSCache(m.clusterGVKList, m.gvkLabelMap, m.watchNamespaceList, func(o *CSCacheOptions) {
+		*o = opts
+	})
+	clusterCache, err := newCache(config, cacheOpts)
+	if err != nil {
+		return fmt.Errorf("failed to init cache for cluster %s: %v", name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.clusters[name]; ok {
+		return fmt.Errorf("cluster %s is already in the cache", name)
+	}
+	if m.clusters == nil {
+		m.clusters = make(map[string]*CSCache)
+		m.cancels = make(map[string]context.CancelFunc)
+	}
+	m.clusters[name] = clusterCache.(*CSCache)
+	if m.startCtx != nil {
+		m.startClusterLocked(name)
+	}
+	klog.Infof("Added cluster %s to the cache", name)
+	return nil
+}
+
+// RemoveCluster stops and removes the cache of the remote cluster
+func (m *MultiClusterCSCache) RemoveCluster(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.clusters[name]; !ok {
+		return fmt.Errorf("cluster %s is not in the cache", name)
+	}
+	if cancel, ok := m.cancels[name]; ok {
+		cancel()
+		delete(m.cancels, name)
+	}
+	delete(m.clusters, name)
+	klog.Infof("Removed cluster %s from the cache", name)
+	return nil
+}
+
+// startClusterLocked runs the cache of the cluster until the multi-cluster cache stops or
+// the cluster is removed. The caller must hold m.mu.
+func (m *MultiClusterCSCache) startClusterLocked(name string) {
+	ctx, cancel := context.WithCancel(m.startCtx)
+	m.cancels[name] = cancel
+	clusterCache := m.clusters[name]
+	go func() {
+		if err := clusterCache.Start(ctx); err != nil {
+			klog.Errorf("Failed to start cache for cluster %s: %v", name, err)
+		}
+	}()
+}
+
+// cacheFor returns the cache of the cluster set in the context, or the local cache
+func (m *MultiClusterCSCache) cacheFor(ctx context.Context) (cache.Cache, error) {
+	name, ok := ctx.Value(clusterKey{}).(string)
+	if !ok {
+		return m.local, nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	clusterCache, ok := m.clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("cluster %s is not in the cache", name)
+	}
+	return clusterCache, nil
+}
+
+// Get implements Reader
+func (m *MultiClusterCSCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c, err := m.cacheFor(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Get(ctx, key, obj)
+}
+
+// List implements Reader
+func (m *MultiClusterCSCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c, err := m.cacheFor(ctx)
+	if err != nil {
+		return err
+	}
+	return c.List(ctx, list, opts...)
+}
+
+// GetInformer returns the informer of the obj in the cluster set in the context
+func (m *MultiClusterCSCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+	c, err := m.cacheFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetInformer(ctx, obj)
+}
+
+// GetInformerForKind returns the informer of the GVK in the cluster set in the context
+func (m *MultiClusterCSCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	c, err := m.cacheFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetInformerForKind(ctx, gvk)
+}
+
+// IndexField adds the index to the cache of the cluster set in the context
+func (m *MultiClusterCSCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	c, err := m.cacheFor(ctx)
+	if err != nil {
+		return err
+	}
+	return c.IndexField(ctx, obj, field, extractValue)
+}
+
+// Start runs the caches of the local and remote clusters. It blocks.
+func (m *MultiClusterCSCache) Start(ctx context.Context) error {
+	m.mu.Lock()
+	m.startCtx = ctx
+	for name := range m.clusters {
+		m.startClusterLocked(name)
+	}
+	m.mu.Unlock()
+	return m.local.Start(ctx)
+}
+
+// WaitForCacheSync waits for the caches of the local and remote clusters to sync
+func (m *MultiClusterCSCache) WaitForCacheSync(ctx context.Context) bool {
+	m.mu.RLock()
+	clusters := make([]*CSCache, 0, len(m.clusters))
+	for _, clusterCache := range m.clusters {
+		clusters = append(clusters, clusterCache)
+	}
+	m.mu.RUnlock()
+	for _, clusterCache := range clusters {
+		if !clusterCache.WaitForCacheSync(ctx) {
+			return false
+		}
+	}
+	return m.local.WaitForCacheSync(ctx)
+}
@@ -0,0 +1,121 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"sync"
+	"sync/atomic"
+
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// WatchEventType is the type of the WatchEvent
+type WatchEventType string
+
+const (
+	// AddEvent is sent when an object is added to the store
+	AddEvent WatchEventType = "ADD"
+	// UpdateEvent is sent when an object is updated in the store
+	UpdateEvent WatchEventType = "UPDATE"
+	// DeleteEvent is sent when an object is deleted from the store
+	DeleteEvent WatchEventType = "DELETE"
+)
+
+// WatchEvent is a store event sent to the subscribers of the EventMultiplexer
+type WatchEvent struct {
+	Type WatchEventType
+	// Object is the new object of ADD and UPDATE, and the deleted object of DELETE
+	Object interface{}
+	// OldObject is the previous object of UPDATE
+	OldObject interface{}
+}
+
+// EventMultiplexer fans out the events of a single informer event handler to multiple subscriber channels
+type EventMultiplexer struct {
+	mu          sync.RWMutex
+	bufferSize  int
+	subscribers map[string]chan WatchEvent
+	dropped     uint64
+}
+
+// NewEventMultiplexer adds a single event handler to the informer and fans out its events.
+// A subscriber whose channel buffer of bufferSize events is full misses the events instead of
+// blocking the other subscribers.
+func NewEventMultiplexer(informer cache.Informer, bufferSize int) *EventMultiplexer {
+	m := &EventMultiplexer{
+		bufferSize:  bufferSize,
+		subscribers: make(map[string]chan WatchEvent),
+	}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			m.send(WatchEvent{Type: AddEvent, Object: obj})
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			m.send(WatchEvent{Type: UpdateEvent, Object: newObj, OldObject: oldObj})
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			m.send(WatchEvent{Type: DeleteEvent, Object: obj})
+		},
+	})
+	return m
+}
+
+// Subscribe returns the event channel of the subscriber, creating it if it doesn't exist
+func (m *EventMultiplexer) Subscribe(id string) <-chan WatchEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ch, ok := m.subscribers[id]; ok {
+		return ch
+	}
+	ch := make(chan WatchEvent, m.bufferSize)
+	m.subscribers[id] = ch
+	return ch
+}
+
+// Unsubscribe closes and removes the event channel of the subscriber
+func (m *EventMultiplexer) Unsubscribe(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ch, ok := m.subscribers[id]; ok {
+		close(ch)
+		delete(m.subscribers, id)
+	}
+}
+
+// Dropped returns the number of events not delivered to a subscriber with a full channel
+func (m *EventMultiplexer) Dropped() uint64 {
+	return atomic.LoadUint64(&m.dropped)
+}
+
+// send delivers the event to every subscriber without blocking
+func (m *EventMultiplexer) send(event WatchEvent) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for id, ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+			atomic.AddUint64(&m.dropped, 1)
+			klog.V(2).Infof("Dropped %s event for the slow subscriber %s", event.Type, id)
+		}
+	}
+}
@@ -0,0 +1,610 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	filteredcache "github.com/IBM/controller-filtered-cache/filteredcache"
+)
+
+// newNamespaceCacheFunc builds the filtered cache of a namespace
+func newNamespaceCacheFunc(gvkLabelMap map[schema.GroupVersionKind]filteredcache.Selector, config *rest.Config, opts cache.Options) func(namespace string) (cache.Cache, error) {
+	return func(namespace string) (cache.Cache, error) {
+		opts.Namespace = namespace
+		return filteredcache.NewFilteredCacheBuilder(gvkLabelMap)(config, opts)
+	}
+}
+
+// AddNamespaceWatch extends the watched namespaces in multi-namespace mode, so that the operator
+// doesn't need to restart. The informers of the namespace-scoped GVKs watching the namespace
+// of the cache are rebuilt with the namespace included, and the filtered cache of the namespace
+// is built with the event handlers and indexes of the informers handed out by GetInformer. If
+// the cache has started, they are started and synced before being swapped in together.
+func (c *CSCache) AddNamespaceWatch(ctx context.Context, namespace string) error {
+	// The namespaces are added one at a time, so that the same namespace isn't added twice
+	c.namespaceAddMu.Lock()
+	defer c.namespaceAddMu.Unlock()
+	if c.IsWatchingNamespace(namespace) {
+		return nil
+	}
+
+	nsCache, err := c.newNamespaceCache(namespace)
+	if err != nil {
+		return fmt.Errorf("failed to init cache for namespace %s: %v", namespace, err)
+	}
+	for _, informer := range c.multiNamespaceInformers {
+		if err := informer.addCache(ctx, nsCache); err != nil {
+			c.removeNamespaceCache(nsCache)
+			return fmt.Errorf("failed to add the informer of %s in namespace %s: %v", informer.gvk, namespace, err)
+		}
+	}
+
+	c.mu.RLock()
+	startCtx := c.startCtx
+	c.mu.RUnlock()
+	rebuilt, err := c.rebuildNamespaceInformers(ctx, startCtx, namespace)
+	if err != nil {
+		c.removeNamespaceCache(nsCache)
+		return err
+	}
+
+	if startCtx != nil {
+		nsCtx, nsCancel := context.WithCancel(startCtx)
+		go func() {
+			defer nsCancel()
+			if err := nsCache.Start(nsCtx); err != nil {
+				klog.Errorf("Failed to start cache for namespace %s: %v", namespace, err)
+			}
+		}()
+		if !nsCache.WaitForCacheSync(ctx) {
+			nsCancel()
+			for _, r := range rebuilt {
+				r.cancel()
+			}
+			c.removeNamespaceCache(nsCache)
+			return fmt.Errorf("failed to sync cache for namespace %s", namespace)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.namespaceMu.Lock()
+	defer c.namespaceMu.Unlock()
+	if c.namespaceCaches == nil {
+		c.namespaceCaches = make(map[string]cache.Cache)
+	}
+	c.namespaceCaches[namespace] = nsCache
+	c.watchNamespaces = append(c.watchNamespaces, namespace)
+	for _, r := range rebuilt {
+		c.swapNamespaceInformerLocked(r)
+	}
+	klog.Infof("Added namespace %s to the watched namespaces", namespace)
+	return nil
+}
+
+// rebuiltInformer is the informer of a GVK rebuilt with an added namespace. If the cache has
+// started, the informer is run before it is swapped in, and the GVK is run with ctx.
+type rebuiltInformer struct {
+	gvk         schema.GroupVersionKind
+	rebuildable *rebuildableInformer
+	informer    toolscache.SharedIndexInformer
+	health      *watchHealth
+	run         *informerRun
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// rebuildNamespaceInformers rebuilds the informers of the GVKs watching the namespace of the
+// cache with the namespace added. If the cache has started, the rebuilt informers are run and
+// synced alongside the current ones. The informers shared from the factory are not rebuilt.
+func (c *CSCache) rebuildNamespaceInformers(ctx, startCtx context.Context, namespace string) ([]*rebuiltInformer, error) {
+	c.mu.RLock()
+	rebuildables := make(map[schema.GroupVersionKind]*rebuildableInformer)
+	for gvk, informer := range c.informerMap {
+		if isListGVK(gvk) || informerNamespace(c.cacheOpts, gvk) == corev1.NamespaceAll {
+			continue
+		}
+		rebuildable, ok := informer.(*rebuildableInformer)
+		if !ok {
+			klog.Warningf("The informer of %s can't be rebuilt, namespace %s is not watched by it", gvk, namespace)
+			continue
+		}
+		rebuildables[gvk] = rebuildable
+	}
+	c.mu.RUnlock()
+
+	var rebuilt []*rebuiltInformer
+	cancelAll := func() {
+		for _, r := range rebuilt {
+			r.cancel()
+		}
+	}
+	for gvk, rebuildable := range rebuildables {
+		namespaces := append(c.informerNamespaces(gvk), namespace)
+		informer, health, err := buildStandaloneInformer(c.config, c.cacheOpts, c.resync, gvk, c.options, namespaces)
+		if err == nil {
+			err = rebuildable.prepare(informer, health)
+		}
+		if err != nil {
+			cancelAll()
+			return nil, fmt.Errorf("failed to rebuild the informer of %s for namespace %s: %v", gvk, namespace, err)
+		}
+		r := &rebuiltInformer{gvk: gvk, rebuildable: rebuildable, informer: informer, health: health, cancel: func() {}}
+		if startCtx != nil {
+			r.ctx, r.cancel = context.WithCancel(startCtx)
+			runCtx, runCancel := context.WithCancel(r.ctx)
+			done := make(chan struct{})
+			r.run = &informerRun{cancel: runCancel, done: done}
+			go func() {
+				defer close(done)
+				informer.Run(runCtx.Done())
+			}()
+		}
+		rebuilt = append(rebuilt, r)
+	}
+
+	if startCtx != nil {
+		for _, r := range rebuilt {
+			if !toolscache.WaitForCacheSync(ctx.Done(), r.informer.HasSynced) {
+				cancelAll()
+				return nil, fmt.Errorf("failed to sync the informer of %s for namespace %s", r.gvk, namespace)
+			}
+		}
+	}
+	return rebuilt, nil
+}
+
+// swapNamespaceInformerLocked swaps the rebuilt informer in the place of the current one, which
+// is stopped. The caller must hold c.mu and c.namespaceMu, with the namespace added.
+func (c *CSCache) swapNamespaceInformerLocked(r *rebuiltInformer) {
+	gvk := r.gvk
+	r.rebuildable.setBuild(func() (toolscache.SharedIndexInformer, *watchHealth, error) {
+		return buildStandaloneInformer(c.config, c.cacheOpts, c.resync, gvk, c.options, c.informerNamespaces(gvk))
+	})
+	if r.run == nil {
+		r.rebuildable.swap(r.informer, r.health, nil)
+		return
+	}
+	if cancel, ok := c.informerCancels[gvk]; ok {
+		cancel()
+	}
+	r.rebuildable.swap(r.informer, r.health, r.run)
+	c.informerCancels[gvk] = r.cancel
+	go c.runInformer(r.ctx, gvk, r.rebuildable)
+}
+
+// informerNamespaces returns the namespaces the informer of the GVK watches: all the namespaces
+// for the cluster-scoped GVKs, or else the namespace of the cache and the namespaces added by
+// AddNamespaceWatch
+func (c *CSCache) informerNamespaces(gvk schema.GroupVersionKind) []string {
+	namespace := informerNamespace(c.cacheOpts, gvk)
+	if namespace == corev1.NamespaceAll {
+		return []string{corev1.NamespaceAll}
+	}
+	c.namespaceMu.RLock()
+	defer c.namespaceMu.RUnlock()
+	namespaces := []string{namespace}
+	for added := range c.namespaceCaches {
+		if added != namespace {
+			namespaces = append(namespaces, added)
+		}
+	}
+	sort.Strings(namespaces[1:])
+	return namespaces
+}
+
+// removeNamespaceCache removes the cache of a namespace which failed to be added from the
+// informers handed out by GetInformer
+func (c *CSCache) removeNamespaceCache(nsCache cache.Cache) {
+	for _, informer := range c.multiNamespaceInformers {
+		informer.removeCache(nsCache)
+	}
+}
+
+// multiNamespaceInformerFor returns the informer of the fallback GVK spanning the fallback cache
+// and the caches of the added namespaces in multi-namespace mode, or else the informer of the
+// fallback cache
+func (c *CSCache) multiNamespaceInformerFor(ctx context.Context, gvk schema.GroupVersionKind, get func(cache.Cache) (cache.Informer, error)) (cache.Informer, error) {
+	if c.IsWatchingNamespace(corev1.NamespaceAll) || c.newNamespaceCache == nil {
+		return get(c.fallback)
+	}
+
+	c.namespaceAddMu.Lock()
+	defer c.namespaceAddMu.Unlock()
+	if informer, ok := c.multiNamespaceInformers[gvk]; ok {
+		return informer, nil
+	}
+
+	c.namespaceMu.RLock()
+	caches := []cache.Cache{c.fallback}
+	for _, nsCache := range c.namespaceCaches {
+		caches = append(caches, nsCache)
+	}
+	c.namespaceMu.RUnlock()
+
+	informer := &multiNamespaceInformer{gvk: gvk, indexers: toolscache.Indexers{}}
+	for _, memberCache := range caches {
+		member, err := get(memberCache)
+		if err != nil {
+			return nil, err
+		}
+		informer.members = append(informer.members, multiNamespaceMember{cache: memberCache, informer: member})
+	}
+	if c.multiNamespaceInformers == nil {
+		c.multiNamespaceInformers = make(map[schema.GroupVersionKind]*multiNamespaceInformer)
+	}
+	c.multiNamespaceInformers[gvk] = informer
+	return informer, nil
+}
+
+// multiNamespaceMember is the informer of a multiNamespaceInformer in one of the caches
+type multiNamespaceMember struct {
+	cache    cache.Cache
+	informer cache.Informer
+}
+
+// indexedField is a field index added to a multiNamespaceInformer
+type indexedField struct {
+	obj          client.Object
+	field        string
+	extractValue client.IndexerFunc
+}
+
+// multiNamespaceInformer is the informer of a fallback GVK in multi-namespace mode, which spans the
+// informers of the fallback cache and of the caches of the namespaces added by AddNamespaceWatch.
+// The event handlers, indexers and field indexes added to it are recorded, and added to the
+// informer of the namespaces added later, so that the controllers receive their events.
+type multiNamespaceInformer struct {
+	gvk schema.GroupVersionKind
+
+	mu       sync.Mutex
+	members  []multiNamespaceMember
+	handlers []registeredHandler
+	indexers toolscache.Indexers
+	fields   []indexedField
+}
+
+var _ cache.Informer = &multiNamespaceInformer{}
+
+func (i *multiNamespaceInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handlers = append(i.handlers, registeredHandler{handler: handler})
+	for _, m := range i.members {
+		m.informer.AddEventHandler(handler)
+	}
+}
+
+func (i *multiNamespaceInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handlers = append(i.handlers, registeredHandler{handler: handler, resyncPeriod: resyncPeriod, withResync: true})
+	for _, m := range i.members {
+		m.informer.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	}
+}
+
+func (i *multiNamespaceInformer) AddIndexers(indexers toolscache.Indexers) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, m := range i.members {
+		if err := m.informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	for name, indexFunc := range indexers {
+		i.indexers[name] = indexFunc
+	}
+	return nil
+}
+
+func (i *multiNamespaceInformer) HasSynced() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, m := range i.members {
+		if !m.informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// indexField indexes the field in each of the caches
+func (i *multiNamespaceInformer) indexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, m := range i.members {
+		if err := m.cache.IndexField(ctx, obj, field, extractValue); err != nil {
+			return err
+		}
+	}
+	i.fields = append(i.fields, indexedField{obj: obj, field: field, extractValue: extractValue})
+	return nil
+}
+
+// addCache adds the informer of the cache of an added namespace, with the recorded event
+// handlers, indexers and field indexes. The cache must not have started.
+func (i *multiNamespaceInformer) addCache(ctx context.Context, nsCache cache.Cache) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	informer, err := nsCache.GetInformerForKind(ctx, i.gvk)
+	if err != nil {
+		return err
+	}
+	if len(i.indexers) > 0 {
+		if err := informer.AddIndexers(i.indexers); err != nil {
+			return err
+		}
+	}
+	for _, f := range i.fields {
+		if err := nsCache.IndexField(ctx, f.obj, f.field, f.extractValue); err != nil {
+			return err
+		}
+	}
+	for _, h := range i.handlers {
+		if h.withResync {
+			informer.AddEventHandlerWithResyncPeriod(h.handler, h.resyncPeriod)
+		} else {
+			informer.AddEventHandler(h.handler)
+		}
+	}
+	i.members = append(i.members, multiNamespaceMember{cache: nsCache, informer: informer})
+	return nil
+}
+
+// removeCache removes the informer of the cache
+func (i *multiNamespaceInformer) removeCache(nsCache cache.Cache) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for j, m := range i.members {
+		if m.cache == nsCache {
+			i.members = append(i.members[:j], i.members[j+1:]...)
+			return
+		}
+	}
+}
+
+// namespacesListWatch lists and watches in each of the namespaces with the ListerWatcher built
+// by newListWatch
+func namespacesListWatch(namespaces []string, newListWatch func(namespace string) toolscache.ListerWatcher) toolscache.ListerWatcher {
+	if len(namespaces) == 1 {
+		return newListWatch(namespaces[0])
+	}
+	lw := multiNamespaceListWatch{}
+	for _, namespace := range namespaces {
+		lw = append(lw, newListWatch(namespace))
+	}
+	return lw
+}
+
+// multiNamespaceListWatch lists and watches in several namespaces. The list merges the lists
+// of the namespaces, with the highest of their resourceVersions, and the watch merges their
+// watches from it. The resourceVersions are shared across namespaces by the api server.
+type multiNamespaceListWatch []toolscache.ListerWatcher
+
+func (lw multiNamespaceListWatch) List(options metav1.ListOptions) (runtime.Object, error) {
+	// The lists of the namespaces can't be paginated together
+	options.Limit = 0
+	options.Continue = ""
+
+	var list runtime.Object
+	var items []runtime.Object
+	var resourceVersion string
+	for _, nsListWatch := range lw {
+		nsList, err := nsListWatch.List(options)
+		if err != nil {
+			return nil, err
+		}
+		nsItems, err := apimeta.ExtractList(nsList)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, nsItems...)
+		listMeta, err := apimeta.ListAccessor(nsList)
+		if err != nil {
+			return nil, err
+		}
+		if resourceVersionLess(resourceVersion, listMeta.GetResourceVersion()) {
+			resourceVersion = listMeta.GetResourceVersion()
+		}
+		if list == nil {
+			list = nsList
+		}
+	}
+
+	if err := apimeta.SetList(list, items); err != nil {
+		return nil, err
+	}
+	listMeta, err := apimeta.ListAccessor(list)
+	if err != nil {
+		return nil, err
+	}
+	listMeta.SetResourceVersion(resourceVersion)
+	return list, nil
+}
+
+func (lw multiNamespaceListWatch) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	watchers := make([]watch.Interface, 0, len(lw))
+	for _, nsListWatch := range lw {
+		watcher, err := nsListWatch.Watch(options)
+		if err != nil {
+			for _, w := range watchers {
+				w.Stop()
+			}
+			return nil, err
+		}
+		watchers = append(watchers, watcher)
+	}
+	return newMultiNamespaceWatch(watchers), nil
+}
+
+// multiNamespaceWatch merges the events of the watches of several namespaces. The watch ends
+// once any of them ends, so that the informer watches them again.
+type multiNamespaceWatch struct {
+	watchers []watch.Interface
+	result   chan watch.Event
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newMultiNamespaceWatch(watchers []watch.Interface) *multiNamespaceWatch {
+	w := &multiNamespaceWatch{
+		watchers: watchers,
+		result:   make(chan watch.Event),
+		stopCh:   make(chan struct{}),
+	}
+	var wg sync.WaitGroup
+	for _, watcher := range watchers {
+		wg.Add(1)
+		go func(watcher watch.Interface) {
+			defer wg.Done()
+			defer w.Stop()
+			for {
+				select {
+				case event, ok := <-watcher.ResultChan():
+					if !ok {
+						return
+					}
+					select {
+					case w.result <- event:
+					case <-w.stopCh:
+						return
+					}
+				case <-w.stopCh:
+					return
+				}
+			}
+		}(watcher)
+	}
+	go func() {
+		wg.Wait()
+		close(w.result)
+	}()
+	return w
+}
+
+func (w *multiNamespaceWatch) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+		for _, watcher := range w.watchers {
+			watcher.Stop()
+		}
+	})
+}
+
+func (w *multiNamespaceWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// WatchNamespaces returns a copy of the watched namespaces. An empty namespace stands for
+// all the namespaces.
+func (c *CSCache) WatchNamespaces() []string {
+	c.namespaceMu.RLock()
+	defer c.namespaceMu.RUnlock()
+	return append([]string{}, c.watchNamespaces...)
+}
+
+// IsWatchingNamespace checks if the namespace is watched
+func (c *CSCache) IsWatchingNamespace(namespace string) bool {
+	c.namespaceMu.RLock()
+	defer c.namespaceMu.RUnlock()
+	for _, ns := range c.watchNamespaces {
+		if ns == corev1.NamespaceAll || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackFor returns the fallback cache serving the namespace
+func (c *CSCache) fallbackFor(namespace string) cache.Cache {
+	c.namespaceMu.RLock()
+	defer c.namespaceMu.RUnlock()
+	if nsCache, ok := c.namespaceCaches[namespace]; ok {
+		return nsCache
+	}
+	return c.fallback
+}
+
+// listFallback lists the objects from the fallback cache. The list across all namespaces
+// merges the objects of the namespaces added by AddNamespaceWatch, deduplicated by their
+// namespace/name key, and has the highest resourceVersion of the merged lists.
+func (c *CSCache) listFallback(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.Namespace != corev1.NamespaceAll {
+		return c.fallbackFor(listOpts.Namespace).List(ctx, list, opts...)
+	}
+
+	c.namespaceMu.RLock()
+	nsCaches := make([]cache.Cache, 0, len(c.namespaceCaches))
+	for _, nsCache := range c.namespaceCaches {
+		nsCaches = append(nsCaches, nsCache)
+	}
+	c.namespaceMu.RUnlock()
+	if len(nsCaches) == 0 {
+		return c.fallback.List(ctx, list, opts...)
+	}
+
+	var allItems []runtime.Object
+	var resourceVersion string
+	seen := make(map[string]bool)
+	for _, listCache := range append([]cache.Cache{c.fallback}, nsCaches...) {
+		listObj := list.DeepCopyObject().(client.ObjectList)
+		if err := listCache.List(ctx, listObj, opts...); err != nil {
+			return err
+		}
+		items, err := apimeta.ExtractList(listObj)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			key, err := toolscache.MetaNamespaceKeyFunc(item)
+			if err != nil {
+				return err
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			allItems = append(allItems, item)
+		}
+		if resourceVersionLess(resourceVersion, listObj.GetResourceVersion()) {
+			resourceVersion = listObj.GetResourceVersion()
+		}
+	}
+
+	list.SetResourceVersion(resourceVersion)
+	return apimeta.SetList(list, allItems)
+}
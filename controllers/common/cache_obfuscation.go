@@ -0,0 +1,116 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RedactionMarker replaces the values of the secret fields
+const RedactionMarker = "***"
+
+type secretAccessKey struct{}
+
+// WithSecretAccess annotates the context of the caller allowed to read the secret fields of
+// the GVKs in plaintext
+func WithSecretAccess(ctx context.Context, gvks ...schema.GroupVersionKind) context.Context {
+	allowed := make(map[schema.GroupVersionKind]bool)
+	if parent, ok := ctx.Value(secretAccessKey{}).(map[schema.GroupVersionKind]bool); ok {
+		for gvk := range parent {
+			allowed[gvk] = true
+		}
+	}
+	for _, gvk := range gvks {
+		allowed[gvk] = true
+	}
+	return context.WithValue(ctx, secretAccessKey{}, allowed)
+}
+
+// hasSecretAccess checks if the context allows reading the secret fields of the GVK
+func hasSecretAccess(ctx context.Context, gvk schema.GroupVersionKind) bool {
+	allowed, ok := ctx.Value(secretAccessKey{}).(map[schema.GroupVersionKind]bool)
+	return ok && allowed[gvk]
+}
+
+// obfuscate replaces the values of the secret fields of the object with the RedactionMarker,
+// unless the context allows reading them. The object must be a copy owned by the caller.
+func (c *CSCache) obfuscate(ctx context.Context, gvk schema.GroupVersionKind, obj runtime.Object) error {
+	secretFields, ok := c.options.SecretFields[gvk]
+	if !ok || hasSecretAccess(ctx, gvk) {
+		return nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	if err := fromRedacted(content, secretFields, RedactionMarker, obj); err != nil {
+		// The binary fields are base64 encoded, so that they decode to the RedactionMarker
+		marker := base64.StdEncoding.EncodeToString([]byte(RedactionMarker))
+		if err := fromRedacted(content, secretFields, marker, obj); err != nil {
+			return fmt.Errorf("failed to redact the secret fields of %s: %v", gvk, err)
+		}
+	}
+	return nil
+}
+
+// obfuscateList obfuscates the items of the list
+func (c *CSCache) obfuscateList(ctx context.Context, gvk schema.GroupVersionKind, list client.ObjectList) error {
+	itemGVK, err := listToGVK(gvk)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.options.SecretFields[itemGVK]; !ok {
+		return nil
+	}
+	return apimeta.EachListItem(list, func(item runtime.Object) error {
+		return c.obfuscate(ctx, itemGVK, item)
+	})
+}
+
+// fromRedacted sets the object to the content with the values of the secret fields replaced
+// by the marker. The secret field is a dot separated path, e.g. data or spec.token, and all
+// the values of a map field are replaced.
+func fromRedacted(content map[string]interface{}, secretFields []string, marker string, obj runtime.Object) error {
+	redacted := runtime.DeepCopyJSON(content)
+	for _, field := range secretFields {
+		path := strings.Split(field, ".")
+		value, found, err := unstructured.NestedFieldNoCopy(redacted, path...)
+		if err != nil || !found {
+			continue
+		}
+		if values, ok := value.(map[string]interface{}); ok {
+			for k := range values {
+				values[k] = marker
+			}
+			continue
+		}
+		if err := unstructured.SetNestedField(redacted, marker, path...); err != nil {
+			return err
+		}
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(redacted, obj)
+}
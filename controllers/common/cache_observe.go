@@ -0,0 +1,59 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// observer is an event handler registered by Observe
+type observer struct {
+	gvk     schema.GroupVersionKind
+	handler toolscache.ResourceEventHandlerFuncs
+}
+
+// Observe adds the handler to the informer of the GVK, e.g. to invalidate a secondary cache.
+// All the handlers of the GVK are called for each event. The handlers observed before Start
+// are added when the cache starts.
+func (c *CSCache) Observe(gvk schema.GroupVersionKind, handler toolscache.ResourceEventHandlerFuncs) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	informer, ok := c.informerMap[gvk]
+	if !ok || isListGVK(gvk) {
+		return fmt.Errorf("failed to observe %s: it is not in the cache", gvk)
+	}
+	if c.startCtx == nil {
+		c.observers = append(c.observers, observer{gvk: gvk, handler: handler})
+		return nil
+	}
+	c.informerFor(gvk, informer).AddEventHandler(handler)
+	return nil
+}
+
+// addObserversLocked adds the handlers observed before Start to the informers.
+// The caller must hold c.mu.
+func (c *CSCache) addObserversLocked() {
+	for _, o := range c.observers {
+		if informer, ok := c.informerMap[o.gvk]; ok {
+			c.informerFor(o.gvk, informer).AddEventHandler(o.handler)
+		}
+	}
+	c.observers = nil
+}
@@ -0,0 +1,540 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CSCacheOptions are the optional settings for the CSCache
+type CSCacheOptions struct {
+	// Metrics exposes the CSCache metrics in the controller-runtime metrics registry
+	Metrics bool
+	// AutoRecovery rebuilds the informer of the GVK when its list and watch keep failing, or
+	// it exits unexpectedly
+	AutoRecovery map[schema.GroupVersionKind]RecoveryPolicy
+	// DeltaBasedGVKs deliver the UPDATE events of the GVKs as JSON merge patches
+	DeltaBasedGVKs []schema.GroupVersionKind
+	// StrictResourceVersion treats the objects older than the highest resourceVersion
+	// seen for them as cache misses
+	StrictResourceVersion bool
+	// SchemaMigrations migrate the objects of the GVK returned from the informer store
+	SchemaMigrations map[schema.GroupVersionKind]MigrateFunc
+	// ResyncHook is called with the number of objects resynced by the informer of a GVK
+	ResyncHook func(ctx context.Context, gvk schema.GroupVersionKind, count int)
+	// TTLEvictions evict the objects of the GVK from the informer store after a TTL
+	TTLEvictions map[schema.GroupVersionKind]TTLPolicy
+	// SyncMetricRegistry records the time-to-first-sync of the informers in a histogram
+	SyncMetricRegistry prometheus.Registerer
+	// CacheMissCallback is called asynchronously whenever an object is fetched from the api server
+	CacheMissCallback func(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey)
+	// Headers are injected into the requests of the informers and getFromClient
+	Headers map[string]string
+	// CostModelRegistry records the cost of processing the informer events of each GVK, and
+	// CostModelAllocEvery is the period in events of the heap allocation samples,
+	// DefaultCostModelAllocEvery if 0, not sampled if negative
+	CostModelRegistry   prometheus.Registerer
+	CostModelAllocEvery int
+	// GVKObjectLimit is the maximum number of objects of the GVK in the informer store.
+	// The least recently accessed objects are evicted once the limit is exceeded.
+	GVKObjectLimit map[schema.GroupVersionKind]int
+	// CrossGVKDependencies hold back the events of each GVK until the event of the GVK
+	// it depends on with the same correlation ID has been processed
+	CrossGVKDependencies map[schema.GroupVersionKind]schema.GroupVersionKind
+	// SelectorCacheSize is the number of compiled label selectors kept for List,
+	// DefaultSelectorCacheSize if not set
+	SelectorCacheSize int
+	// WriteThroughExpiry is how long the objects fetched from the api server serve Get
+	// before the informer store
+	WriteThroughExpiry time.Duration
+	// ElectedCh resumes the event processing when it fires, i.e. the operator acquires leadership
+	ElectedCh <-chan struct{}
+	// FollowerCh suspends the event processing when it fires, i.e. the operator loses leadership
+	FollowerCh <-chan struct{}
+	// SecondaryConfig is the config of the secondary regional cluster serving getFromClient
+	// when RegionFallbackCondition is true for the error of the primary cluster
+	SecondaryConfig         *rest.Config
+	RegionFallbackCondition func(err error) bool
+	// GVKCodecFactories decode the resources of the GVK with non-standard content types,
+	// e.g. Protobuf, instead of the default codec factory
+	GVKCodecFactories map[schema.GroupVersionKind]runtime.NegotiatedSerializer
+	// QueryRouter returns the route key of the Get and List requests. The requests are served
+	// by the cache registered for the route key by RegisterRoute, if any.
+	QueryRouter func(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) string
+	// ParentCache serves Get for the objects missing in the local informer store, except the
+	// ones matching OverrideSelector
+	ParentCache      *CSCache
+	OverrideSelector labels.Selector
+	// SecretFields are the fields of the GVK redacted in the objects returned by Get and List
+	SecretFields map[schema.GroupVersionKind][]string
+	// ReadOnly rejects the mutating calls made by the cache layer with ErrReadOnlyCache
+	ReadOnly bool
+	// LazyGVKs of the cluster GVK list don't have their informers built and started until
+	// the first Get or List, which waits at most the LazyInitTimeouts of the GVK for the
+	// informer to sync, DefaultLazyInitTimeout if not set
+	LazyGVKs         []schema.GroupVersionKind
+	LazyInitTimeouts map[schema.GroupVersionKind]time.Duration
+	// TLSRotationEnabled reloads the client certificate files of the config on every new
+	// connection of the informers and getFromClient
+	TLSRotationEnabled bool
+	// AutoDiscoverCRDs adds the GVKs of the cluster-scoped CRDs matching CRDLabelSelector
+	// to the cluster GVK list when the cache is built
+	AutoDiscoverCRDs bool
+	CRDLabelSelector string
+	// APIServerTimeout bounds each request of getFromClient and the informer lists, so that
+	// a slow api server doesn't block the reconciles when the context has no deadline
+	APIServerTimeout time.Duration
+	// DryRunAPIClient sends the requests of getFromClient and the deletes of the expired objects
+	// with dryRun=All, and doesn't keep the results in the write-through cache or the
+	// resourceVersion tracker
+	DryRunAPIClient bool
+	// EventRecorder emits a Warning event on EventObject, e.g. the operator pod, after
+	// ErrorThreshold consecutive getFromClient failures of a GVK, DefaultErrorThreshold if not set
+	EventRecorder  record.EventRecorder
+	EventObject    runtime.Object
+	ErrorThreshold int
+	// FieldIndexes are registered when the cache is built, so that List with a field
+	// selector on them works without calling IndexField before Start
+	FieldIndexes []FieldIndexSpec
+	// DebugHTTPMux serves the state of the informers on DebugCachePath
+	DebugHTTPMux *http.ServeMux
+	// InformerTweakOptions customize the list and watch options of the informer of the GVK,
+	// e.g. with a field selector, the options are not changed by default
+	InformerTweakOptions map[schema.GroupVersionKind]TweakListOptionsFunc
+	// AccessLogRetention is how long the changes of the objects are kept for TouchedSince,
+	// DefaultAccessLogRetention if not set
+	AccessLogRetention time.Duration
+	// GVKKeyFuncs are the custom key functions of the objects of the GVK served by GetByCustomKey
+	GVKKeyFuncs map[schema.GroupVersionKind]toolscache.KeyFunc
+	// WatchdogInterval is the interval the informers are checked by the watchdog. The informers
+	// which have received no event for two intervals are restarted.
+	WatchdogInterval time.Duration
+	// WriteThroughUpdates updates the objects swapped in the informer store by CompareAndSwap
+	// on the api server as well
+	WriteThroughUpdates bool
+	// FallbackPolicy controls when Get falls back to the api server on a store miss, and
+	// FallbackDelay is how long FallbackOnTimeout waits, DefaultFallbackDelay if not set
+	FallbackPolicy FallbackPolicy
+	FallbackDelay  time.Duration
+	// ExcludeNamespaces are the namespaces whose objects are not listed and watched, by a
+	// metadata.namespace field selector. The selector is only added to the GVKs the Mapper of
+	// the cache options knows as namespace-scoped, and not to the informers of InformerFactory.
+	ExcludeNamespaces []string
+	// InformerFactory shares the informers of the resources it knows with the other users
+	// of the factory, instead of building an informer with its own watch per GVK. The shared
+	// informers are listed and watched with the namespace and tweaks of the factory.
+	InformerFactory informers.SharedInformerFactory
+	// DynamicClient gets, lists and watches the resources whose REST client can't be built.
+	// The informers watching with the dynamic client store unstructured objects.
+	DynamicClient dynamic.Interface
+}
+
+// TweakListOptionsFunc customizes the list and watch options of an informer
+type TweakListOptionsFunc func(*metav1.ListOptions)
+
+// TTLPolicy defines when and how the objects are evicted
+type TTLPolicy struct {
+	// TTL is the time since the creation of the object after which it is evicted
+	TTL time.Duration
+	// DeleteFromCluster also deletes the evicted object from the cluster
+	DeleteFromCluster bool
+}
+
+// MigrateFunc migrates a cached object to a newer schema
+type MigrateFunc func(runtime.Object) (runtime.Object, error)
+
+// RecoveryPolicy defines how a failed informer is rebuilt
+type RecoveryPolicy struct {
+	// MaxAttempts is the maximum number of times the informer is rebuilt without a successful
+	// list or watch
+	MaxAttempts int
+	// Backoff is the wait schedule between the attempts. The informer is rebuilt once its list
+	// and watch have failed for the duration of the backoff.
+	Backoff wait.Backoff
+}
+
+// CSCacheOption configures the CSCacheOptions
+type CSCacheOption func(*CSCacheOptions)
+
+// WithMetrics exposes the CSCache metrics in the controller-runtime metrics registry
+func WithMetrics() CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.Metrics = true
+	}
+}
+
+// WithAutoRecovery rebuilds and restarts the informer of the GVK when its list and watch keep
+// failing or it exits unexpectedly, waiting per the backoff schedule between at most
+// maxAttempts attempts
+func WithAutoRecovery(gvk schema.GroupVersionKind, maxAttempts int, backoff wait.Backoff) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.AutoRecovery == nil {
+			o.AutoRecovery = make(map[schema.GroupVersionKind]RecoveryPolicy)
+		}
+		o.AutoRecovery[gvk] = RecoveryPolicy{MaxAttempts: maxAttempts, Backoff: backoff}
+	}
+}
+
+// WithDeltaBasedReconcile delivers the UPDATE events of the GVK to the PatchEventHandlers
+// as JSON merge patches between the old and new objects
+func WithDeltaBasedReconcile(gvk schema.GroupVersionKind) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.DeltaBasedGVKs = append(o.DeltaBasedGVKs, gvk)
+	}
+}
+
+// WithStrictResourceVersion treats the objects in the informer store that are older than
+// the highest resourceVersion seen for them as cache misses
+func WithStrictResourceVersion() CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.StrictResourceVersion = true
+	}
+}
+
+// WithSchemaMigration migrates the objects of the GVK returned by Get and List from the
+// informer store with the migrateFunc
+func WithSchemaMigration(gvk schema.GroupVersionKind, migrateFunc func(runtime.Object) (runtime.Object, error)) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.SchemaMigrations == nil {
+			o.SchemaMigrations = make(map[schema.GroupVersionKind]MigrateFunc)
+		}
+		o.SchemaMigrations[gvk] = migrateFunc
+	}
+}
+
+// WithGlobalResyncHook calls fn with the GVK and the number of resynced objects each
+// time the resync period of an informer triggers
+func WithGlobalResyncHook(fn func(ctx context.Context, gvk schema.GroupVersionKind, count int)) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.ResyncHook = fn
+	}
+}
+
+// WithTTLEviction evicts the objects of the GVK from the informer store once they are older
+// than ttl, and also deletes them from the cluster if deleteFromCluster is set
+func WithTTLEviction(gvk schema.GroupVersionKind, ttl time.Duration, deleteFromCluster bool) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.TTLEvictions == nil {
+			o.TTLEvictions = make(map[schema.GroupVersionKind]TTLPolicy)
+		}
+		o.TTLEvictions[gvk] = TTLPolicy{TTL: ttl, DeleteFromCluster: deleteFromCluster}
+	}
+}
+
+// WithInformerSyncedMetric records the duration between Start and the first sync of each
+// GVK informer in the cs_cache_informer_sync_duration_seconds histogram of the registry
+func WithInformerSyncedMetric(registry prometheus.Registerer) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.SyncMetricRegistry = registry
+	}
+}
+
+// WithCacheMissCallback calls fn in a new goroutine with the GVK and key of the object
+// whenever it is fetched from the api server instead of the informer store
+func WithCacheMissCallback(fn func(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey)) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.CacheMissCallback = fn
+	}
+}
+
+// WithContextualMetadata injects the headers, e.g. the cluster ID and the instance name of the
+// operator, into every request made by the informers and getFromClient
+func WithContextualMetadata(headers map[string]string) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.Headers == nil {
+			o.Headers = make(map[string]string)
+		}
+		for k, v := range headers {
+			o.Headers[k] = v
+		}
+	}
+}
+
+// WithGVKCostModel records the time spent and the heap allocated by the event handlers
+// processing each ADD/UPDATE/DELETE event in the cs_cache_event_cpu_seconds and
+// cs_cache_event_alloc_bytes histograms of the registry
+func WithGVKCostModel(registry prometheus.Registerer) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.CostModelRegistry = registry
+	}
+}
+
+// WithCostModelAllocSampling samples the heap allocation of the event handlers for the
+// cs_cache_event_alloc_bytes histogram of the cost model on one of every n events, instead
+// of DefaultCostModelAllocEvery. The allocation is not sampled if n is negative.
+func WithCostModelAllocSampling(n int) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.CostModelAllocEvery = n
+	}
+}
+
+// WithGVKObjectLimit limits the number of objects of the GVK in the informer store, evicting
+// the least recently accessed ones
+func WithGVKObjectLimit(gvk schema.GroupVersionKind, limit int) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.GVKObjectLimit == nil {
+			o.GVKObjectLimit = make(map[schema.GroupVersionKind]int)
+		}
+		o.GVKObjectLimit[gvk] = limit
+	}
+}
+
+// WithCrossGVKConsistency holds back the events of the GVK delivered to the handlers added
+// through GetInformer, until an event of dependsOn with the same CorrelationIDAnnotation
+// has been processed, and then delivers them together. At most 100 events are held back per
+// correlation ID, and they are dropped after 5 minutes without the event of dependsOn.
+func WithCrossGVKConsistency(gvk schema.GroupVersionKind, dependsOn schema.GroupVersionKind) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.CrossGVKDependencies == nil {
+			o.CrossGVKDependencies = make(map[schema.GroupVersionKind]schema.GroupVersionKind)
+		}
+		o.CrossGVKDependencies[gvk] = dependsOn
+	}
+}
+
+// WithWriteThroughPolicy serves the objects fetched from the api server by Get for d,
+// bypassing the informer store, e.g. while the informer is catching up
+func WithWriteThroughPolicy(d time.Duration) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.WriteThroughExpiry = d
+	}
+}
+
+// WithLeaderElectionIntegration keeps the informers running on the non-leader replicas, but
+// suspends delivering their events to the handlers until electorCh fires, and again each
+// time followerCh fires. The events of each object are coalesced while suspended, and
+// delivered once resumed.
+func WithLeaderElectionIntegration(electorCh <-chan struct{}, followerCh <-chan struct{}) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.ElectedCh = electorCh
+		o.FollowerCh = followerCh
+	}
+}
+
+// WithMultiRegionFallback re-issues the requests of getFromClient against the secondary regional
+// cluster when fallbackCondition is true for the error of the primary cluster. The secondary
+// cluster is read-only and best-effort, the error of the primary cluster is returned if it fails.
+func WithMultiRegionFallback(secondaryConfig *rest.Config, fallbackCondition func(err error) bool) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.SecondaryConfig = secondaryConfig
+		o.RegionFallbackCondition = fallbackCondition
+	}
+}
+
+// WithQueryRouter routes the Get and List requests to the cache registered by RegisterRoute
+// for the route key returned by fn, before looking them up in the informer stores
+func WithQueryRouter(fn func(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (routeKey string)) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.QueryRouter = fn
+	}
+}
+
+// WithObjectCacheHierarchy looks up the objects missing in the local informer store in the
+// informer store of the parent, e.g. a global cache of the cluster-wide objects. The objects
+// of the parent matching overrideSelector are overridden by the local result.
+func WithObjectCacheHierarchy(parent *CSCache, overrideSelector labels.Selector) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.ParentCache = parent
+		o.OverrideSelector = overrideSelector
+	}
+}
+
+// WithSecretObfuscation replaces the values of the secretFields of the GVK, e.g. data or
+// spec.token, with the RedactionMarker in the objects returned by Get and List, unless the
+// context of the caller is annotated by WithSecretAccess
+func WithSecretObfuscation(gvk schema.GroupVersionKind, secretFields []string) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.SecretFields == nil {
+			o.SecretFields = make(map[schema.GroupVersionKind][]string)
+		}
+		o.SecretFields[gvk] = append(o.SecretFields[gvk], secretFields...)
+	}
+}
+
+// WithReadOnly makes the cache strictly read-only, the mutating calls made through the cache
+// layer, e.g. the TTL eviction deleting from the cluster, return ErrReadOnlyCache
+func WithReadOnly() CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// WithLazyGVK defers building and starting the informer of the GVK of the cluster GVK list
+// until its first Get or List, which waits at most initTimeout for the informer to sync
+func WithLazyGVK(gvk schema.GroupVersionKind, initTimeout time.Duration) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.LazyGVKs = append(o.LazyGVKs, gvk)
+		if o.LazyInitTimeouts == nil {
+			o.LazyInitTimeouts = make(map[schema.GroupVersionKind]time.Duration)
+		}
+		o.LazyInitTimeouts[gvk] = initTimeout
+	}
+}
+
+// WithTLSRotation reloads the client certificate files of the config on every new connection,
+// so that a rotated certificate is used without restarting the operator
+func WithTLSRotation() CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.TLSRotationEnabled = true
+	}
+}
+
+// WithCRDAutoDiscovery watches the GVKs of the cluster-scoped CRDs matching the label selector
+// installed when the cache is built, in addition to the cluster GVK list
+func WithCRDAutoDiscovery(labelSelector string) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.AutoDiscoverCRDs = true
+		o.CRDLabelSelector = labelSelector
+	}
+}
+
+// WithAPIServerTimeout bounds each api server request made by the cache, except the watches
+func WithAPIServerTimeout(timeout time.Duration) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.APIServerTimeout = timeout
+	}
+}
+
+// WithDryRunAPIClient previews the objects fetched from the api server without affecting the
+// state of the cache. The api server ignores dryRun on reads, it only marks the requests, and
+// doesn't delete the expired objects evicted with DeleteFromCluster.
+func WithDryRunAPIClient() CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.DryRunAPIClient = true
+	}
+}
+
+// WithEventRecorder emits a CacheError Warning event on the object after threshold
+// consecutive failures to get the objects of a GVK from the api server
+func WithEventRecorder(recorder record.EventRecorder, object runtime.Object, threshold int) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.EventRecorder = recorder
+		o.EventObject = object
+		o.ErrorThreshold = threshold
+	}
+}
+
+// WithFieldIndex registers the index of the field of the GVK when the cache is built
+func WithFieldIndex(gvk schema.GroupVersionKind, field string, extractor client.IndexerFunc) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.FieldIndexes = append(o.FieldIndexes, FieldIndexSpec{GVK: gvk, Field: field, Extractor: extractor})
+	}
+}
+
+// WithDebugHandler registers the handler serving the state of the informers on
+// DebugCachePath of the mux
+func WithDebugHandler(mux *http.ServeMux) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.DebugHTTPMux = mux
+	}
+}
+
+// WithInformerTweakOptions customizes the list and watch options of the informer of the GVK
+func WithInformerTweakOptions(gvk schema.GroupVersionKind, tweak TweakListOptionsFunc) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.InformerTweakOptions == nil {
+			o.InformerTweakOptions = make(map[schema.GroupVersionKind]TweakListOptionsFunc)
+		}
+		o.InformerTweakOptions[gvk] = tweak
+	}
+}
+
+// WithAccessLogRetention keeps the changes of the objects for TouchedSince for the retention
+func WithAccessLogRetention(retention time.Duration) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.AccessLogRetention = retention
+	}
+}
+
+// WithKeyFunc indexes the objects of the GVK by the keys of keyFunc for GetByCustomKey
+func WithKeyFunc(gvk schema.GroupVersionKind, keyFunc toolscache.KeyFunc) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		if o.GVKKeyFuncs == nil {
+			o.GVKKeyFuncs = make(map[schema.GroupVersionKind]toolscache.KeyFunc)
+		}
+		o.GVKKeyFuncs[gvk] = keyFunc
+	}
+}
+
+// WithWatchdog restarts the informers which have received no event for two intervals
+func WithWatchdog(interval time.Duration) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.WatchdogInterval = interval
+	}
+}
+
+// WithWriteThroughUpdates updates the objects swapped in the informer store on the api server,
+// e.g. for the test harnesses and the shadow deployments using the cache as the write layer
+func WithWriteThroughUpdates() CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.WriteThroughUpdates = true
+	}
+}
+
+// WithFallbackPolicy controls when Get falls back to the api server on a store miss. The
+// delay is how long FallbackOnTimeout waits for the object to be added to the store.
+func WithFallbackPolicy(policy FallbackPolicy, delay time.Duration) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.FallbackPolicy = policy
+		o.FallbackDelay = delay
+	}
+}
+
+// WithExcludeNamespaces keeps the objects of the namespaces out of the informer stores
+func WithExcludeNamespaces(namespaces ...string) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.ExcludeNamespaces = append(o.ExcludeNamespaces, namespaces...)
+	}
+}
+
+// WithInformerFactory shares the informers of the resources known to the factory
+func WithInformerFactory(factory informers.SharedInformerFactory) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.InformerFactory = factory
+	}
+}
+
+// WithDynamicClient falls back to the dynamic client when the REST client of a GVK can't be built
+func WithDynamicClient(dynamicClient dynamic.Interface) CSCacheOption {
+	return func(o *CSCacheOptions) {
+		o.DynamicClient = dynamicClient
+	}
+}
+
+// buildCSCacheOptions applies the given options on top of the defaults
+func buildCSCacheOptions(options []CSCacheOption) CSCacheOptions {
+	csOpts := CSCacheOptions{}
+	for _, option := range options {
+		option(&csOpts)
+	}
+	return csOpts
+}
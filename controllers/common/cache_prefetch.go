@@ -0,0 +1,98 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Prefetch adds the objects of the keys missing in the informer store of the obj type, so that
+// a batch reconcile doesn't get them one by one from the api server. The field selectors don't
+// support set-based matching on the name, so the missing keys of a namespace are fetched by a
+// single List of the namespace, or of the name if only one key is missing.
+func (c *CSCache) Prefetch(ctx context.Context, keys []client.ObjectKey, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
+	if err != nil {
+		return err
+	}
+	informer, ok := c.getInformer(gvk)
+	if !ok {
+		return fmt.Errorf("failed to prefetch %s: it is not in the cache", gvk)
+	}
+	store := informer.GetStore()
+
+	// Group the keys missing in the store by namespace
+	missing := make(map[string]map[string]bool)
+	for _, key := range keys {
+		keyString := key.Name
+		if key.Namespace != "" {
+			keyString = key.Namespace + "/" + key.Name
+		}
+		if _, exists, err := store.GetByKey(keyString); err != nil {
+			return err
+		} else if exists {
+			continue
+		}
+		if missing[key.Namespace] == nil {
+			missing[key.Namespace] = make(map[string]bool)
+		}
+		missing[key.Namespace][key.Name] = true
+	}
+
+	added := 0
+	for namespace, names := range missing {
+		opts := metav1.ListOptions{}
+		if len(names) == 1 {
+			for name := range names {
+				opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+			}
+		}
+		items, _, err := c.listFromClientWithOptions(ctx, gvk, namespace, opts)
+		if err != nil {
+			return fmt.Errorf("failed to prefetch %s: %v", gvk, err)
+		}
+		for _, item := range items {
+			meta, err := apimeta.Accessor(item)
+			if err != nil {
+				return err
+			}
+			if !names[meta.GetName()] {
+				continue
+			}
+			// Don't replace the object the informer has added in the meantime
+			if _, exists, err := store.Get(item); err != nil {
+				return err
+			} else if exists {
+				continue
+			}
+			if err := store.Add(item); err != nil {
+				return err
+			}
+			added++
+		}
+	}
+	klog.V(2).Infof("Prefetched %d objects of %s", added, gvk)
+	return nil
+}
@@ -0,0 +1,144 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// Prepopulate seeds the informer stores with the objects listed from the api server before
+// the informers run, so that WaitForCacheSync returns without waiting for the initial
+// list-watch of the informers. The objects seeded are delivered as UPDATE events once the
+// informers have listed them again.
+func (c *CSCache) Prepopulate(ctx context.Context) error {
+	c.mu.RLock()
+	informers := make(map[schema.GroupVersionKind]toolscache.SharedIndexInformer)
+	for gvk, informer := range c.informerMap {
+		if !isListGVK(gvk) {
+			informers[gvk] = informer
+		}
+	}
+	c.mu.RUnlock()
+
+	for gvk, informer := range informers {
+		items, err := c.listFromClient(ctx, gvk)
+		if err != nil {
+			return fmt.Errorf("failed to prepopulate %s: %v", gvk, err)
+		}
+		if err := informer.GetStore().Replace(items, ""); err != nil {
+			return fmt.Errorf("failed to prepopulate %s: %v", gvk, err)
+		}
+
+		c.mu.Lock()
+		if c.prepopulated == nil {
+			c.prepopulated = make(map[schema.GroupVersionKind]bool)
+		}
+		c.prepopulated[gvk] = true
+		c.mu.Unlock()
+		klog.Infof("Prepopulated %d objects of %s", len(items), gvk)
+	}
+	return nil
+}
+
+// listFromClient lists the objects of the GVK from the api server in the namespaces the
+// informer of the GVK is listed in
+func (c *CSCache) listFromClient(ctx context.Context, gvk schema.GroupVersionKind) ([]interface{}, error) {
+	items, _, err := c.listFromInformerNamespaces(ctx, gvk)
+	return items, err
+}
+
+// listFromInformerNamespaces lists the objects of the GVK from the api server in each of the
+// namespaces the informer of the GVK is listed in, along with the highest resourceVersion of
+// the lists
+func (c *CSCache) listFromInformerNamespaces(ctx context.Context, gvk schema.GroupVersionKind) ([]interface{}, string, error) {
+	var items []interface{}
+	var resourceVersion string
+	for _, namespace := range c.informerNamespaces(gvk) {
+		nsItems, nsVersion, err := c.listFromClientWithOptions(ctx, gvk, namespace, metav1.ListOptions{})
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, nsItems...)
+		if resourceVersionLess(resourceVersion, nsVersion) {
+			resourceVersion = nsVersion
+		}
+	}
+	return items, resourceVersion, nil
+}
+
+// listFromClientWithOptions lists the objects of the GVK in the namespace from the api server.
+// The options are tweaked like the ones the informer of the GVK is listed with, and the
+// dynamic client lists the unstructured objects when the REST client can't be built, so
+// that the objects listed are the ones the informer would list. The resourceVersion of the
+// list is returned along with its objects.
+func (c *CSCache) listFromClientWithOptions(ctx context.Context, gvk schema.GroupVersionKind, namespace string, opts metav1.ListOptions) ([]interface{}, string, error) {
+	informerTweak(c.cacheOpts, gvk, c.options)(&opts)
+
+	var result runtime.Object
+	client, err := c.clientForGVK(ctx, gvk)
+	if err != nil {
+		if c.options.DynamicClient == nil {
+			return nil, "", err
+		}
+		result, err = dynamicResource(c.options.DynamicClient, gvk, namespace).List(ctx, opts)
+	} else {
+		result, err = client.
+			Get().
+			NamespaceIfScoped(namespace, namespace != "").
+			Resource(kindToResource(gvk.Kind)).
+			VersionedParams(&opts, metav1.ParameterCodec).
+			Do(ctx).
+			Get()
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	listMeta, err := apimeta.ListAccessor(result)
+	if err != nil {
+		return nil, "", err
+	}
+	objs, err := apimeta.ExtractList(result)
+	if err != nil {
+		return nil, "", err
+	}
+	items := make([]interface{}, 0, len(objs))
+	for _, obj := range objs {
+		items = append(items, obj)
+	}
+	return items, listMeta.GetResourceVersion(), nil
+}
+
+// hasSyncedLocked checks if the informer of the GVK has synced or been prepopulated.
+// The caller must hold c.mu.
+func (c *CSCache) hasSyncedLocked(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) bool {
+	if informer.HasSynced() {
+		return true
+	}
+	if itemGVK, err := listToGVK(gvk); err == nil {
+		gvk = itemGVK
+	}
+	return c.prepopulated[gvk]
+}
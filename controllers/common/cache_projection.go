@@ -0,0 +1,153 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ProjectedListOption makes CSCache.List return only the projected fields of every object
+type ProjectedListOption struct {
+	Fields []string
+}
+
+// ApplyToList implements client.ListOption. The option is only recognized by CSCache.List.
+func (ProjectedListOption) ApplyToList(*client.ListOptions) {}
+
+// ProjectFields projects the objects listed from the informer store to the given fields.
+// A field is a dot separated path of json names, e.g. "metadata.name" or "metadata.annotations.foo",
+// the last element of a path into a map is the map key.
+func ProjectFields(fields ...string) ProjectedListOption {
+	return ProjectedListOption{Fields: fields}
+}
+
+// projectedFields returns the fields of the ProjectedListOption in the list options
+func projectedFields(opts []client.ListOption) ([]string, bool) {
+	for _, opt := range opts {
+		if projected, ok := opt.(ProjectedListOption); ok {
+			return projected.Fields, true
+		}
+	}
+	return nil, false
+}
+
+// project returns a new object of the same type holding only the given fields of obj
+func project(obj runtime.Object, fields []string) (runtime.Object, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		out := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		for _, field := range fields {
+			path := strings.Split(field, ".")
+			val, found, err := unstructured.NestedFieldNoCopy(u.Object, path...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to project field %s: %v", field, err)
+			}
+			if !found {
+				continue
+			}
+			if err := unstructured.SetNestedField(out.Object, runtime.DeepCopyJSONValue(val), path...); err != nil {
+				return nil, fmt.Errorf("failed to project field %s: %v", field, err)
+			}
+		}
+		return out, nil
+	}
+
+	src := reflect.ValueOf(obj)
+	if src.Kind() != reflect.Ptr || src.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unable to project %T, which is not a pointer to a struct", obj)
+	}
+	dst := reflect.New(src.Elem().Type())
+	for _, field := range fields {
+		if err := copyPath(dst.Elem(), src.Elem(), strings.Split(field, ".")); err != nil {
+			return nil, fmt.Errorf("failed to project field %s of %T: %v", field, obj, err)
+		}
+	}
+	return dst.Interface().(runtime.Object), nil
+}
+
+// copyPath copies the value at the path from src to dst, leaving everything else in dst untouched
+func copyPath(dst, src reflect.Value, path []string) error {
+	if len(path) == 0 {
+		dst.Set(src)
+		return nil
+	}
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(src.Type().Elem()))
+		}
+		return copyPath(dst.Elem(), src.Elem(), path)
+	case reflect.Struct:
+		index, ok := fieldIndexByJSONName(src.Type(), path[0])
+		if !ok {
+			return fmt.Errorf("unknown field %q in %s", path[0], src.Type())
+		}
+		return copyPath(dst.FieldByIndex(index), src.FieldByIndex(index), path[1:])
+	case reflect.Map:
+		if src.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", src.Type().Key())
+		}
+		key := reflect.ValueOf(path[0]).Convert(src.Type().Key())
+		val := src.MapIndex(key)
+		if !val.IsValid() {
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(src.Type()))
+		}
+		// Map elements are not addressable, copy into a new element and put it back
+		elem := reflect.New(src.Type().Elem()).Elem()
+		if existing := dst.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := copyPath(elem, val, path[1:]); err != nil {
+			return err
+		}
+		dst.SetMapIndex(key, elem)
+		return nil
+	default:
+		return fmt.Errorf("unable to select %q in %s", path[0], src.Type())
+	}
+}
+
+// fieldIndexByJSONName finds the struct field with the json name, looking into the inlined fields as well
+func fieldIndexByJSONName(t reflect.Type, name string) ([]int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == name {
+			return []int{i}, true
+		}
+		if field.Anonymous && tag[0] == "" && field.Type.Kind() == reflect.Struct {
+			if index, ok := fieldIndexByJSONName(field.Type, name); ok {
+				return append([]int{i}, index...), true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,140 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// queueDepthPeriod is the period the cs_cache_event_queue_depth gauges are updated
+const queueDepthPeriod = 5 * time.Second
+
+// eventQueue delivers the events of the informer to the handler in order from its own
+// goroutine, so that the events waiting for a slow handler are counted in the queue depth
+// of the GVK. The goroutine only runs while events are queued.
+type eventQueue struct {
+	handler toolscache.ResourceEventHandler
+	// depth counts the events queued and not yet handled for the GVK, accessed atomically
+	depth *int64
+
+	mu      sync.Mutex
+	pending []func()
+	running bool
+}
+
+func (q *eventQueue) OnAdd(obj interface{}) {
+	q.enqueue(func() { q.handler.OnAdd(obj) })
+}
+
+func (q *eventQueue) OnUpdate(oldObj, newObj interface{}) {
+	q.enqueue(func() { q.handler.OnUpdate(oldObj, newObj) })
+}
+
+func (q *eventQueue) OnDelete(obj interface{}) {
+	q.enqueue(func() { q.handler.OnDelete(obj) })
+}
+
+// enqueue queues the delivery of the event, and starts draining the queue if it isn't
+func (q *eventQueue) enqueue(deliver func()) {
+	atomic.AddInt64(q.depth, 1)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, deliver)
+	if !q.running {
+		q.running = true
+		go q.drain()
+	}
+}
+
+// drain delivers the queued events until the queue is empty
+func (q *eventQueue) drain() {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		deliver := q.pending[0]
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		deliver()
+		atomic.AddInt64(q.depth, -1)
+	}
+}
+
+// queueInformer queues the events of the handlers added to the informer, counting them in
+// the queue depth of the GVK
+type queueInformer struct {
+	toolscache.SharedIndexInformer
+	depth *int64
+}
+
+var _ cache.Informer = &queueInformer{}
+
+// AddEventHandler adds the handler behind an event queue
+func (i *queueInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.SharedIndexInformer.AddEventHandler(&eventQueue{handler: handler, depth: i.depth})
+}
+
+// AddEventHandlerWithResyncPeriod adds the handler behind an event queue
+func (i *queueInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.SharedIndexInformer.AddEventHandlerWithResyncPeriod(&eventQueue{handler: handler, depth: i.depth}, resyncPeriod)
+}
+
+// queueDepthFor returns the counter of the events queued for the handlers of the GVK
+func (c *CSCache) queueDepthFor(gvk schema.GroupVersionKind) *int64 {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	if c.queueDepths == nil {
+		c.queueDepths = make(map[schema.GroupVersionKind]*int64)
+	}
+	depth, ok := c.queueDepths[gvk]
+	if !ok {
+		depth = new(int64)
+		c.queueDepths[gvk] = depth
+	}
+	return depth
+}
+
+// forgetQueueDepth forgets the queue depth of the GVK removed from the cache
+func (c *CSCache) forgetQueueDepth(gvk schema.GroupVersionKind) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	delete(c.queueDepths, gvk)
+	if c.queueDepthGauge != nil {
+		c.queueDepthGauge.DeleteLabelValues(gvk.String())
+	}
+}
+
+// updateQueueDepth sets the cs_cache_event_queue_depth gauge of each GVK with handlers
+func (c *CSCache) updateQueueDepth(ctx context.Context) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	for gvk, depth := range c.queueDepths {
+		c.queueDepthGauge.WithLabelValues(gvk.String()).Set(float64(atomic.LoadInt64(depth)))
+	}
+}
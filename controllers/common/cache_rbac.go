@@ -0,0 +1,64 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// recordForbidden counts the request of the GVK forbidden by the api server, which means
+// the operator lacks the RBAC permission of the verb on the resource
+func (c *CSCache) recordForbidden(gvk schema.GroupVersionKind, key client.ObjectKey, verb string, err error) {
+	klog.Warningf("Forbidden to %s %s %s from %s, the operator is missing the RBAC permission of the verb: %v", verb, gvk, key, c.config.Host, err)
+
+	c.forbiddenMu.Lock()
+	defer c.forbiddenMu.Unlock()
+	if c.forbiddenCounts == nil {
+		c.forbiddenCounts = make(map[schema.GroupVersionKind]uint64)
+	}
+	c.forbiddenCounts[gvk]++
+}
+
+// ForbiddenGVKs returns the GVKs whose requests have been forbidden by the api server, so
+// that the missing RBAC permissions can be surfaced in the status conditions
+func (c *CSCache) ForbiddenGVKs() []schema.GroupVersionKind {
+	c.forbiddenMu.Lock()
+	defer c.forbiddenMu.Unlock()
+	gvks := make([]schema.GroupVersionKind, 0, len(c.forbiddenCounts))
+	for gvk := range c.forbiddenCounts {
+		gvks = append(gvks, gvk)
+	}
+	sort.Slice(gvks, func(i, j int) bool {
+		return gvks[i].String() < gvks[j].String()
+	})
+	return gvks
+}
+
+// forbiddenCountsSnapshot returns a copy of the number of forbidden requests of each GVK
+func (c *CSCache) forbiddenCountsSnapshot() map[schema.GroupVersionKind]uint64 {
+	c.forbiddenMu.Lock()
+	defer c.forbiddenMu.Unlock()
+	counts := make(map[schema.GroupVersionKind]uint64, len(c.forbiddenCounts))
+	for gvk, count := range c.forbiddenCounts {
+		counts[gvk] = count
+	}
+	return counts
+}
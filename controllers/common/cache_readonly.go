@@ -0,0 +1,61 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrReadOnlyCache is returned when a mutating call is made through a read-only cache
+var ErrReadOnlyCache = errors.New("the cache is read-only")
+
+// readOnlyCache is the fallback cache of a read-only CSCache. The reads and the informers
+// are served by the wrapped cache, and the mutating calls return ErrReadOnlyCache.
+type readOnlyCache struct {
+	cache.Cache
+}
+
+var _ client.Writer = readOnlyCache{}
+
+// Create returns ErrReadOnlyCache
+func (readOnlyCache) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return ErrReadOnlyCache
+}
+
+// Update returns ErrReadOnlyCache
+func (readOnlyCache) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return ErrReadOnlyCache
+}
+
+// Patch returns ErrReadOnlyCache
+func (readOnlyCache) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return ErrReadOnlyCache
+}
+
+// Delete returns ErrReadOnlyCache
+func (readOnlyCache) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return ErrReadOnlyCache
+}
+
+// DeleteAllOf returns ErrReadOnlyCache
+func (readOnlyCache) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	return ErrReadOnlyCache
+}
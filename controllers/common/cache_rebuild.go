@@ -0,0 +1,303 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// watchHealth tracks the list and watch calls of an informer failing without reconnecting
+type watchHealth struct {
+	mu            sync.Mutex
+	failingSince  time.Time
+	lastErr       error
+	succeededOnce bool
+}
+
+// observe records the result of a list or watch call
+func (h *watchHealth) observe(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.failingSince = time.Time{}
+		h.lastErr = nil
+		h.succeededOnce = true
+		return
+	}
+	if h.failingSince.IsZero() {
+		h.failingSince = time.Now()
+	}
+	h.lastErr = err
+}
+
+// failing returns since when the list and watch calls fail, and the last error
+func (h *watchHealth) failing() (time.Time, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.failingSince, h.lastErr
+}
+
+// succeeded checks if a list or watch call has succeeded
+func (h *watchHealth) succeeded() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.succeededOnce
+}
+
+// healthListWatch records the results of the list and watch calls in the watchHealth
+type healthListWatch struct {
+	toolscache.ListerWatcher
+	health *watchHealth
+}
+
+func (lw healthListWatch) List(options metav1.ListOptions) (runtime.Object, error) {
+	obj, err := lw.ListerWatcher.List(options)
+	lw.health.observe(err)
+	return obj, err
+}
+
+func (lw healthListWatch) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	w, err := lw.ListerWatcher.Watch(options)
+	lw.health.observe(err)
+	return w, err
+}
+
+// informerBuildFunc builds a new informer along with the health of its list and watch
+type informerBuildFunc func() (toolscache.SharedIndexInformer, *watchHealth, error)
+
+// registeredHandler is an event handler added to the rebuildableInformer
+type registeredHandler struct {
+	handler      toolscache.ResourceEventHandler
+	resyncPeriod time.Duration
+	withResync   bool
+}
+
+// rebuildableInformer is the informer of a GVK which can be replaced by a new one, e.g. once
+// its watch has stalled. The event handlers, indexers and watch error handler added to it are
+// recorded, and added to the new informer, so that the users of the informer are not affected.
+type rebuildableInformer struct {
+	build informerBuildFunc
+
+	mu           sync.RWMutex
+	current      toolscache.SharedIndexInformer
+	health       *watchHealth
+	handlers     []registeredHandler
+	indexers     toolscache.Indexers
+	errorHandler toolscache.WatchErrorHandler
+	// run is the run of the current informer started before it was swapped in
+	run *informerRun
+}
+
+// informerRun is the run of an informer, stopped by cancel and done once it has stopped
+type informerRun struct {
+	cancel context.CancelFunc
+	done   <-chan struct{}
+}
+
+var _ toolscache.SharedIndexInformer = &rebuildableInformer{}
+
+// newRebuildableInformer builds the informer with the build function used to rebuild it
+func newRebuildableInformer(build informerBuildFunc) (*rebuildableInformer, error) {
+	current, health, err := build()
+	if err != nil {
+		return nil, err
+	}
+	if err := current.SetWatchErrorHandler(healthErrorHandler(health, nil)); err != nil {
+		return nil, err
+	}
+	return &rebuildableInformer{build: build, current: current, health: health, indexers: toolscache.Indexers{}}, nil
+}
+
+// healthErrorHandler records the watch errors in the health, e.g. of a watch stream which has
+// failed, before handing them to the handler, or else the default one
+func healthErrorHandler(health *watchHealth, handler toolscache.WatchErrorHandler) toolscache.WatchErrorHandler {
+	return func(r *toolscache.Reflector, err error) {
+		health.observe(err)
+		if handler == nil {
+			handler = toolscache.DefaultWatchErrorHandler
+		}
+		handler(r, err)
+	}
+}
+
+// replace replaces the current informer by the one rebuilt with build, with the recorded event handlers,
+// indexers and watch error handler. The current informer must have been stopped, the rebuilt
+// one is run by Run.
+func (i *rebuildableInformer) replace(informer toolscache.SharedIndexInformer, health *watchHealth) error {
+	if err := i.prepare(informer, health); err != nil {
+		return err
+	}
+	i.swap(informer, health, nil)
+	return nil
+}
+
+// prepare adds the recorded indexers and watch error handler to the informer, which must not
+// have been run yet. The watch errors are recorded in the health of the informer.
+func (i *rebuildableInformer) prepare(informer toolscache.SharedIndexInformer, health *watchHealth) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if len(i.indexers) > 0 {
+		if err := informer.AddIndexers(i.indexers); err != nil {
+			return err
+		}
+	}
+	return informer.SetWatchErrorHandler(healthErrorHandler(health, i.errorHandler))
+}
+
+// swap makes the prepared informer the current one with the recorded event handlers. The
+// informer already running is given with its run, so that Run waits for it rather than
+// running it again.
+func (i *rebuildableInformer) swap(informer toolscache.SharedIndexInformer, health *watchHealth, run *informerRun) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, h := range i.handlers {
+		if h.withResync {
+			informer.AddEventHandlerWithResyncPeriod(h.handler, h.resyncPeriod)
+		} else {
+			informer.AddEventHandler(h.handler)
+		}
+	}
+	i.current = informer
+	i.health = health
+	i.run = run
+}
+
+// setBuild sets the function the informer is rebuilt with
+func (i *rebuildableInformer) setBuild(build informerBuildFunc) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.build = build
+}
+
+// rebuild builds a new informer with the build function
+func (i *rebuildableInformer) rebuild() (toolscache.SharedIndexInformer, *watchHealth, error) {
+	i.mu.RLock()
+	build := i.build
+	i.mu.RUnlock()
+	return build()
+}
+
+// watchHealth returns the health of the list and watch of the current informer
+func (i *rebuildableInformer) watchHealth() *watchHealth {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.health
+}
+
+func (i *rebuildableInformer) informer() toolscache.SharedIndexInformer {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.current
+}
+
+// eventHandlers returns the event handlers added to the informer
+func (i *rebuildableInformer) eventHandlers() []toolscache.ResourceEventHandler {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	handlers := make([]toolscache.ResourceEventHandler, 0, len(i.handlers))
+	for _, h := range i.handlers {
+		handlers = append(handlers, h.handler)
+	}
+	return handlers
+}
+
+func (i *rebuildableInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handlers = append(i.handlers, registeredHandler{handler: handler})
+	i.current.AddEventHandler(handler)
+}
+
+func (i *rebuildableInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handlers = append(i.handlers, registeredHandler{handler: handler, resyncPeriod: resyncPeriod, withResync: true})
+	i.current.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+}
+
+func (i *rebuildableInformer) AddIndexers(indexers toolscache.Indexers) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i.current.AddIndexers(indexers); err != nil {
+		return err
+	}
+	for name, indexFunc := range indexers {
+		i.indexers[name] = indexFunc
+	}
+	return nil
+}
+
+func (i *rebuildableInformer) SetWatchErrorHandler(handler toolscache.WatchErrorHandler) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i.current.SetWatchErrorHandler(healthErrorHandler(i.health, handler)); err != nil {
+		return err
+	}
+	i.errorHandler = handler
+	return nil
+}
+
+// Run runs the current informer. The informer replaced after the stop channel is closed is
+// not run, so that the rebuild can stop the informer before replacing it. The informer swapped
+// in while already running is waited for rather than run again.
+func (i *rebuildableInformer) Run(stopCh <-chan struct{}) {
+	i.mu.RLock()
+	informer, run := i.current, i.run
+	i.mu.RUnlock()
+	select {
+	case <-stopCh:
+		return
+	default:
+	}
+	if run != nil {
+		select {
+		case <-run.done:
+		case <-stopCh:
+			run.cancel()
+			<-run.done
+		}
+		return
+	}
+	informer.Run(stopCh)
+}
+
+func (i *rebuildableInformer) GetStore() toolscache.Store {
+	return i.informer().GetStore()
+}
+
+func (i *rebuildableInformer) GetIndexer() toolscache.Indexer {
+	return i.informer().GetIndexer()
+}
+
+func (i *rebuildableInformer) GetController() toolscache.Controller {
+	return i.informer().GetController()
+}
+
+func (i *rebuildableInformer) HasSynced() bool {
+	return i.informer().HasSynced()
+}
+
+func (i *rebuildableInformer) LastSyncResourceVersion() string {
+	return i.informer().LastSyncResourceVersion()
+}
@@ -0,0 +1,60 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DefaultErrorThreshold is the number of consecutive getFromClient failures of a GVK
+// emitting a CacheError event by default
+const DefaultErrorThreshold = 5
+
+// CacheErrorReason is the reason of the events emitted on the persistent cache errors
+const CacheErrorReason = "CacheError"
+
+// recordClientResult counts the consecutive getFromClient failures of the GVK, and emits a
+// Warning event on the EventObject once the count reaches the ErrorThreshold. The count is
+// reset by the next success, so that each streak of failures emits a single event.
+func (c *CSCache) recordClientResult(gvk schema.GroupVersionKind, err error) {
+	if c.options.EventRecorder == nil {
+		return
+	}
+
+	c.clientErrorMu.Lock()
+	if err == nil {
+		delete(c.clientErrorCounts, gvk)
+		c.clientErrorMu.Unlock()
+		return
+	}
+	if c.clientErrorCounts == nil {
+		c.clientErrorCounts = make(map[schema.GroupVersionKind]int)
+	}
+	c.clientErrorCounts[gvk]++
+	count := c.clientErrorCounts[gvk]
+	c.clientErrorMu.Unlock()
+
+	threshold := c.options.ErrorThreshold
+	if threshold <= 0 {
+		threshold = DefaultErrorThreshold
+	}
+	if count == threshold && c.options.EventObject != nil {
+		c.options.EventRecorder.Eventf(c.options.EventObject, corev1.EventTypeWarning, CacheErrorReason,
+			"%d consecutive failures to get %s from the api server: %v", count, gvk, err)
+	}
+}
@@ -0,0 +1,58 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newRegionalFallback builds the CSCache of the secondary regional cluster. It has no
+// informers, so it only serves the read requests re-issued by getFromClient.
+func newRegionalFallback(secondaryConfig *rest.Config, csOpts CSCacheOptions, opts cache.Options) (*CSCache, error) {
+	config, err := buildClientConfig(secondaryConfig, CSCacheOptions{Headers: csOpts.Headers, TLSRotationEnabled: csOpts.TLSRotationEnabled})
+	if err != nil {
+		return nil, err
+	}
+	return &CSCache{
+		config:      config,
+		cacheOpts:   opts,
+		informerMap: make(map[schema.GroupVersionKind]toolscache.SharedIndexInformer),
+		Scheme:      opts.Scheme,
+	}, nil
+}
+
+// getFromRegionalFallback re-issues the failed request of getFromClient against the secondary
+// regional cluster. It is best-effort, so the original error is returned if it fails as well.
+func (c *CSCache) getFromRegionalFallback(ctx context.Context, key client.ObjectKey, obj runtime.Object, gvk schema.GroupVersionKind, err error) error {
+	if c.regionalFallback == nil || !c.options.RegionFallbackCondition(err) {
+		return err
+	}
+	if fallbackErr := c.regionalFallback.getFromClient(ctx, key, obj, gvk); fallbackErr != nil {
+		klog.Warningf("Failed to get %s %s from the secondary cluster: %v", gvk, key, fallbackErr)
+		return err
+	}
+	klog.Warningf("Got %s %s from the secondary cluster: %v", gvk, key, err)
+	return nil
+}
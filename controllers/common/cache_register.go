@@ -0,0 +1,289 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	utilyaml "github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// GVKConfigKey is the key of the GVK list in the ConfigMap watched by WatchGVKConfig
+	GVKConfigKey = "gvks"
+	// defaultGVKConfigSyncPeriod is used when the informers are not resynced
+	defaultGVKConfigSyncPeriod = time.Minute
+)
+
+// Register adds the informer of the cluster-scoped GVK to the cache. If the cache has
+// started, the informer is started as well.
+func (c *CSCache) Register(gvk schema.GroupVersionKind) error {
+	_, err := c.register(gvk)
+	return err
+}
+
+// register adds the informer of the GVK to the cache, and reports whether it was added by
+// this call rather than already in the cache
+func (c *CSCache) register(gvk schema.GroupVersionKind) (bool, error) {
+	if isListGVK(gvk) {
+		return false, fmt.Errorf("failed to register %s: list kinds are registered with their item kinds", gvk)
+	}
+	// The informer isn't built while a namespace is added, so that it watches the namespace
+	c.namespaceAddMu.Lock()
+	defer c.namespaceAddMu.Unlock()
+	if _, ok := c.getInformer(gvk); ok {
+		return false, nil
+	}
+
+	informerMap, err := c.buildInformer(gvk)
+	if err != nil {
+		return false, fmt.Errorf("failed to register %s: %v", gvk, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.informerMap[gvk]; ok {
+		return false, nil
+	}
+	for k, v := range informerMap {
+		c.informerMap[k] = v
+	}
+	c.enableDiffEviction(gvk, informerMap[gvk])
+	c.enableAccessLog(gvk, informerMap[gvk])
+	if c.startCtx != nil {
+		c.startInformerLocked(gvk, informerMap[gvk])
+	}
+	klog.Infof("Registered %s in cache", gvk)
+	return true, nil
+}
+
+// buildInformer builds the informer of the GVK and its List GVK with the indexes of the options
+func (c *CSCache) buildInformer(gvk schema.GroupVersionKind) (map[schema.GroupVersionKind]toolscache.SharedIndexInformer, error) {
+	informerMap, err := buildInformerMap(c.config, c.cacheOpts, c.resync, []schema.GroupVersionKind{gvk}, c.rvTracker, c.options, c.informerNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.addFieldIndexes(gvk, informerMap[gvk]); err != nil {
+		return nil, err
+	}
+	if err := c.addKeyFuncIndex(gvk, informerMap[gvk]); err != nil {
+		return nil, err
+	}
+	return informerMap, nil
+}
+
+// Deregister removes the informer of the GVK from the cache and stops it
+func (c *CSCache) Deregister(gvk schema.GroupVersionKind) error {
+	if err := c.deregister(gvk); err != nil {
+		return err
+	}
+	// The gates deliver the events under their lock, so they are cleared out of the lock of
+	// the cache
+	c.clearGates(gvk)
+	return nil
+}
+
+func (c *CSCache) deregister(gvk schema.GroupVersionKind) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.informerMap[gvk]; !ok {
+		return fmt.Errorf("failed to deregister %s: it is not in the cache", gvk)
+	}
+	delete(c.informerMap, gvk)
+	delete(c.informerMap, schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind + "List"})
+	if cancel, ok := c.informerCancels[gvk]; ok {
+		cancel()
+		delete(c.informerCancels, gvk)
+	}
+	delete(c.resyncCounts, gvk)
+	delete(c.prepopulated, gvk)
+	c.dropSuspendableHandlers(gvk)
+	c.forgetSync(gvk)
+	c.forgetTTLEntries(gvk)
+	c.forgetTouches(gvk)
+	c.forgetQueueDepth(gvk)
+	c.diffs.evictGVK(gvk)
+	klog.Infof("Deregistered %s from cache", gvk)
+	return nil
+}
+
+// GVKs returns the sorted GVKs with an informer in the cache, without the List kinds
+func (c *CSCache) GVKs() []schema.GroupVersionKind {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gvksLocked(func(schema.GroupVersionKind, toolscache.SharedIndexInformer) bool { return true })
+}
+
+// HasGVK checks if the GVK has an informer in the cache
+func (c *CSCache) HasGVK(gvk schema.GroupVersionKind) bool {
+	_, ok := c.getInformer(gvk)
+	return ok && !isListGVK(gvk)
+}
+
+// SyncedGVKs returns the sorted GVKs whose informer has synced or been prepopulated
+func (c *CSCache) SyncedGVKs() []schema.GroupVersionKind {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gvksLocked(c.hasSyncedLocked)
+}
+
+// PendingGVKs returns the sorted GVKs whose informer hasn't synced yet
+func (c *CSCache) PendingGVKs() []schema.GroupVersionKind {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gvksLocked(func(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) bool {
+		return !c.hasSyncedLocked(gvk, informer)
+	})
+}
+
+// gvksLocked returns the sorted GVKs, without the List kinds, whose informer matches the filter.
+// The caller must hold c.mu.
+func (c *CSCache) gvksLocked(filter func(schema.GroupVersionKind, toolscache.SharedIndexInformer) bool) []schema.GroupVersionKind {
+	gvks := make([]schema.GroupVersionKind, 0, len(c.informerMap)/2)
+	for gvk, informer := range c.informerMap {
+		if !isListGVK(gvk) && filter(gvk, informer) {
+			gvks = append(gvks, gvk)
+		}
+	}
+	sort.Slice(gvks, func(i, j int) bool {
+		return gvks[i].String() < gvks[j].String()
+	})
+	return gvks
+}
+
+// startInformerLocked runs the informer until the cache stops or the GVK is deregistered.
+// The caller must hold c.mu.
+func (c *CSCache) startInformerLocked(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) {
+	ctx, cancel := context.WithCancel(c.startCtx)
+	if c.informerCancels == nil {
+		c.informerCancels = make(map[schema.GroupVersionKind]context.CancelFunc)
+	}
+	c.informerCancels[gvk] = cancel
+	c.recordSyncStart(gvk)
+	c.addResyncHookLocked(gvk, informer)
+	// The informers shared from the factory may already run, and are set up by their owner
+	if !c.isFactoryInformer(gvk, informer) {
+		c.trackInformerErrors(gvk, informer)
+	}
+	go c.runInformer(ctx, gvk, informer)
+	go c.awaitFirstSync(ctx, gvk, informer)
+}
+
+// WatchGVKConfig keeps the GVKs of the cache in line with the ConfigMap. The GVKConfigKey of
+// the ConfigMap holds a YAML list of group/version/kind strings. The GVKs added to the list are
+// registered and the ones removed from it are deregistered within one informer resync period.
+func (c *CSCache) WatchGVKConfig(ctx context.Context, client client.Client, configMapKey types.NamespacedName) error {
+	if err := c.syncGVKConfig(ctx, client, configMapKey); err != nil {
+		return err
+	}
+
+	period := c.resync
+	if period == 0 {
+		period = defaultGVKConfigSyncPeriod
+	}
+	go utilwait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.syncGVKConfig(ctx, client, configMapKey); err != nil {
+			klog.Errorf("Failed to sync GVK config from ConfigMap %s: %v", configMapKey, err)
+		}
+	}, period)
+	return nil
+}
+
+// syncGVKConfig registers and deregisters the GVKs according to the ConfigMap
+func (c *CSCache) syncGVKConfig(ctx context.Context, client client.Client, configMapKey types.NamespacedName) error {
+	cm := &corev1.ConfigMap{}
+	if err := client.Get(ctx, configMapKey, cm); err != nil {
+		return err
+	}
+	gvks, err := parseGVKList(cm.Data[GVKConfigKey])
+	if err != nil {
+		return fmt.Errorf("failed to parse %s of ConfigMap %s: %v", GVKConfigKey, configMapKey, err)
+	}
+
+	c.gvkConfigMu.Lock()
+	defer c.gvkConfigMu.Unlock()
+
+	var errs []string
+	desired := make(map[schema.GroupVersionKind]bool, len(gvks))
+	for _, gvk := range gvks {
+		desired[gvk] = true
+		if c.configGVKs[gvk] {
+			continue
+		}
+		created, err := c.register(gvk)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		// The GVKs already in the cache, e.g. in the cluster GVK list, are not deregistered
+		// once removed from the ConfigMap
+		if !created {
+			continue
+		}
+		if c.configGVKs == nil {
+			c.configGVKs = make(map[schema.GroupVersionKind]bool)
+		}
+		c.configGVKs[gvk] = true
+	}
+	for gvk := range c.configGVKs {
+		if desired[gvk] {
+			continue
+		}
+		if err := c.Deregister(gvk); err != nil {
+			errs = append(errs, err.Error())
+		}
+		delete(c.configGVKs, gvk)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// parseGVKList parses a YAML list of group/version/kind strings. The group of the core
+// resources is empty, e.g. /v1/ConfigMap or v1/ConfigMap.
+func parseGVKList(data string) ([]schema.GroupVersionKind, error) {
+	var items []string
+	if err := utilyaml.Unmarshal([]byte(data), &items); err != nil {
+		return nil, err
+	}
+	gvks := make([]schema.GroupVersionKind, 0, len(items))
+	for _, item := range items {
+		parts := strings.Split(item, "/")
+		switch len(parts) {
+		case 2:
+			gvks = append(gvks, schema.GroupVersionKind{Version: parts[0], Kind: parts[1]})
+		case 3:
+			gvks = append(gvks, schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]})
+		default:
+			return nil, fmt.Errorf("%q is not in the form of group/version/kind", item)
+		}
+	}
+	return gvks, nil
+}
@@ -0,0 +1,148 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// ErrNotSynced is returned by GVKResourceVersion when the informer of the GVK hasn't synced yet
+var ErrNotSynced = errors.New("the informer has not synced")
+
+// GVKResourceVersion returns the resourceVersion the informer of the GVK last listed or
+// watched at, e.g. to audit the watch gaps
+func (c *CSCache) GVKResourceVersion(gvk schema.GroupVersionKind) (string, error) {
+	informer, ok := c.getInformer(gvk)
+	if !ok {
+		return "", fmt.Errorf("failed to get the resourceVersion of %s: it is not in the cache", gvk)
+	}
+	if !informer.HasSynced() {
+		return "", ErrNotSynced
+	}
+	return informer.LastSyncResourceVersion(), nil
+}
+
+// ResourceVersionMap returns the resourceVersion of the objects of the GVK in the informer
+// store by their namespace/name key, computed in one pass without copying the objects
+func (c *CSCache) ResourceVersionMap(gvk schema.GroupVersionKind) (map[string]string, error) {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return nil, fmt.Errorf("failed to get the resourceVersions of %s: it is not in the cache", gvk)
+	}
+	items := informer.GetStore().List()
+	versions := make(map[string]string, len(items))
+	for _, item := range items {
+		accessor, err := apimeta.Accessor(item)
+		if err != nil {
+			return nil, err
+		}
+		key := accessor.GetName()
+		if accessor.GetNamespace() != "" {
+			key = accessor.GetNamespace() + "/" + key
+		}
+		versions[key] = accessor.GetResourceVersion()
+	}
+	return versions, nil
+}
+
+// resourceVersionTracker tracks the highest resourceVersion seen for each object
+type resourceVersionTracker struct {
+	mu                           sync.RWMutex
+	resourceVersionHighWatermark map[string]string
+}
+
+func newResourceVersionTracker() *resourceVersionTracker {
+	return &resourceVersionTracker{resourceVersionHighWatermark: make(map[string]string)}
+}
+
+// eventHandler returns the informer event handler updating the high watermarks of the GVK
+func (t *resourceVersionTracker) eventHandler(gvk schema.GroupVersionKind) toolscache.ResourceEventHandlerFuncs {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			t.observe(gvk, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			t.observe(gvk, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			key, err := toolscache.MetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			delete(t.resourceVersionHighWatermark, watermarkKey(gvk, key))
+		},
+	}
+}
+
+// observe raises the high watermark of the object to its resourceVersion
+func (t *resourceVersionTracker) observe(gvk schema.GroupVersionKind, obj interface{}) {
+	meta, err := apimeta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	key, err := toolscache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	wk := watermarkKey(gvk, key)
+	if resourceVersionLess(t.resourceVersionHighWatermark[wk], meta.GetResourceVersion()) {
+		t.resourceVersionHighWatermark[wk] = meta.GetResourceVersion()
+	}
+}
+
+// isStale checks if the resourceVersion is older than the high watermark of the object
+func (t *resourceVersionTracker) isStale(gvk schema.GroupVersionKind, key, resourceVersion string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return resourceVersionLess(resourceVersion, t.resourceVersionHighWatermark[watermarkKey(gvk, key)])
+}
+
+func watermarkKey(gvk schema.GroupVersionKind, key string) string {
+	return gvk.String() + "/" + key
+}
+
+// resourceVersionLess compares two resourceVersions. The resourceVersion is opaque, so only
+// the numeric ones are compared, and an empty one is older than any other.
+func resourceVersionLess(a, b string) bool {
+	if b == "" {
+		return false
+	}
+	if a == "" {
+		return true
+	}
+	aVal, errA := strconv.ParseUint(a, 10, 64)
+	bVal, errB := strconv.ParseUint(b, 10, 64)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return aVal < bVal
+}
@@ -0,0 +1,89 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// resyncInterval is the window in which the resynced objects of a GVK are counted
+const resyncInterval = time.Second
+
+// addResyncHookLocked counts the resync events of the GVK. A resync is delivered as an
+// UPDATE event whose old and new objects have the same resourceVersion. The informers
+// restarted with their handlers keep counting on the handler added first.
+// The caller must hold c.mu.
+func (c *CSCache) addResyncHookLocked(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) {
+	if c.options.ResyncHook == nil {
+		return
+	}
+	if _, ok := c.resyncCounts[gvk]; ok {
+		return
+	}
+	if c.resyncCounts == nil {
+		c.resyncCounts = make(map[schema.GroupVersionKind]*uint64)
+	}
+	count := new(uint64)
+	c.resyncCounts[gvk] = count
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, err := apimeta.Accessor(oldObj)
+			if err != nil {
+				return
+			}
+			newMeta, err := apimeta.Accessor(newObj)
+			if err != nil {
+				return
+			}
+			if oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+				atomic.AddUint64(count, 1)
+			}
+		},
+	})
+}
+
+// runResyncHook calls the resync hook with the objects resynced in each interval until
+// the context is done
+func (c *CSCache) runResyncHook(ctx context.Context) {
+	ticker := time.NewTicker(resyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.mu.RLock()
+			counts := make(map[schema.GroupVersionKind]*uint64, len(c.resyncCounts))
+			for gvk, count := range c.resyncCounts {
+				counts[gvk] = count
+			}
+			c.mu.RUnlock()
+
+			for gvk, count := range counts {
+				if resynced := atomic.SwapUint64(count, 0); resynced > 0 {
+					c.options.ResyncHook(ctx, gvk, int(resynced))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RegisterRoute registers the cache serving the Get and List requests the query router
+// routes to routeKey
+func (c *CSCache) RegisterRoute(routeKey string, routeCache cache.Cache) {
+	c.routeMu.Lock()
+	defer c.routeMu.Unlock()
+	if c.routes == nil {
+		c.routes = make(map[string]cache.Cache)
+	}
+	c.routes[routeKey] = routeCache
+	klog.Infof("Registered route %s in cache", routeKey)
+}
+
+// routeFor returns the cache registered for the route key of the request, if any
+func (c *CSCache) routeFor(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (cache.Cache, bool) {
+	if c.options.QueryRouter == nil {
+		return nil, false
+	}
+	routeKey := c.options.QueryRouter(ctx, gvk, key)
+
+	c.routeMu.RLock()
+	defer c.routeMu.RUnlock()
+	routeCache, ok := c.routes[routeKey]
+	return routeCache, ok
+}
+
+// routeList returns the cache registered for the route key of the List request, if any. The
+// router is called with the GVK of the items and the namespace of the list options.
+func (c *CSCache) routeList(ctx context.Context, gvk schema.GroupVersionKind, opts []client.ListOption) (cache.Cache, bool) {
+	if c.options.QueryRouter == nil {
+		return nil, false
+	}
+	if itemGVK, err := listToGVK(gvk); err == nil {
+		gvk = itemGVK
+	}
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	return c.routeFor(ctx, gvk, client.ObjectKey{Namespace: listOpts.Namespace})
+}
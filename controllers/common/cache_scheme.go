@@ -0,0 +1,61 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"sort"
+
+	"github.com/IBM/controller-filtered-cache/filteredcache"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewCSCacheFromScheme builds the CSCache watching the GVKs registered in the scheme whose
+// groups are cluster scoped, instead of enumerating them in the clusterGVKList
+func NewCSCacheFromScheme(scheme *runtime.Scheme, clusterScopedGroups []string, gvkLabelMap map[schema.GroupVersionKind]filteredcache.Selector, watchNamespaces []string, options ...CSCacheOption) cache.NewCacheFunc {
+	return NewCSCache(schemeGVKs(scheme, clusterScopedGroups), gvkLabelMap, watchNamespaces, options...)
+}
+
+// schemeGVKs returns the sorted GVKs of the objects registered in the scheme for the groups.
+// The lists, options and internal versions registered along with the objects are skipped.
+func schemeGVKs(scheme *runtime.Scheme, groups []string) []schema.GroupVersionKind {
+	groupSet := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		groupSet[group] = true
+	}
+
+	var gvks []schema.GroupVersionKind
+	for gvk := range scheme.AllKnownTypes() {
+		if !groupSet[gvk.Group] || gvk.Version == runtime.APIVersionInternal {
+			continue
+		}
+		obj, err := scheme.New(gvk)
+		if err != nil {
+			continue
+		}
+		if _, ok := obj.(client.Object); !ok {
+			continue
+		}
+		gvks = append(gvks, gvk)
+	}
+	sort.Slice(gvks, func(i, j int) bool {
+		return gvks[i].String() < gvks[j].String()
+	})
+	return gvks
+}
@@ -0,0 +1,185 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/utils/lru"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultSelectorCacheSize is the number of compiled label selectors kept by default
+const DefaultSelectorCacheSize = 256
+
+// compiledSelector returns the compiled label selector cached for the raw selector string,
+// so that the repeated List calls with the same selector share one compiled selector
+func (c *CSCache) compiledSelector(sel labels.Selector) labels.Selector {
+	c.selectorCacheOnce.Do(func() {
+		size := c.options.SelectorCacheSize
+		if size <= 0 {
+			size = DefaultSelectorCacheSize
+		}
+		c.selectorCache = lru.New(size)
+	})
+
+	raw := sel.String()
+	if cached, ok := c.selectorCache.Get(raw); ok {
+		return cached.(labels.Selector)
+	}
+	compiled, err := labels.Parse(raw)
+	if err != nil {
+		// The selector can't be rebuilt from its string, use it as it is
+		compiled = sel
+	}
+	c.selectorCache.Add(raw, compiled)
+	return compiled
+}
+
+// LabelIndexField is the field of the index on the label key. A label index registered by
+// IndexField with the label values serves List with a single-value selector on the label
+// without scanning the informer store.
+func LabelIndexField(key string) string {
+	return "metadata.labels." + key
+}
+
+// singleLabelValue returns the label key and value of the selector made of a single
+// requirement matching exactly one value
+func singleLabelValue(sel labels.Selector) (string, string, bool) {
+	if sel == nil {
+		return "", "", false
+	}
+	reqs, selectable := sel.Requirements()
+	if !selectable || len(reqs) != 1 {
+		return "", "", false
+	}
+	switch reqs[0].Operator() {
+	case selection.In, selection.Equals, selection.DoubleEquals:
+		values := reqs[0].Values().List()
+		if len(values) != 1 {
+			return "", "", false
+		}
+		return reqs[0].Key(), values[0], true
+	}
+	return "", "", false
+}
+
+// hasFieldIndex checks if the informer has the index on the field, and it isn't removed
+func (c *CSCache) hasFieldIndex(informer toolscache.SharedIndexInformer, gvk schema.GroupVersionKind, field string) bool {
+	if _, ok := informer.GetIndexer().GetIndexers()[FieldIndexName(field)]; !ok {
+		return false
+	}
+	return !c.isIndexRemoved(gvk, field)
+}
+
+// hasLabelIndex checks if the informer has the index on the label key, and it isn't removed
+func (c *CSCache) hasLabelIndex(informer toolscache.SharedIndexInformer, gvk schema.GroupVersionKind, key string) bool {
+	return c.hasFieldIndex(informer, gvk, LabelIndexField(key))
+}
+
+// RegisterLabelIndex indexes the objects of the GVK by the value of the label key, so that
+// ListByLabel and List with a single-value selector on the label are served from the index.
+// Like IndexField, it must be called before the informer of the GVK has objects. Registering
+// the index of a label key twice is a no-op.
+func (c *CSCache) RegisterLabelIndex(ctx context.Context, gvk schema.GroupVersionKind, labelKey string) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return fmt.Errorf("failed to index the label %s of %s: it is not in the cache", labelKey, gvk)
+	}
+	if c.hasLabelIndex(informer, gvk, labelKey) {
+		return nil
+	}
+	return indexByField(informer, LabelIndexField(labelKey), func(obj client.Object) []string {
+		value, ok := obj.GetLabels()[labelKey]
+		if !ok {
+			return nil
+		}
+		return []string{value}
+	})
+}
+
+// ListByLabel returns a copy of the objects of the GVK in all the namespaces with the label
+// value, looked up in the index registered by RegisterLabelIndex
+func (c *CSCache) ListByLabel(ctx context.Context, gvk schema.GroupVersionKind, labelKey, labelValue string) ([]runtime.Object, error) {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return nil, fmt.Errorf("failed to list %s by the label %s: it is not in the cache", gvk, labelKey)
+	}
+	if !c.hasLabelIndex(informer, gvk, labelKey) {
+		return nil, fmt.Errorf("failed to list %s by the label %s: the label is not indexed", gvk, labelKey)
+	}
+	items, err := informer.GetIndexer().ByIndex(FieldIndexName(LabelIndexField(labelKey)), KeyToNamespacedKey("", labelValue))
+	if err != nil {
+		return nil, err
+	}
+	return c.copyObjects(ctx, items, gvk)
+}
+
+// AnnotationIndexField is the field of the index on the annotation key. The key is escaped,
+// since the annotation keys have a prefix separated by a slash.
+func AnnotationIndexField(key string) string {
+	return "metadata.annotations." + url.QueryEscape(key)
+}
+
+// hasAnnotationIndex checks if the informer has the index on the annotation key, and it isn't removed
+func (c *CSCache) hasAnnotationIndex(informer toolscache.SharedIndexInformer, gvk schema.GroupVersionKind, key string) bool {
+	return c.hasFieldIndex(informer, gvk, AnnotationIndexField(key))
+}
+
+// AnnotationIndex indexes the objects of the GVK by the value of the annotation key, so that
+// ListByAnnotation is served from the index. Like RegisterLabelIndex, it must be called before
+// the informer of the GVK has objects, and registering the same annotation key twice is a no-op.
+func (c *CSCache) AnnotationIndex(gvk schema.GroupVersionKind, annotationKey string) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return fmt.Errorf("failed to index the annotation %s of %s: it is not in the cache", annotationKey, gvk)
+	}
+	if c.hasAnnotationIndex(informer, gvk, annotationKey) {
+		return nil
+	}
+	return indexByField(informer, AnnotationIndexField(annotationKey), func(obj client.Object) []string {
+		value, ok := obj.GetAnnotations()[annotationKey]
+		if !ok {
+			return nil
+		}
+		return []string{value}
+	})
+}
+
+// ListByAnnotation returns a copy of the objects of the GVK in all the namespaces with the
+// annotation value, looked up in the index registered by AnnotationIndex
+func (c *CSCache) ListByAnnotation(ctx context.Context, gvk schema.GroupVersionKind, annotationKey, annotationValue string) ([]runtime.Object, error) {
+	informer, ok := c.getInformer(gvk)
+	if !ok || isListGVK(gvk) {
+		return nil, fmt.Errorf("failed to list %s by the annotation %s: it is not in the cache", gvk, annotationKey)
+	}
+	if !c.hasAnnotationIndex(informer, gvk, annotationKey) {
+		return nil, fmt.Errorf("failed to list %s by the annotation %s: the annotation is not indexed", gvk, annotationKey)
+	}
+	items, err := informer.GetIndexer().ByIndex(FieldIndexName(AnnotationIndexField(annotationKey)), KeyToNamespacedKey("", annotationValue))
+	if err != nil {
+		return nil, err
+	}
+	return c.copyObjects(ctx, items, gvk)
+}
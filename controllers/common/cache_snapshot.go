@@ -0,0 +1,72 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+// Snapshot returns a deep copy of the objects in the informer store of each GVK, e.g. to
+// inspect the cache while debugging in production. The secret fields are redacted unless
+// the context allows reading them by WithSecretAccess.
+func (c *CSCache) Snapshot(ctx context.Context) (map[schema.GroupVersionKind][]runtime.Object, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	snapshot := make(map[schema.GroupVersionKind][]runtime.Object)
+	for gvk, informer := range c.informerMap {
+		if isListGVK(gvk) {
+			continue
+		}
+		objs, err := c.copyObjects(ctx, informer.GetStore().List(), gvk)
+		if err != nil {
+			return nil, err
+		}
+		snapshot[gvk] = objs
+	}
+	return snapshot, nil
+}
+
+// SnapshotJSON returns the Snapshot serialized with the Scheme of the cache, as a JSON object
+// of the objects of each GVK
+func (c *CSCache) SnapshotJSON(ctx context.Context) ([]byte, error) {
+	objSnapshot, err := c.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codecs := serializer.NewCodecFactory(c.Scheme)
+	snapshot := make(map[string][]json.RawMessage)
+	for gvk, objs := range objSnapshot {
+		encoder := codecs.LegacyCodec(gvk.GroupVersion())
+		items := make([]json.RawMessage, 0, len(objs))
+		for _, obj := range objs {
+			data, err := runtime.Encode(encoder, obj)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode %s: %v", gvk, err)
+			}
+			items = append(items, data)
+		}
+		snapshot[gvk.String()] = items
+	}
+	return json.Marshal(snapshot)
+}
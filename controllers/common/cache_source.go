@@ -0,0 +1,40 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// InjectEventSource returns the source of the events of the GVK informer for a controller,
+// e.g. ctrl.NewControllerManagedBy(mgr).Watches(src, &handler.EnqueueRequestForObject{}).
+// The handlers added by the source are subject to the leader election and the event gates
+// of the cache like the ones added through GetInformer.
+func (c *CSCache) InjectEventSource(gvk schema.GroupVersionKind) (source.Source, error) {
+	if isListGVK(gvk) {
+		return nil, fmt.Errorf("failed to inject the event source of %s: list kinds have no events", gvk)
+	}
+	informer, err := c.GetInformerForKind(context.TODO(), gvk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inject the event source of %s: %v", gvk, err)
+	}
+	return &source.Informer{Informer: informer}, nil
+}
@@ -0,0 +1,133 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// CacheStats is a snapshot of the CSCache health
+type CacheStats struct {
+	// GVKStoreSizes is the number of objects in the informer store of each GVK
+	GVKStoreSizes map[schema.GroupVersionKind]int
+	// TotalGetHits is the number of Get requests served from the informer store
+	TotalGetHits uint64
+	// TotalGetMisses is the number of Get requests sent to the api server
+	TotalGetMisses uint64
+	// TotalListHits is the number of List requests served from the informer store
+	TotalListHits uint64
+	// TotalGetErrors is the number of Get requests failed on the api server
+	TotalGetErrors uint64
+	// LastSyncDurations is the time from Start until the informer of each GVK synced
+	LastSyncDurations map[schema.GroupVersionKind]time.Duration
+}
+
+// HitRatio returns the ratio of Get requests served from the informer store
+func (s CacheStats) HitRatio() float64 {
+	total := s.TotalGetHits + s.TotalGetMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.TotalGetHits) / float64(total)
+}
+
+// Stats returns a snapshot of the CSCache health
+func (c *CSCache) Stats() CacheStats {
+	stats := CacheStats{
+		GVKStoreSizes:     c.Len(),
+		TotalGetHits:      atomic.LoadUint64(&c.getHits),
+		TotalGetMisses:    atomic.LoadUint64(&c.getMisses),
+		TotalListHits:     atomic.LoadUint64(&c.listHits),
+		TotalGetErrors:    atomic.LoadUint64(&c.getErrors),
+		LastSyncDurations: make(map[schema.GroupVersionKind]time.Duration),
+	}
+
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	for gvk, d := range c.syncDurations {
+		stats.LastSyncDurations[gvk] = d
+	}
+	return stats
+}
+
+// SyncDuration returns how long the informer of the GVK took to sync for the first time since
+// it started, and false if it hasn't synced yet
+func (c *CSCache) SyncDuration(gvk schema.GroupVersionKind) (time.Duration, bool) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	d, ok := c.syncDurations[gvk]
+	return d, ok
+}
+
+// recordSyncStart records the time the informer of the GVK started, and forgets the sync
+// duration of a previous informer of the GVK
+func (c *CSCache) recordSyncStart(gvk schema.GroupVersionKind) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	if c.syncStartTimes == nil {
+		c.syncStartTimes = make(map[schema.GroupVersionKind]time.Time)
+	}
+	c.syncStartTimes[gvk] = time.Now()
+	delete(c.syncDurations, gvk)
+	delete(c.syncTimes, gvk)
+}
+
+// forgetSync forgets the sync start, duration and time of the informer of the GVK
+func (c *CSCache) forgetSync(gvk schema.GroupVersionKind) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	delete(c.syncStartTimes, gvk)
+	delete(c.syncDurations, gvk)
+	delete(c.syncTimes, gvk)
+}
+
+// awaitFirstSync records the sync duration once the informer of the GVK has synced
+func (c *CSCache) awaitFirstSync(ctx context.Context, gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) {
+	if toolscache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		c.recordSyncDuration(gvk)
+	}
+}
+
+// recordSyncDuration records the time from the start of the informer of the GVK, or else
+// the start of the cache, until the informer synced
+func (c *CSCache) recordSyncDuration(gvk schema.GroupVersionKind) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	start, ok := c.syncStartTimes[gvk]
+	if !ok {
+		start = c.startTime
+	}
+	if _, ok := c.syncDurations[gvk]; ok || start.IsZero() {
+		return
+	}
+	if c.syncDurations == nil {
+		c.syncDurations = make(map[schema.GroupVersionKind]time.Duration)
+	}
+	c.syncDurations[gvk] = time.Since(start)
+	if c.syncTimes == nil {
+		c.syncTimes = make(map[schema.GroupVersionKind]time.Time)
+	}
+	c.syncTimes[gvk] = start.Add(c.syncDurations[gvk])
+	if c.syncHistogram != nil {
+		c.syncHistogram.WithLabelValues(gvk.String()).Observe(c.syncDurations[gvk].Seconds())
+	}
+}
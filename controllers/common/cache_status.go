@@ -0,0 +1,134 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// DebugCachePath is the path of the CSCache debug handler in the DebugHTTPMux
+const DebugCachePath = "/debug/cache"
+
+// InformerStatus is the state of the informer of a GVK
+type InformerStatus struct {
+	// Running is true while the informer runs, including its recovery attempts
+	Running bool
+	// Synced is true once the informer has synced
+	Synced bool
+	// ObjectCount is the number of objects in the informer store
+	ObjectCount int
+	// LastError is the last list or watch error of the informer
+	LastError error
+	// LastSyncTime is the time the informer synced since it last started
+	LastSyncTime time.Time
+}
+
+// GVKStatus returns the state of the informer of each GVK, without the List kinds
+func (c *CSCache) GVKStatus() map[schema.GroupVersionKind]InformerStatus {
+	c.mu.RLock()
+	informers := make(map[schema.GroupVersionKind]toolscache.SharedIndexInformer, len(c.informerMap))
+	for gvk, informer := range c.informerMap {
+		if !isListGVK(gvk) {
+			informers[gvk] = informer
+		}
+	}
+	c.mu.RUnlock()
+
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	status := make(map[schema.GroupVersionKind]InformerStatus, len(informers))
+	for gvk, informer := range informers {
+		status[gvk] = InformerStatus{
+			Running:      c.runningInformers[gvk] > 0,
+			Synced:       informer.HasSynced(),
+			ObjectCount:  len(informer.GetStore().ListKeys()),
+			LastError:    c.informerErrors[gvk],
+			LastSyncTime: c.syncTimes[gvk],
+		}
+	}
+	return status
+}
+
+// setInformerRunning records whether an informer of the GVK runs. The informers of the GVK
+// are counted, since the stopped informer of a restarted GVK may exit after the new one runs.
+func (c *CSCache) setInformerRunning(gvk schema.GroupVersionKind, running bool) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	if c.runningInformers == nil {
+		c.runningInformers = make(map[schema.GroupVersionKind]int)
+	}
+	if running {
+		c.runningInformers[gvk]++
+	} else {
+		c.runningInformers[gvk]--
+	}
+}
+
+// trackInformerErrors records the list and watch errors of the informer of the GVK, and
+// handles them as the default handler does. It must be called before the informer runs.
+func (c *CSCache) trackInformerErrors(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) {
+	err := informer.SetWatchErrorHandler(func(r *toolscache.Reflector, err error) {
+		c.statsMu.Lock()
+		if c.informerErrors == nil {
+			c.informerErrors = make(map[schema.GroupVersionKind]error)
+		}
+		c.informerErrors[gvk] = err
+		c.statsMu.Unlock()
+		toolscache.DefaultWatchErrorHandler(r, err)
+	})
+	if err != nil {
+		klog.V(2).Infof("Failed to track the errors of the informer for %s: %v", gvk, err)
+	}
+}
+
+// informerStatusJSON is the InformerStatus served by the debug handler
+type informerStatusJSON struct {
+	Running      bool      `json:"running"`
+	Synced       bool      `json:"synced"`
+	ObjectCount  int       `json:"objectCount"`
+	LastError    string    `json:"lastError,omitempty"`
+	LastSyncTime time.Time `json:"lastSyncTime"`
+}
+
+// debugHandler serves the state of the informers as a JSON object keyed by GVK
+func (c *CSCache) debugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]informerStatusJSON)
+		for gvk, status := range c.GVKStatus() {
+			s := informerStatusJSON{
+				Running:      status.Running,
+				Synced:       status.Synced,
+				ObjectCount:  status.ObjectCount,
+				LastSyncTime: status.LastSyncTime,
+			}
+			if status.LastError != nil {
+				s.LastError = status.LastError.Error()
+			}
+			body[gvk.String()] = s
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			klog.Errorf("Failed to write the cache status: %v", err)
+		}
+	})
+}
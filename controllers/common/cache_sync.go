@@ -0,0 +1,201 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ListenAndSync lists the objects of every GVK from the api server on each interval and
+// writes the objects which differ to the informer store, in case the watches have stalled,
+// e.g. after a network interruption. It supplements the watches of the informers, and
+// blocks until the context is done.
+func (c *CSCache) ListenAndSync(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid sync interval %v", interval)
+	}
+	utilwait.UntilWithContext(ctx, func(ctx context.Context) {
+		c.mu.RLock()
+		informers := make(map[schema.GroupVersionKind]toolscache.SharedIndexInformer)
+		for gvk, informer := range c.informerMap {
+			if !isListGVK(gvk) {
+				informers[gvk] = informer
+			}
+		}
+		c.mu.RUnlock()
+
+		for gvk, informer := range informers {
+			if err := c.syncStore(ctx, gvk, informer); err != nil {
+				klog.Warningf("Failed to sync the store of %s: %v", gvk, err)
+			}
+		}
+	}, interval)
+	return nil
+}
+
+// syncStore writes the objects listed from the api server which are missing in the store of
+// the informer, or newer than the stored ones, and deletes the stored objects no longer
+// listed. The list and the watch of the informer are not simultaneous, so the objects older
+// than the stored ones are skipped, and only the stored objects older than the list are
+// deleted, which the watch would otherwise have updated.
+func (c *CSCache) syncStore(ctx context.Context, gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer) error {
+	items, listVersion, err := c.listFromInformerNamespaces(ctx, gvk)
+	if err != nil {
+		return err
+	}
+
+	store := informer.GetStore()
+	listed := make(map[string]bool, len(items))
+	var added, updated, deleted int
+	for _, item := range items {
+		key, err := toolscache.MetaNamespaceKeyFunc(item)
+		if err != nil {
+			return err
+		}
+		listed[key] = true
+
+		existing, exists, err := store.GetByKey(key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err := writeStore(gvk, informer, nil, item); err != nil {
+				return err
+			}
+			added++
+		} else if resourceVersionLess(resourceVersionOf(existing), resourceVersionOf(item)) {
+			if err := writeStore(gvk, informer, existing, item); err != nil {
+				return err
+			}
+			updated++
+		}
+	}
+	for _, key := range store.ListKeys() {
+		if listed[key] {
+			continue
+		}
+		existing, exists, err := store.GetByKey(key)
+		if err != nil {
+			return err
+		}
+		if !exists || !resourceVersionLess(resourceVersionOf(existing), listVersion) {
+			continue
+		}
+		if err := writeStore(gvk, informer, existing, nil); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	if added+updated+deleted > 0 {
+		klog.Infof("Synced %s with the api server: %d added, %d updated, %d deleted", gvk, added, updated, deleted)
+	}
+	return nil
+}
+
+// Refresh gets the latest object from the api server into obj, with its secret fields
+// redacted, and updates the object in the store of the informer of the GVK if it is older,
+// e.g. after the object is edited while the watch is lagging. The update is delivered to the
+// event handlers of the informer.
+func (c *CSCache) Refresh(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
+	if err != nil {
+		return err
+	}
+	if err := c.getFromClient(ctx, key, obj, gvk); err != nil {
+		return err
+	}
+	if err := c.refreshStore(gvk, obj); err != nil {
+		return err
+	}
+	return c.obfuscate(ctx, gvk, obj)
+}
+
+// refreshStore updates the object in the store of the informer of the GVK if it is older
+// than obj, or missing
+func (c *CSCache) refreshStore(gvk schema.GroupVersionKind, obj client.Object) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok || c.isExcludedNamespace(obj.GetNamespace()) {
+		return nil
+	}
+	existing, exists, err := informer.GetStore().Get(obj)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		existing = nil
+	} else if !resourceVersionLess(resourceVersionOf(existing), obj.GetResourceVersion()) {
+		return nil
+	}
+	return writeStore(gvk, informer, existing, obj.DeepCopyObject())
+}
+
+// writeStore writes the change of an object on the api server to the store of the informer,
+// and delivers it to the event handlers of the informer, which don't see the writes to the
+// store otherwise. oldObj is nil for an added object, and obj is nil for a deleted one. The
+// stores of the informers shared from the factory are only written by their owner.
+func writeStore(gvk schema.GroupVersionKind, informer toolscache.SharedIndexInformer, oldObj, obj interface{}) error {
+	rebuildable, ok := informer.(*rebuildableInformer)
+	if !ok {
+		klog.V(2).Infof("Skipped writing the store of %s, which is shared from the factory", gvk)
+		return nil
+	}
+	store := rebuildable.GetStore()
+	switch {
+	case oldObj == nil:
+		if err := store.Add(obj); err != nil {
+			return err
+		}
+		for _, handler := range rebuildable.eventHandlers() {
+			handler.OnAdd(obj)
+		}
+	case obj == nil:
+		if err := store.Delete(oldObj); err != nil {
+			return err
+		}
+		for _, handler := range rebuildable.eventHandlers() {
+			handler.OnDelete(oldObj)
+		}
+	default:
+		if err := store.Update(obj); err != nil {
+			return err
+		}
+		for _, handler := range rebuildable.eventHandlers() {
+			handler.OnUpdate(oldObj, obj)
+		}
+	}
+	return nil
+}
+
+// resourceVersionOf returns the resourceVersion of the object, or empty if it has no metadata
+func resourceVersionOf(obj interface{}) string {
+	meta, err := apimeta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return meta.GetResourceVersion()
+}
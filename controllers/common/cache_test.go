@@ -0,0 +1,4197 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	webhookGVK   = admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfiguration")
+	configMapGVK = corev1.SchemeGroupVersion.WithKind("ConfigMap")
+)
+
+// newTestCSCache builds a CSCache whose informers are never started, so that
+// the test can seed their stores directly
+func newTestCSCache(gvks ...schema.GroupVersionKind) *CSCache {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+	informerMap := make(map[schema.GroupVersionKind]toolscache.SharedIndexInformer)
+	for _, gvk := range gvks {
+		typed, err := scheme.New(gvk)
+		Expect(err).NotTo(HaveOccurred())
+		informer := toolscache.NewSharedIndexInformer(&toolscache.ListWatch{}, typed, 0, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+		informerMap[gvk] = informer
+		informerMap[schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind + "List"}] = informer
+	}
+	return &CSCache{
+		config:      &rest.Config{Host: "https://localhost:6443"},
+		cacheOpts:   cache.Options{Scheme: scheme},
+		informerMap: informerMap,
+		Scheme:      scheme,
+	}
+}
+
+func newWebhookConfig(name string) *admissionv1.ValidatingWebhookConfiguration {
+	return &admissionv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: "1"},
+	}
+}
+
+func newConfigMap(namespace, name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, ResourceVersion: "1"},
+	}
+}
+
+// fakeCache serves the objects of the fake client as a cache
+type fakeCache struct {
+	*informertest.FakeInformers
+	reader client.Reader
+	// resourceVersion is set on the lists if not empty
+	resourceVersion string
+}
+
+func (c fakeCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return c.reader.Get(ctx, key, obj)
+}
+
+func (c fakeCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.reader.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	if c.resourceVersion != "" {
+		list.SetResourceVersion(c.resourceVersion)
+	}
+	return nil
+}
+
+func newFakeCache(scheme *runtime.Scheme, objs ...client.Object) cache.Cache {
+	return fakeCache{
+		FakeInformers: &informertest.FakeInformers{Scheme: scheme},
+		reader:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+	}
+}
+
+// exitingInformer returns from Run without waiting for the stop channel
+type exitingInformer struct {
+	toolscache.SharedIndexInformer
+}
+
+func (exitingInformer) Run(stopCh <-chan struct{}) {}
+
+// handlerCountingInformer is an exitingInformer which counts the event handlers added to it
+type handlerCountingInformer struct {
+	exitingInformer
+	handlers int
+}
+
+func (i *handlerCountingInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.handlers++
+	i.exitingInformer.AddEventHandler(handler)
+}
+
+// recordingCodecFactory records the media types it decodes
+type recordingCodecFactory struct {
+	runtime.NegotiatedSerializer
+	decoded chan string
+}
+
+func (f recordingCodecFactory) DecoderToVersion(decoder runtime.Decoder, gv runtime.GroupVersioner) runtime.Decoder {
+	for _, info := range f.SupportedMediaTypes() {
+		if info.Serializer == decoder {
+			f.decoded <- info.MediaType
+		}
+	}
+	return f.NegotiatedSerializer.DecoderToVersion(decoder, gv)
+}
+
+// newListWatchServer serves the list and watch of the ValidatingWebhookConfigurations with the
+// webhook configurations, and NotFound for the other requests
+func newListWatchServer(items ...*admissionv1.ValidatingWebhookConfiguration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Query().Get("watch") == "true":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case strings.HasSuffix(r.URL.Path, "/validatingwebhookconfigurations"):
+			list := &admissionv1.ValidatingWebhookConfigurationList{}
+			list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+			list.ResourceVersion = "1"
+			for _, item := range items {
+				list.Items = append(list.Items, *item)
+			}
+			_ = json.NewEncoder(w).Encode(list)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+		}
+	}))
+}
+
+// newMutableListWatchServer serves the list and the get of the webhook configurations items
+// returns at the time of the request, and watches without events
+func newMutableListWatchServer(items func() []*admissionv1.ValidatingWebhookConfiguration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/validatingwebhookconfigurations") {
+			list := &admissionv1.ValidatingWebhookConfigurationList{}
+			list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+			list.ResourceVersion = "10"
+			for _, item := range items() {
+				list.Items = append(list.Items, *item)
+			}
+			_ = json.NewEncoder(w).Encode(list)
+			return
+		}
+		for _, item := range items() {
+			if path.Base(r.URL.Path) == item.Name {
+				obj := item.DeepCopy()
+				obj.SetGroupVersionKind(webhookGVK)
+				_ = json.NewEncoder(w).Encode(obj)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+	}))
+}
+
+// inconsistentIndexer lists its objects twice and doesn't find the missing keys
+type inconsistentIndexer struct {
+	toolscache.Indexer
+	missing string
+}
+
+func (i inconsistentIndexer) List() []interface{} {
+	items := i.Indexer.List()
+	return append(items, items...)
+}
+
+func (i inconsistentIndexer) GetByKey(key string) (interface{}, bool, error) {
+	if key == i.missing {
+		return nil, false, nil
+	}
+	return i.Indexer.GetByKey(key)
+}
+
+// inconsistentInformer serves the inconsistentIndexer as its store
+type inconsistentInformer struct {
+	toolscache.SharedIndexInformer
+	indexer inconsistentIndexer
+}
+
+func (i inconsistentInformer) GetStore() toolscache.Store {
+	return i.indexer
+}
+
+func (i inconsistentInformer) GetIndexer() toolscache.Indexer {
+	return i.indexer
+}
+
+var _ = Describe("CSCache", func() {
+
+	Context("Len", func() {
+		It("Should count the objects of each GVK", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			for _, name := range []string{"a", "b", "c"} {
+				Expect(store.Add(newWebhookConfig(name))).To(Succeed())
+			}
+
+			Expect(c.Len()).To(Equal(map[schema.GroupVersionKind]int{webhookGVK: 3}))
+		})
+	})
+
+	Context("Stats", func() {
+		It("Should count the Get requests served from the store", func() {
+			c := newTestCSCache(webhookGVK)
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("a"))).To(Succeed())
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: "a"}, obj)).To(Succeed())
+			Expect(obj.Name).To(Equal("a"))
+
+			stats := c.Stats()
+			Expect(stats.TotalGetHits).To(Equal(uint64(1)))
+			Expect(stats.TotalGetMisses).To(Equal(uint64(0)))
+			Expect(stats.HitRatio()).To(Equal(1.0))
+			Expect(stats.GVKStoreSizes[webhookGVK]).To(Equal(1))
+		})
+	})
+
+	Context("Delta based reconcile", func() {
+		It("Should rebuild the new object from the old one and the patch", func() {
+			oldObj := newWebhookConfig("a")
+			oldObj.Labels = map[string]string{"removed": "true"}
+			newObj := newWebhookConfig("a")
+			newObj.ResourceVersion = "2"
+			newObj.Annotations = map[string]string{"added": "true"}
+
+			patch, err := createMergePatch(oldObj, newObj)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ApplyPatch(oldObj, patch)).To(Succeed())
+			Expect(oldObj).To(Equal(newObj))
+		})
+	})
+
+	Context("Strict resourceVersion", func() {
+		It("Should treat the object older than the high watermark as a miss", func() {
+			c := newTestCSCache(webhookGVK)
+			c.rvTracker = newResourceVersionTracker()
+			informer := c.informerMap[webhookGVK]
+			Expect(informer.GetStore().Add(newWebhookConfig("a"))).To(Succeed())
+
+			key := types.NamespacedName{Name: "a"}
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.getFromStore(informer, key, obj, webhookGVK)).To(Succeed())
+
+			newer := newWebhookConfig("a")
+			newer.ResourceVersion = "5"
+			c.rvTracker.observe(webhookGVK, newer)
+			Expect(c.getFromStore(informer, key, obj, webhookGVK)).NotTo(Succeed())
+		})
+	})
+
+	Context("listToGVK", func() {
+		It("Should trim the List suffix", func() {
+			gvk, err := listToGVK(schema.GroupVersionKind{Group: "g", Version: "v1", Kind: "FooBarList"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gvk).To(Equal(schema.GroupVersionKind{Group: "g", Version: "v1", Kind: "FooBar"}))
+		})
+		It("Should only trim the last List of the kind", func() {
+			gvk, err := listToGVK(schema.GroupVersionKind{Kind: "ListenerListList"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gvk.Kind).To(Equal("ListenerList"))
+		})
+		It("Should reject the kind containing List in the middle", func() {
+			_, err := listToGVK(schema.GroupVersionKind{Kind: "FooListBar"})
+			Expect(err).To(HaveOccurred())
+		})
+		It("Should reject the kind not ending in List", func() {
+			_, err := listToGVK(schema.GroupVersionKind{Kind: "PodExecOptions"})
+			Expect(err).To(HaveOccurred())
+			_, err = listToGVK(schema.GroupVersionKind{Kind: "List"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Field selectors", func() {
+		It("Should intersect the results of a multi-condition selector", func() {
+			c := newTestCSCache(configMapGVK)
+			ctx := context.TODO()
+			Expect(c.IndexField(ctx, &corev1.ConfigMap{}, "metadata.name", func(obj client.Object) []string {
+				return []string{obj.GetName()}
+			})).To(Succeed())
+			Expect(c.IndexField(ctx, &corev1.ConfigMap{}, "metadata.namespace", func(obj client.Object) []string {
+				return []string{obj.GetNamespace()}
+			})).To(Succeed())
+
+			store := c.informerMap[configMapGVK].GetStore()
+			Expect(store.Add(newConfigMap("bar", "foo"))).To(Succeed())
+			Expect(store.Add(newConfigMap("baz", "foo"))).To(Succeed())
+			Expect(store.Add(newConfigMap("bar", "qux"))).To(Succeed())
+
+			list := &corev1.ConfigMapList{}
+			selector := client.MatchingFieldsSelector{Selector: fields.ParseSelectorOrDie("metadata.name=foo,metadata.namespace=bar")}
+			Expect(c.List(ctx, list, selector)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Namespace).To(Equal("bar"))
+			Expect(list.Items[0].Name).To(Equal("foo"))
+		})
+
+		It("Should reject a non-exact selector", func() {
+			c := newTestCSCache(configMapGVK)
+			selector := client.MatchingFieldsSelector{Selector: fields.ParseSelectorOrDie("metadata.name!=foo")}
+			Expect(c.List(context.TODO(), &corev1.ConfigMapList{}, selector)).NotTo(Succeed())
+		})
+	})
+
+	Context("TTL eviction", func() {
+		It("Should evict only the expired objects from the store", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("old"))).To(Succeed())
+			Expect(store.Add(newWebhookConfig("new"))).To(Succeed())
+			c.ttlEntries = map[schema.GroupVersionKind]map[string]time.Time{
+				webhookGVK: {
+					"old": time.Now().Add(-time.Hour),
+					"new": time.Now(),
+				},
+			}
+
+			c.evictExpired(context.TODO(), webhookGVK, TTLPolicy{TTL: time.Minute})
+			Expect(store.ListKeys()).To(ConsistOf("new"))
+		})
+
+		It("Should delete the expired objects from the cluster with dryRun", func() {
+			deleted := make(chan string, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deleted <- r.Method + " " + r.URL.Path + "?dryRun=" + r.URL.Query().Get("dryRun")
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(&metav1.Status{Status: metav1.StatusSuccess})).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.options.DryRunAPIClient = true
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("old"))).To(Succeed())
+			c.ttlEntries = map[schema.GroupVersionKind]map[string]time.Time{
+				webhookGVK: {"old": time.Now().Add(-time.Hour)},
+			}
+
+			c.evictExpired(context.TODO(), webhookGVK, TTLPolicy{TTL: time.Minute, DeleteFromCluster: true})
+			Expect(<-deleted).To(Equal("DELETE /apis/admissionregistration.k8s.io/v1/validatingwebhookconfigurations/old?dryRun=All"))
+		})
+	})
+
+	Context("SortedListOption", func() {
+		It("Should return the objects ordered by namespace and name on every call", func() {
+			c := newTestCSCache(configMapGVK)
+			store := c.informerMap[configMapGVK].GetStore()
+			for _, key := range [][]string{{"b", "a"}, {"a", "c"}, {"a", "b"}, {"c", "a"}} {
+				Expect(store.Add(newConfigMap(key[0], key[1]))).To(Succeed())
+			}
+
+			for i := 0; i < 5; i++ {
+				list := &corev1.ConfigMapList{}
+				Expect(c.List(context.TODO(), list, SortedListOption{})).To(Succeed())
+				var keys []string
+				for _, item := range list.Items {
+					keys = append(keys, item.Namespace+"/"+item.Name)
+				}
+				Expect(keys).To(Equal([]string{"a/b", "a/c", "b/a", "c/a"}))
+			}
+		})
+	})
+
+	Context("RemoveIndexField", func() {
+		It("Should fail the List on a removed index", func() {
+			c := newTestCSCache(configMapGVK)
+			ctx := context.TODO()
+			Expect(c.IndexField(ctx, &corev1.ConfigMap{}, "metadata.name", func(obj client.Object) []string {
+				return []string{obj.GetName()}
+			})).To(Succeed())
+			Expect(c.informerMap[configMapGVK].GetStore().Add(newConfigMap("ns", "foo"))).To(Succeed())
+
+			selector := client.MatchingFieldsSelector{Selector: fields.ParseSelectorOrDie("metadata.name=foo")}
+			list := &corev1.ConfigMapList{}
+			Expect(c.List(ctx, list, selector)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+
+			Expect(c.RemoveIndexField(ctx, &corev1.ConfigMap{}, "metadata.name")).To(Succeed())
+			Expect(c.List(ctx, list, selector)).NotTo(Succeed())
+		})
+	})
+
+	Context("Client cache", func() {
+		It("Should reuse the REST client until the cache is cleared", func() {
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: "https://localhost:6443"}
+
+			client, err := c.clientForGVK(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			cached, err := c.clientForGVK(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cached).To(BeIdenticalTo(client))
+
+			c.ClearClientCache()
+			renewed, err := c.clientForGVK(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(renewed).NotTo(BeIdenticalTo(client))
+		})
+
+		It("Should replace the REST client when the host changes", func() {
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: "https://localhost:6443"}
+			client, err := c.clientForGVK(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+
+			c.config.Host = "https://localhost:8443"
+			renewed, err := c.clientForGVK(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(renewed).NotTo(BeIdenticalTo(client))
+		})
+	})
+
+	Context("Contextual metadata", func() {
+		It("Should inject the headers into the api server requests", func() {
+			received := make(chan http.Header, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received <- r.Header
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			csOpts := buildCSCacheOptions([]CSCacheOption{WithContextualMetadata(map[string]string{"X-Cluster-Id": "cluster-a"})})
+			c := newTestCSCache(webhookGVK)
+			config, err := buildClientConfig(&rest.Config{Host: server.URL}, csOpts)
+			Expect(err).NotTo(HaveOccurred())
+			c.config = config
+
+			err = c.getFromClient(context.TODO(), types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)
+			Expect(err).To(HaveOccurred())
+			Expect((<-received).Get("X-Cluster-Id")).To(Equal("cluster-a"))
+		})
+	})
+
+	Context("WatchGVKConfig", func() {
+		It("Should register and deregister the GVKs in the ConfigMap", func() {
+			c := newTestCSCache(webhookGVK)
+			ctx := context.TODO()
+			cm := newConfigMap("ns", "gvk-config")
+			cm.Data = map[string]string{GVKConfigKey: "- v1/ConfigMap\n- v1/Secret\n"}
+			fakeClient := fake.NewClientBuilder().WithScheme(c.Scheme).WithObjects(cm).Build()
+			key := types.NamespacedName{Namespace: "ns", Name: "gvk-config"}
+
+			Expect(c.syncGVKConfig(ctx, fakeClient, key)).To(Succeed())
+			Expect(c.Len()).To(HaveKey(configMapGVK))
+			Expect(c.Len()).To(HaveKey(corev1.SchemeGroupVersion.WithKind("Secret")))
+
+			cm.Data[GVKConfigKey] = "- v1/ConfigMap\n"
+			Expect(fakeClient.Update(ctx, cm)).To(Succeed())
+			Expect(c.syncGVKConfig(ctx, fakeClient, key)).To(Succeed())
+			Expect(c.Len()).To(Equal(map[schema.GroupVersionKind]int{webhookGVK: 0, configMapGVK: 0}))
+		})
+
+		It("Should not deregister the GVKs of the cluster GVK list removed from the ConfigMap", func() {
+			c := newTestCSCache(webhookGVK)
+			ctx := context.TODO()
+			cm := newConfigMap("ns", "gvk-config")
+			cm.Data = map[string]string{GVKConfigKey: "- admissionregistration.k8s.io/v1/ValidatingWebhookConfiguration\n"}
+			fakeClient := fake.NewClientBuilder().WithScheme(c.Scheme).WithObjects(cm).Build()
+			key := types.NamespacedName{Namespace: "ns", Name: "gvk-config"}
+
+			Expect(c.syncGVKConfig(ctx, fakeClient, key)).To(Succeed())
+			Expect(c.configGVKs).To(BeEmpty())
+
+			cm.Data[GVKConfigKey] = "[]"
+			Expect(fakeClient.Update(ctx, cm)).To(Succeed())
+			Expect(c.syncGVKConfig(ctx, fakeClient, key)).To(Succeed())
+			Expect(c.HasGVK(webhookGVK)).To(BeTrue())
+		})
+
+		It("Should forget the state of the deregistered GVK", func() {
+			c := newTestCSCache(webhookGVK)
+			Expect(c.enableTTLEviction(webhookGVK)).To(Succeed())
+			c.recordTouch(webhookGVK, "a", time.Now())
+			c.recordSyncStart(webhookGVK)
+			c.recordSyncDuration(webhookGVK)
+			c.prepopulated = map[schema.GroupVersionKind]bool{webhookGVK: true}
+
+			Expect(c.Deregister(webhookGVK)).To(Succeed())
+			Expect(c.ttlEntries).NotTo(HaveKey(webhookGVK))
+			Expect(c.accessLog).NotTo(HaveKey(webhookGVK))
+			Expect(c.syncStartTimes).NotTo(HaveKey(webhookGVK))
+			Expect(c.syncDurations).NotTo(HaveKey(webhookGVK))
+			Expect(c.syncTimes).NotTo(HaveKey(webhookGVK))
+			Expect(c.prepopulated).NotTo(HaveKey(webhookGVK))
+		})
+	})
+
+	Context("GVK object limit", func() {
+		It("Should evict the least recently accessed object over the limit", func() {
+			c := newTestCSCache(webhookGVK)
+			c.options.GVKObjectLimit = map[schema.GroupVersionKind]int{webhookGVK: 2}
+			Expect(c.enableObjectLimit(webhookGVK)).To(Succeed())
+			store := c.informerMap[webhookGVK].GetStore()
+			handler := c.objectLimitHandler(webhookGVK)
+
+			add := func(name string) {
+				obj := newWebhookConfig(name)
+				Expect(store.Add(obj)).To(Succeed())
+				handler.OnAdd(obj)
+			}
+			add("a")
+			add("b")
+			// Access a, so that b becomes the least recently accessed object
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			add("c")
+
+			Expect(store.ListKeys()).To(ConsistOf("a", "c"))
+		})
+	})
+
+	Context("Cross GVK consistency", func() {
+		It("Should hold back the dependent events until the parent event is processed", func() {
+			gate := newConsistencyGate(webhookGVK, time.Minute, 10)
+			var delivered []string
+			child := newWebhookConfig("child")
+			child.Annotations = map[string]string{CorrelationIDAnnotation: "id-1"}
+			parent := newWebhookConfig("parent")
+			parent.Annotations = map[string]string{CorrelationIDAnnotation: "id-1"}
+
+			gate.deliver(child, func() { delivered = append(delivered, "child") })
+			gate.deliver(newWebhookConfig("other"), func() { delivered = append(delivered, "other") })
+			Expect(delivered).To(Equal([]string{"other"}))
+
+			gate.parentHandler().OnAdd(parent)
+			Expect(delivered).To(Equal([]string{"other", "child"}))
+		})
+
+		It("Should drop the oldest events beyond the buffer, and the events held back too long", func() {
+			gate := newConsistencyGate(webhookGVK, 100*time.Millisecond, 2)
+			var mu sync.Mutex
+			var delivered []string
+			deliverFunc := func(name string) func() {
+				return func() {
+					mu.Lock()
+					defer mu.Unlock()
+					delivered = append(delivered, name)
+				}
+			}
+			withID := func(name, id string) *admissionv1.ValidatingWebhookConfiguration {
+				obj := newWebhookConfig(name)
+				obj.Annotations = map[string]string{CorrelationIDAnnotation: id}
+				return obj
+			}
+
+			for _, name := range []string{"a", "b", "c"} {
+				gate.deliver(withID(name, "id-1"), deliverFunc(name))
+			}
+			gate.deliver(withID("expired", "id-2"), deliverFunc("expired"))
+			gate.parentHandler().OnAdd(withID("parent", "id-1"))
+			mu.Lock()
+			Expect(delivered).To(Equal([]string{"b", "c"}))
+			mu.Unlock()
+
+			Eventually(func() int {
+				gate.mu.Lock()
+				defer gate.mu.Unlock()
+				return len(gate.pending)
+			}).Should(BeZero())
+			gate.parentHandler().OnAdd(withID("parent-2", "id-2"))
+			mu.Lock()
+			Expect(delivered).To(Equal([]string{"b", "c"}))
+			mu.Unlock()
+
+			// The correlation ID replaced on the parent is held back again
+			gate.parentHandler().OnUpdate(withID("parent", "id-1"), withID("parent", "id-3"))
+			gate.mu.Lock()
+			Expect(gate.released).NotTo(HaveKey("id-1"))
+			Expect(gate.released).To(HaveKey("id-3"))
+			gate.mu.Unlock()
+		})
+
+		It("Should deliver the buffered events, deletes included, before the following ones", func() {
+			gate := newConsistencyGate(webhookGVK, time.Minute, 10)
+			child := newWebhookConfig("child")
+			child.Annotations = map[string]string{CorrelationIDAnnotation: "id-1"}
+			parent := newWebhookConfig("parent")
+			parent.Annotations = map[string]string{CorrelationIDAnnotation: "id-1"}
+
+			var mu sync.Mutex
+			var delivered []string
+			record := func(name string) {
+				mu.Lock()
+				defer mu.Unlock()
+				delivered = append(delivered, name)
+			}
+			following := make(chan struct{})
+			gate.deliver(child, func() {
+				// The event of the dependent informer arriving during the release waits for it
+				go func() {
+					defer close(following)
+					gate.deliver(child, func() { record("update") })
+				}()
+				time.Sleep(50 * time.Millisecond)
+				record("add")
+			})
+			gate.deliver(toolscache.DeletedFinalStateUnknown{Key: "child", Obj: child}, func() { record("delete") })
+			mu.Lock()
+			Expect(delivered).To(BeEmpty())
+			mu.Unlock()
+
+			gate.parentHandler().OnAdd(parent)
+			Eventually(following).Should(BeClosed())
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(delivered).To(Equal([]string{"add", "delete", "update"}))
+		})
+
+		It("Should clear the gate once the GVKs are deregistered", func() {
+			c := newTestCSCache(webhookGVK, configMapGVK)
+			Expect(c.enableCrossGVKConsistency(configMapGVK, webhookGVK)).To(Succeed())
+			gate := c.gates[configMapGVK]
+			child := newConfigMap("ns", "child")
+			child.Annotations = map[string]string{CorrelationIDAnnotation: "id-1"}
+			parent := newWebhookConfig("parent")
+			parent.Annotations = map[string]string{CorrelationIDAnnotation: "id-1"}
+
+			gate.parentHandler().OnAdd(parent)
+			gate.deliver(child.DeepCopy(), func() {})
+			child.Annotations[CorrelationIDAnnotation] = "id-2"
+			gate.deliver(child, func() { Fail("the events of the deregistered GVK should be dropped") })
+			Expect(c.Deregister(configMapGVK)).To(Succeed())
+			gate.mu.Lock()
+			Expect(gate.pending).To(BeEmpty())
+			Expect(gate.released).To(BeEmpty())
+			gate.mu.Unlock()
+
+			// The events are no longer held back without the GVK depended on
+			Expect(c.Deregister(webhookGVK)).To(Succeed())
+			delivered := false
+			gate.deliver(child, func() { delivered = true })
+			Expect(delivered).To(BeTrue())
+		})
+	})
+
+	Context("Label selector cache", func() {
+		It("Should reuse the compiled selector for the same raw selector", func() {
+			c := newTestCSCache(configMapGVK)
+			store := c.informerMap[configMapGVK].GetStore()
+			cm := newConfigMap("ns", "a")
+			cm.Labels = map[string]string{"app": "cs"}
+			Expect(store.Add(cm)).To(Succeed())
+			Expect(store.Add(newConfigMap("ns", "b"))).To(Succeed())
+
+			firstList, secondList := &corev1.ConfigMapList{}, &corev1.ConfigMapList{}
+			Expect(c.List(context.TODO(), firstList, client.MatchingLabels{"app": "cs"})).To(Succeed())
+			Expect(c.List(context.TODO(), secondList, client.MatchingLabels{"app": "cs"})).To(Succeed())
+			Expect(firstList.Items).To(HaveLen(1))
+			Expect(secondList.Items).To(Equal(firstList.Items))
+
+			// The compiled selector is backed by a slice, compare the backing arrays
+			first := reflect.ValueOf(c.compiledSelector(labels.SelectorFromSet(labels.Set{"app": "cs"})))
+			second := reflect.ValueOf(c.compiledSelector(labels.SelectorFromSet(labels.Set{"app": "cs"})))
+			Expect(second.Pointer()).To(Equal(first.Pointer()))
+		})
+	})
+
+	Context("AddNamespaceWatch", func() {
+		It("Should serve the objects of the added namespace", func() {
+			c := newTestCSCache()
+			c.fallback = newFakeCache(c.Scheme, newConfigMap("ns1", "a"))
+			c.watchNamespaces = []string{"ns1"}
+			c.newNamespaceCache = func(namespace string) (cache.Cache, error) {
+				return newFakeCache(c.Scheme, newConfigMap(namespace, "b")), nil
+			}
+
+			list := &corev1.ConfigMapList{}
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+
+			Expect(c.IsWatchingNamespace("ns2")).To(BeFalse())
+			Expect(c.AddNamespaceWatch(context.TODO(), "ns2")).To(Succeed())
+			Expect(c.AddNamespaceWatch(context.TODO(), "ns2")).To(Succeed())
+			Expect(c.WatchNamespaces()).To(Equal([]string{"ns1", "ns2"}))
+			Expect(c.IsWatchingNamespace("ns2")).To(BeTrue())
+
+			allList := &corev1.ConfigMapList{}
+			Expect(c.List(context.TODO(), allList)).To(Succeed())
+			Expect(allList.Items).To(HaveLen(2))
+
+			nsList := &corev1.ConfigMapList{}
+			Expect(c.List(context.TODO(), nsList, client.InNamespace("ns2"))).To(Succeed())
+			Expect(nsList.Items).To(HaveLen(1))
+			Expect(nsList.Items[0].Name).To(Equal("b"))
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: "ns2", Name: "b"}, cm)).To(Succeed())
+		})
+
+		It("Should not add a namespace when watching all namespaces", func() {
+			c := newTestCSCache()
+			c.watchNamespaces = []string{""}
+			c.newNamespaceCache = func(namespace string) (cache.Cache, error) {
+				Fail("the namespace cache should not be built")
+				return nil, nil
+			}
+			Expect(c.AddNamespaceWatch(context.TODO(), "ns2")).To(Succeed())
+		})
+
+		It("Should add the namespace once when added concurrently", func() {
+			c := newTestCSCache()
+			c.fallback = newFakeCache(c.Scheme)
+			c.watchNamespaces = []string{"ns1"}
+			var built int32
+			c.newNamespaceCache = func(namespace string) (cache.Cache, error) {
+				atomic.AddInt32(&built, 1)
+				return newFakeCache(c.Scheme), nil
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					Expect(c.AddNamespaceWatch(context.TODO(), "ns2")).To(Succeed())
+				}()
+			}
+			wg.Wait()
+			Expect(c.WatchNamespaces()).To(Equal([]string{"ns1", "ns2"}))
+			Expect(atomic.LoadInt32(&built)).To(Equal(int32(1)))
+		})
+
+		It("Should deliver the events and indexes of the added namespace to the fallback informers", func() {
+			c := newTestCSCache()
+			c.fallback = newFakeCache(c.Scheme)
+			c.watchNamespaces = []string{"ns1"}
+			nsCache := newFakeCache(c.Scheme)
+			c.newNamespaceCache = func(namespace string) (cache.Cache, error) {
+				return nsCache, nil
+			}
+
+			informer, err := c.GetInformer(context.TODO(), &corev1.ConfigMap{})
+			Expect(err).NotTo(HaveOccurred())
+			added := make(chan string, 2)
+			informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{AddFunc: func(obj interface{}) {
+				added <- obj.(*corev1.ConfigMap).Namespace
+			}})
+			Expect(informer.AddIndexers(toolscache.Indexers{"byName": toolscache.MetaNamespaceIndexFunc})).To(Succeed())
+			Expect(c.IndexField(context.TODO(), &corev1.ConfigMap{}, "metadata.name", func(obj client.Object) []string {
+				return []string{obj.GetName()}
+			})).To(Succeed())
+
+			Expect(c.AddNamespaceWatch(context.TODO(), "ns2")).To(Succeed())
+			kindInformer, err := c.GetInformerForKind(context.TODO(), configMapGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kindInformer).To(BeIdenticalTo(informer))
+
+			fakeInformer, err := nsCache.(fakeCache).FakeInformerFor(&corev1.ConfigMap{})
+			Expect(err).NotTo(HaveOccurred())
+			fakeInformer.Add(newConfigMap("ns2", "b"))
+			Eventually(added).Should(Receive(Equal("ns2")))
+		})
+
+		It("Should rebuild the namespace-scoped informers with the added namespace", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("watch") == "true" {
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+					<-r.Context().Done()
+					return
+				}
+				switch r.URL.Path {
+				case "/api/v1/namespaces/ns1/configmaps":
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"ConfigMapList","metadata":{"resourceVersion":"1"},"items":[{"metadata":{"namespace":"ns1","name":"a","resourceVersion":"1"}}]}`))
+				case "/api/v1/namespaces/ns2/configmaps":
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"ConfigMapList","metadata":{"resourceVersion":"2"},"items":[{"metadata":{"namespace":"ns2","name":"b","resourceVersion":"2"}}]}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+				}
+			}))
+			defer server.Close()
+
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			mapper.Add(configMapGVK, apimeta.RESTScopeNamespace)
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.cacheOpts = cache.Options{Scheme: c.Scheme, Namespace: "ns1", Mapper: mapper}
+			c.fallback = newFakeCache(c.Scheme)
+			c.watchNamespaces = []string{"ns1"}
+			c.newNamespaceCache = func(namespace string) (cache.Cache, error) {
+				return newFakeCache(c.Scheme), nil
+			}
+			Expect(c.Register(configMapGVK)).To(Succeed())
+			informer, err := c.GetInformer(context.TODO(), &corev1.ConfigMap{})
+			Expect(err).NotTo(HaveOccurred())
+			added := make(chan string, 4)
+			informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{AddFunc: func(obj interface{}) {
+				added <- obj.(*corev1.ConfigMap).Namespace
+			}})
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Expect(c.WaitForCacheSync(ctx)).To(BeTrue())
+			Eventually(added).Should(Receive(Equal("ns1")))
+			list := &corev1.ConfigMapList{}
+			Expect(c.List(ctx, list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+
+			Expect(c.AddNamespaceWatch(ctx, "ns2")).To(Succeed())
+			Expect(c.informerNamespaces(configMapGVK)).To(Equal([]string{"ns1", "ns2"}))
+			list = &corev1.ConfigMapList{}
+			Expect(c.List(ctx, list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(2))
+			Eventually(added).Should(Receive(Equal("ns2")))
+		})
+	})
+
+	Context("Write-through policy", func() {
+		It("Should serve the fetched object until it expires", func() {
+			c := newTestCSCache(webhookGVK)
+			c.options.WriteThroughExpiry = 100 * time.Millisecond
+			key := types.NamespacedName{Name: "a"}
+			c.storeWriteThrough(webhookGVK, key, newWebhookConfig("a"))
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			found, err := c.getFromWriteThrough(webhookGVK, key, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(obj.Name).To(Equal("a"))
+
+			Expect(c.Get(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			Expect(c.Stats().TotalGetHits).To(Equal(uint64(1)))
+
+			Eventually(func() bool {
+				found, _ := c.getFromWriteThrough(webhookGVK, key, &admissionv1.ValidatingWebhookConfiguration{})
+				return found
+			}).Should(BeFalse())
+		})
+	})
+
+	Context("Health checkers", func() {
+		It("Should fail the liveness once an informer has exited while the cache is running", func() {
+			c := newTestCSCache(webhookGVK)
+			Expect(c.LivenessChecker()(nil)).To(Succeed())
+
+			// The informers stopped with their context have not failed
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			c.runInformer(ctx, webhookGVK, exitingInformer{c.informerMap[webhookGVK]})
+			Expect(c.LivenessChecker()(nil)).To(Succeed())
+
+			c.runInformer(context.TODO(), webhookGVK, exitingInformer{c.informerMap[webhookGVK]})
+			err := c.LivenessChecker()(nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(webhookGVK.String()))
+			Expect(c.Check(nil)).To(HaveOccurred())
+		})
+
+		It("Should fail the readiness until WaitForCacheSync has returned true", func() {
+			c := newTestCSCache()
+			c.fallback = newFakeCache(c.Scheme)
+			Expect(c.ReadinessChecker()(nil)).To(HaveOccurred())
+			Expect(c.Check(nil)).To(HaveOccurred())
+
+			Expect(c.WaitForCacheSync(context.TODO())).To(BeTrue())
+			Expect(c.ReadinessChecker()(nil)).To(Succeed())
+			Expect(c.Check(nil)).To(Succeed())
+		})
+	})
+
+	Context("DiffStore", func() {
+		It("Should return the diff between the last two versions returned by Get", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("a"))).To(Succeed())
+			key := types.NamespacedName{Name: "a"}
+
+			_, err := c.LastDiff(key, webhookGVK)
+			Expect(err).To(HaveOccurred())
+
+			Expect(c.Get(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			patch, err := c.LastDiff(key, webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patch).To(BeNil())
+
+			updated := newWebhookConfig("a")
+			updated.ResourceVersion = "2"
+			updated.Labels = map[string]string{"changed": "true"}
+			Expect(store.Update(updated)).To(Succeed())
+			Expect(c.Get(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			patch, err = c.LastDiff(key, webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(patch)).To(Equal(`{"metadata":{"labels":{"changed":"true"},"resourceVersion":"2"}}`))
+
+			c.diffs.evict(webhookGVK, "a")
+			_, err = c.LastDiff(key, webhookGVK)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Leader election integration", func() {
+		It("Should buffer the events while suspended and deliver them on resume", func() {
+			c := newTestCSCache(webhookGVK)
+			informer := &suspendableInformer{SharedIndexInformer: c.informerMap[webhookGVK], gvk: webhookGVK, cache: c}
+
+			var events []string
+			handler := informer.wrap(toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					events = append(events, "add "+obj.(client.Object).GetName())
+				},
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					events = append(events, "update "+oldObj.(client.Object).GetResourceVersion()+"->"+newObj.(client.Object).GetResourceVersion())
+				},
+				DeleteFunc: func(obj interface{}) {
+					events = append(events, "delete "+obj.(client.Object).GetName())
+				},
+			})
+			withRV := func(name, rv string) *admissionv1.ValidatingWebhookConfiguration {
+				obj := newWebhookConfig(name)
+				obj.ResourceVersion = rv
+				return obj
+			}
+
+			handler.OnAdd(withRV("seen", "1"))
+			handler.OnAdd(withRV("deleted", "1"))
+			c.SuspendReconcile()
+			// The object in the store before the suspension is not replayed
+			Expect(informer.GetStore().Add(withRV("unchanged", "1"))).To(Succeed())
+			handler.OnUpdate(withRV("seen", "1"), withRV("seen", "2"))
+			handler.OnUpdate(withRV("seen", "2"), withRV("seen", "3"))
+			handler.OnAdd(withRV("new", "1"))
+			handler.OnUpdate(withRV("new", "1"), withRV("new", "2"))
+			handler.OnAdd(withRV("transient", "1"))
+			handler.OnDelete(withRV("transient", "1"))
+			handler.OnDelete(withRV("deleted", "1"))
+			Expect(events).To(Equal([]string{"add seen", "add deleted"}))
+
+			c.ResumeReconcile()
+			Expect(events).To(Equal([]string{"add seen", "add deleted", "update 1->3", "add new", "delete deleted"}))
+			c.ResumeReconcile()
+			Expect(events).To(HaveLen(5))
+
+			handler.OnAdd(withRV("b", "1"))
+			Expect(events[5:]).To(Equal([]string{"add b"}))
+		})
+
+		It("Should drop the handlers of the deregistered informer", func() {
+			c := newTestCSCache(webhookGVK)
+			c.options.ElectedCh = make(chan struct{})
+			informer, err := c.GetInformer(context.TODO(), &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(err).NotTo(HaveOccurred())
+			informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{})
+			Expect(c.suspendableHandlers).To(HaveLen(1))
+
+			Expect(c.Deregister(webhookGVK)).To(Succeed())
+			Expect(c.suspendableHandlers).To(BeEmpty())
+		})
+	})
+
+	Context("buildInformerMap", func() {
+		It("Should build unstructured informers for the GVKs not in the scheme", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			unknownGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}
+
+			informerMap, err := buildInformerMap(&rest.Config{Host: "https://localhost:6443"}, cache.Options{Scheme: scheme}, 0,
+				[]schema.GroupVersionKind{webhookGVK, unknownGVK}, nil, CSCacheOptions{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(informerMap).To(HaveLen(4))
+			Expect(informerMap).To(HaveKey(webhookGVK))
+			Expect(informerMap).To(HaveKey(unknownGVK))
+		})
+
+		It("Should aggregate the errors of the GVKs", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+			informerMap, err := buildInformerMap(&rest.Config{Host: "http://%zz"}, cache.Options{Scheme: scheme}, 0,
+				[]schema.GroupVersionKind{webhookGVK, configMapGVK}, nil, CSCacheOptions{}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(webhookGVK.String()))
+			Expect(err.Error()).To(ContainSubstring(configMapGVK.String()))
+			Expect(informerMap).To(BeEmpty())
+		})
+	})
+
+	Context("Multi-region fallback", func() {
+		It("Should get the object from the secondary cluster when the primary is unavailable", func() {
+			primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer primary.Close()
+			secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"apiVersion":"admissionregistration.k8s.io/v1","kind":"ValidatingWebhookConfiguration","metadata":{"name":"a"}}`))
+			}))
+			defer secondary.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: primary.URL}
+			c.options.RegionFallbackCondition = apierrors.IsServiceUnavailable
+			regionalFallback, err := newRegionalFallback(&rest.Config{Host: secondary.URL}, c.options, c.cacheOpts)
+			Expect(err).NotTo(HaveOccurred())
+			c.regionalFallback = regionalFallback
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.getFromClient(context.TODO(), types.NamespacedName{Name: "a"}, obj, webhookGVK)).To(Succeed())
+			Expect(obj.Name).To(Equal("a"))
+
+			c.options.RegionFallbackCondition = apierrors.IsNotFound
+			err = c.getFromClient(context.TODO(), types.NamespacedName{Name: "a"}, obj, webhookGVK)
+			Expect(apierrors.IsServiceUnavailable(err)).To(BeTrue())
+		})
+	})
+
+	Context("GVK codec factories", func() {
+		It("Should decode the Protobuf encoded resources with the codec factory of the GVK", func() {
+			c := newTestCSCache(webhookGVK)
+			webhook := newWebhookConfig("a")
+			webhook.SetGroupVersionKind(webhookGVK)
+			data, err := runtime.Encode(protobuf.NewSerializer(c.Scheme, c.Scheme), webhook)
+			Expect(err).NotTo(HaveOccurred())
+
+			accepted := make(chan string, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accepted <- r.Header.Get("Accept")
+				w.Header().Set("Content-Type", runtime.ContentTypeProtobuf)
+				_, _ = w.Write(data)
+			}))
+			defer server.Close()
+
+			codecFactory := recordingCodecFactory{
+				NegotiatedSerializer: serializer.WithoutConversionCodecFactory{CodecFactory: serializer.NewCodecFactory(c.Scheme)},
+				decoded:              make(chan string, 1),
+			}
+			c.config = &rest.Config{Host: server.URL}
+			c.options.GVKCodecFactories = map[schema.GroupVersionKind]runtime.NegotiatedSerializer{webhookGVK: codecFactory}
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.getFromClient(context.TODO(), types.NamespacedName{Name: "a"}, obj, webhookGVK)).To(Succeed())
+			Expect(obj.Name).To(Equal("a"))
+			Expect(<-accepted).To(ContainSubstring(runtime.ContentTypeProtobuf))
+			Expect(<-codecFactory.decoded).To(Equal(runtime.ContentTypeProtobuf))
+		})
+	})
+
+	Context("Query router", func() {
+		It("Should route the requests to the registered cache", func() {
+			c := newTestCSCache(webhookGVK)
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("production"))).To(Succeed())
+			c.options.QueryRouter = func(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) string {
+				if key.Name == "staging" || key.Name == "" {
+					return "staging"
+				}
+				return ""
+			}
+			c.RegisterRoute("staging", newFakeCache(c.Scheme, newWebhookConfig("staging")))
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: "staging"}, obj)).To(Succeed())
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: "production"}, obj)).To(Succeed())
+			Expect(c.Stats().TotalGetHits).To(Equal(uint64(1)))
+
+			list := &admissionv1.ValidatingWebhookConfigurationList{}
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Name).To(Equal("staging"))
+		})
+	})
+
+	Context("MustBeFresherThan", func() {
+		It("Should fetch the object from the api server if the stored one is older", func() {
+			served := "7"
+			requested := make(chan string, 2)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested <- r.URL.Query().Get("resourceVersion")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"apiVersion":"admissionregistration.k8s.io/v1","kind":"ValidatingWebhookConfiguration","metadata":{"name":"a","resourceVersion":"` + served + `"}}`))
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("a"))).To(Succeed())
+			key := types.NamespacedName{Name: "a"}
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.GetWithOptions(context.TODO(), key, obj, MustBeFresherThan("1"))).To(Succeed())
+			Expect(obj.ResourceVersion).To(Equal("1"))
+
+			Expect(c.GetWithOptions(context.TODO(), key, obj, MustBeFresherThan("5"))).To(Succeed())
+			Expect(obj.ResourceVersion).To(Equal("7"))
+			Expect(<-requested).To(Equal("5"))
+
+			served = "3"
+			Expect(c.GetWithOptions(context.TODO(), key, obj, MustBeFresherThan("5"))).NotTo(Succeed())
+		})
+	})
+
+	Context("Object cache hierarchy", func() {
+		It("Should get the object missing in the local store from the parent", func() {
+			parent := newTestCSCache(webhookGVK)
+			parentStore := parent.informerMap[webhookGVK].GetStore()
+			Expect(parentStore.Add(newWebhookConfig("global"))).To(Succeed())
+			overridden := newWebhookConfig("overridden")
+			overridden.Labels = map[string]string{"override": "true"}
+			Expect(parentStore.Add(overridden)).To(Succeed())
+
+			c := newTestCSCache(webhookGVK)
+			c.options.ParentCache = parent
+			c.options.OverrideSelector = labels.SelectorFromSet(labels.Set{"override": "true"})
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.getFromParent(types.NamespacedName{Name: "global"}, obj, webhookGVK, GetOptions{})).To(BeTrue())
+			Expect(obj.Name).To(Equal("global"))
+			Expect(c.getFromParent(types.NamespacedName{Name: "overridden"}, obj, webhookGVK, GetOptions{})).To(BeFalse())
+			Expect(obj.Name).To(Equal("global"))
+			Expect(obj.Labels).To(BeEmpty())
+			Expect(c.getFromParent(types.NamespacedName{Name: "missing"}, obj, webhookGVK, GetOptions{})).To(BeFalse())
+			stale := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.getFromParent(types.NamespacedName{Name: "global"}, stale, webhookGVK, GetOptions{MinResourceVersion: "2"})).To(BeFalse())
+			Expect(stale.Name).To(BeEmpty())
+
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: "global"}, obj)).To(Succeed())
+			Expect(c.Stats().TotalGetHits).To(Equal(uint64(1)))
+		})
+	})
+
+	Context("Cross-namespace List", func() {
+		It("Should merge the objects of all the namespaces", func() {
+			c := newTestCSCache()
+			c.fallback = newFakeCache(c.Scheme)
+			c.watchNamespaces = []string{"ns-a"}
+			shared := newConfigMap("ns-a", "shared")
+			c.namespaceCaches = map[string]cache.Cache{
+				"ns-a": fakeCache{
+					FakeInformers:   &informertest.FakeInformers{Scheme: c.Scheme},
+					reader:          fake.NewClientBuilder().WithScheme(c.Scheme).WithObjects(newConfigMap("ns-a", "a"), shared).Build(),
+					resourceVersion: "12",
+				},
+				"ns-b": fakeCache{
+					FakeInformers:   &informertest.FakeInformers{Scheme: c.Scheme},
+					reader:          fake.NewClientBuilder().WithScheme(c.Scheme).WithObjects(newConfigMap("ns-b", "b"), shared.DeepCopy()).Build(),
+					resourceVersion: "9",
+				},
+			}
+
+			list := &corev1.ConfigMapList{}
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			var keys []string
+			for _, item := range list.Items {
+				keys = append(keys, item.Namespace+"/"+item.Name)
+			}
+			Expect(keys).To(ConsistOf("ns-a/a", "ns-a/shared", "ns-b/b"))
+			Expect(list.ResourceVersion).To(Equal("12"))
+		})
+	})
+
+	Context("Secret obfuscation", func() {
+		It("Should redact the secret fields unless the context allows reading them", func() {
+			secretGVK := corev1.SchemeGroupVersion.WithKind("Secret")
+			c := newTestCSCache(secretGVK)
+			c.options.SecretFields = map[schema.GroupVersionKind][]string{secretGVK: {"data", "stringData"}}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: "1"},
+				Data:       map[string][]byte{"token": []byte("plaintext")},
+			}
+			Expect(c.informerMap[secretGVK].GetStore().Add(secret)).To(Succeed())
+			key := types.NamespacedName{Name: "a"}
+
+			obj := &corev1.Secret{}
+			Expect(c.Get(context.TODO(), key, obj)).To(Succeed())
+			Expect(obj.Data).To(Equal(map[string][]byte{"token": []byte(RedactionMarker)}))
+
+			list := &corev1.SecretList{}
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Data).To(Equal(map[string][]byte{"token": []byte(RedactionMarker)}))
+
+			Expect(c.Get(WithSecretAccess(context.TODO(), secretGVK), key, obj)).To(Succeed())
+			Expect(obj.Data).To(Equal(map[string][]byte{"token": []byte("plaintext")}))
+			Expect(secret.Data).To(Equal(map[string][]byte{"token": []byte("plaintext")}))
+		})
+
+		Context("Readers", func() {
+			secretGVK := corev1.SchemeGroupVersion.WithKind("Secret")
+			redacted := map[string][]byte{"token": []byte(RedactionMarker)}
+			var c *CSCache
+
+			BeforeEach(func() {
+				byUID := func(obj interface{}) (string, error) {
+					return string(obj.(client.Object).GetUID()), nil
+				}
+				c = newTestCSCache(secretGVK)
+				c.options = buildCSCacheOptions([]CSCacheOption{WithKeyFunc(secretGVK, byUID)})
+				c.options.SecretFields = map[schema.GroupVersionKind][]string{secretGVK: {"data"}}
+				informer := c.informerMap[secretGVK]
+				Expect(c.addKeyFuncIndex(secretGVK, informer)).To(Succeed())
+				Expect(c.RegisterLabelIndex(context.TODO(), secretGVK, "app")).To(Succeed())
+				Expect(c.AnnotationIndex(secretGVK, "owner")).To(Succeed())
+				Expect(c.RegisterGenerationIndex(secretGVK)).To(Succeed())
+				Expect(informer.GetStore().Add(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "a", Namespace: "ns", ResourceVersion: "1", UID: "uid-a", Generation: 2,
+						Labels: map[string]string{"app": "a"}, Annotations: map[string]string{"owner": "a"},
+					},
+					Data: map[string][]byte{"token": []byte("plaintext")},
+				})).To(Succeed())
+			})
+
+			expectRedacted := func(objs []runtime.Object) {
+				Expect(objs).To(HaveLen(1))
+				Expect(objs[0].(*corev1.Secret).Data).To(Equal(redacted))
+			}
+
+			It("Should redact the secret fields in GetAll", func() {
+				objs, err := c.GetAll(context.TODO(), secretGVK)
+				Expect(err).NotTo(HaveOccurred())
+				expectRedacted(objs)
+			})
+
+			It("Should redact the secret fields in ForEach", func() {
+				var objs []runtime.Object
+				Expect(c.ForEach(context.TODO(), secretGVK, func(obj runtime.Object) bool {
+					objs = append(objs, obj)
+					return true
+				})).To(Succeed())
+				expectRedacted(objs)
+			})
+
+			It("Should redact the secret fields in ListChunked", func() {
+				var objs []runtime.Object
+				Expect(c.ListChunked(context.TODO(), secretGVK, 10, func(chunk []runtime.Object) error {
+					objs = append(objs, chunk...)
+					return nil
+				})).To(Succeed())
+				expectRedacted(objs)
+			})
+
+			It("Should redact the secret fields in ListByLabel", func() {
+				objs, err := c.ListByLabel(context.TODO(), secretGVK, "app", "a")
+				Expect(err).NotTo(HaveOccurred())
+				expectRedacted(objs)
+			})
+
+			It("Should redact the secret fields in ListByAnnotation", func() {
+				objs, err := c.ListByAnnotation(context.TODO(), secretGVK, "owner", "a")
+				Expect(err).NotTo(HaveOccurred())
+				expectRedacted(objs)
+			})
+
+			It("Should redact the secret fields in GetByCustomKey", func() {
+				obj := &corev1.Secret{}
+				Expect(c.GetByCustomKey(context.TODO(), secretGVK, "uid-a", obj)).To(Succeed())
+				Expect(obj.Data).To(Equal(redacted))
+			})
+
+			It("Should redact the secret fields in ObjectGenerationSince", func() {
+				objs, err := c.ObjectGenerationSince(context.TODO(), secretGVK, 1)
+				Expect(err).NotTo(HaveOccurred())
+				expectRedacted(objs)
+			})
+
+			It("Should redact the secret fields in GetOrFetch", func() {
+				c.options.FallbackPolicy = FallbackNever
+				obj := &corev1.Secret{}
+				Expect(c.GetOrFetch(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "b"}, obj,
+					func(ctx context.Context, key client.ObjectKey) (client.Object, error) {
+						return &corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+							Data:       map[string][]byte{"token": []byte("plaintext")},
+						}, nil
+					})).To(Succeed())
+				Expect(obj.Name).To(Equal("b"))
+				Expect(obj.Data).To(Equal(redacted))
+			})
+
+			It("Should redact the secret fields in Refresh", func() {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"a","namespace":"ns","resourceVersion":"1"},"data":{"token":"cGxhaW50ZXh0"}}`))
+				}))
+				defer server.Close()
+				c.config = &rest.Config{Host: server.URL}
+
+				obj := &corev1.Secret{}
+				Expect(c.Refresh(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "a"}, obj)).To(Succeed())
+				Expect(obj.Data).To(Equal(redacted))
+				stored, exists, err := c.informerMap[secretGVK].GetStore().GetByKey("ns/a")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(BeTrue())
+				Expect(stored.(*corev1.Secret).Data).To(Equal(map[string][]byte{"token": []byte("plaintext")}))
+			})
+
+			It("Should not redact the secret fields the context allows reading", func() {
+				objs, err := c.GetAll(WithSecretAccess(context.TODO(), secretGVK), secretGVK)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(objs).To(HaveLen(1))
+				Expect(objs[0].(*corev1.Secret).Data).To(Equal(map[string][]byte{"token": []byte("plaintext")}))
+			})
+		})
+	})
+
+	Context("MultiClusterCSCache", func() {
+		It("Should route the requests to the cache of the cluster", func() {
+			newServer := func(cluster string) *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"apiVersion":"admissionregistration.k8s.io/v1","kind":"ValidatingWebhookConfiguration","metadata":{"name":"a","labels":{"cluster":"` + cluster + `"}}}`))
+				}))
+			}
+			hub := newServer("hub")
+			defer hub.Close()
+			spoke := newServer("spoke")
+			defer spoke.Close()
+
+			local := newTestCSCache(webhookGVK)
+			Expect(local.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("a"))).To(Succeed())
+			m := &MultiClusterCSCache{
+				local:              local,
+				clusterGVKList:     []schema.GroupVersionKind{webhookGVK},
+				watchNamespaceList: []string{""},
+				cacheOpts:          local.cacheOpts,
+			}
+			Expect(m.AddCluster("hub", &rest.Config{Host: hub.URL}, CSCacheOptions{})).To(Succeed())
+			Expect(m.AddCluster("spoke", &rest.Config{Host: spoke.URL}, CSCacheOptions{})).To(Succeed())
+			Expect(m.AddCluster("spoke", &rest.Config{Host: spoke.URL}, CSCacheOptions{})).NotTo(Succeed())
+
+			key := types.NamespacedName{Name: "a"}
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(m.Get(context.TODO(), key, obj)).To(Succeed())
+			Expect(obj.Labels).To(BeEmpty())
+			Expect(m.Get(WithCluster(context.TODO(), "hub"), key, obj)).To(Succeed())
+			Expect(obj.Labels["cluster"]).To(Equal("hub"))
+			Expect(m.Get(WithCluster(context.TODO(), "spoke"), key, obj)).To(Succeed())
+			Expect(obj.Labels["cluster"]).To(Equal("spoke"))
+
+			Expect(m.RemoveCluster("spoke")).To(Succeed())
+			Expect(m.Get(WithCluster(context.TODO(), "spoke"), key, obj)).NotTo(Succeed())
+			Expect(m.RemoveCluster("spoke")).NotTo(Succeed())
+		})
+	})
+
+	Context("Clone", func() {
+		It("Should only show the objects of the watched namespaces", func() {
+			c := newTestCSCache(configMapGVK)
+			store := c.informerMap[configMapGVK].GetStore()
+			for _, cm := range []*corev1.ConfigMap{newConfigMap("ns-a", "a"), newConfigMap("ns-b", "b")} {
+				Expect(store.Add(cm)).To(Succeed())
+			}
+
+			_, err := c.Clone(nil)
+			Expect(err).To(HaveOccurred())
+			view, err := c.Clone([]string{"ns-a"})
+			Expect(err).NotTo(HaveOccurred())
+
+			list := &corev1.ConfigMapList{}
+			Expect(view.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Name).To(Equal("a"))
+			Expect(view.List(context.TODO(), list, client.InNamespace("ns-b"))).To(Succeed())
+			Expect(list.Items).To(BeEmpty())
+
+			Expect(view.Get(context.TODO(), types.NamespacedName{Namespace: "ns-a", Name: "a"}, &corev1.ConfigMap{})).To(Succeed())
+			err = view.Get(context.TODO(), types.NamespacedName{Namespace: "ns-b", Name: "b"}, &corev1.ConfigMap{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			// The informers registered in the cache are shared with the view
+			c.informerMap[webhookGVK] = newTestCSCache(webhookGVK).informerMap[webhookGVK]
+			_, err = view.GetInformerForKind(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("Prepopulate", func() {
+		It("Should seed the informer store before the cache starts", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				list := &admissionv1.ValidatingWebhookConfigurationList{}
+				list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+				for _, name := range []string{"a", "b", "c", "d", "e"} {
+					list.Items = append(list.Items, *newWebhookConfig(name))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(list)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			Expect(c.Prepopulate(context.TODO())).To(Succeed())
+
+			Expect(c.informerMap[webhookGVK].GetStore().ListKeys()).To(ConsistOf("a", "b", "c", "d", "e"))
+			ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+			defer cancel()
+			Expect(c.WaitForCacheSync(ctx)).To(BeTrue())
+		})
+
+		It("Should list the objects in the namespace and with the tweaks of the informer", func() {
+			var mu sync.Mutex
+			requests := make(map[string]url.Values)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests[r.URL.Path] = r.URL.Query()
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/json")
+				if strings.HasSuffix(r.URL.Path, "/configmaps") {
+					list := &corev1.ConfigMapList{}
+					list.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMapList"))
+					list.Items = append(list.Items, *newConfigMap("ns", "a"))
+					Expect(json.NewEncoder(w).Encode(list)).To(Succeed())
+					return
+				}
+				list := &admissionv1.ValidatingWebhookConfigurationList{}
+				list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+				Expect(json.NewEncoder(w).Encode(list)).To(Succeed())
+			}))
+			defer server.Close()
+
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			mapper.Add(webhookGVK, apimeta.RESTScopeRoot)
+			mapper.Add(configMapGVK, apimeta.RESTScopeNamespace)
+			c := newTestCSCache(webhookGVK, configMapGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.cacheOpts.Namespace = "ns"
+			c.cacheOpts.Mapper = mapper
+			c.options.InformerTweakOptions = map[schema.GroupVersionKind]TweakListOptionsFunc{
+				webhookGVK: func(options *metav1.ListOptions) { options.LabelSelector = "app=a" },
+			}
+			c.options.ExcludeNamespaces = []string{"kube-system"}
+			Expect(c.Prepopulate(context.TODO())).To(Succeed())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(requests).To(HaveKey("/apis/admissionregistration.k8s.io/v1/validatingwebhookconfigurations"))
+			Expect(requests["/apis/admissionregistration.k8s.io/v1/validatingwebhookconfigurations"].Get("labelSelector")).To(Equal("app=a"))
+			Expect(requests).To(HaveKey("/api/v1/namespaces/ns/configmaps"))
+			Expect(requests["/api/v1/namespaces/ns/configmaps"].Get("fieldSelector")).To(Equal("metadata.namespace!=kube-system"))
+			Expect(c.informerMap[configMapGVK].GetStore().ListKeys()).To(ConsistOf("ns/a"))
+		})
+	})
+
+	Context("WaitForGVKSync", func() {
+		It("Should time out waiting for the informer that hasn't synced", func() {
+			c := newTestCSCache(webhookGVK, configMapGVK)
+			c.prepopulated = map[schema.GroupVersionKind]bool{configMapGVK: true}
+
+			synced, err := c.WaitForGVKSync(context.TODO(), configMapGVK, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(synced).To(BeTrue())
+
+			synced, err = c.WaitForGVKSync(context.TODO(), webhookGVK, 100*time.Millisecond)
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(synced).To(BeFalse())
+
+			_, err = c.WaitForGVKSync(context.TODO(), schema.GroupVersionKind{Version: "v1", Kind: "Unknown"}, time.Second)
+			Expect(err).To(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+			defer cancel()
+			Expect(c.WaitForCacheSync(ctx)).To(BeFalse())
+		})
+	})
+
+	Context("Read-only guard", func() {
+		It("Should reject the mutating calls", func() {
+			c := newTestCSCache(webhookGVK)
+			c.options.ReadOnly = true
+			c.fallback = readOnlyCache{Cache: newFakeCache(c.Scheme, newConfigMap("ns", "a"))}
+
+			writer, ok := c.fallback.(client.Writer)
+			Expect(ok).To(BeTrue())
+			Expect(writer.Create(context.TODO(), newConfigMap("ns", "b"))).To(MatchError(ErrReadOnlyCache))
+			Expect(writer.Delete(context.TODO(), newConfigMap("ns", "a"))).To(MatchError(ErrReadOnlyCache))
+			Expect(c.deleteFromClient(context.TODO(), "a", webhookGVK)).To(MatchError(ErrReadOnlyCache))
+
+			Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "a"}, &corev1.ConfigMap{})).To(Succeed())
+		})
+	})
+
+	Context("Lazy GVKs", func() {
+		It("Should start the informer on the first access", func() {
+			server := newListWatchServer()
+			defer server.Close()
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.lazyGVKs = map[schema.GroupVersionKind]*lazyInformer{webhookGVK: {timeout: 5 * time.Second}}
+			c.startCtx = ctx
+
+			_, ok := c.getInformer(webhookGVK)
+			Expect(ok).To(BeFalse())
+
+			err := c.Get(ctx, types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			informer, ok := c.getInformer(webhookGVK)
+			Expect(ok).To(BeTrue())
+			Expect(informer.HasSynced()).To(BeTrue())
+			Expect(c.informerCancels).To(HaveKey(webhookGVK))
+			Expect(c.lazyGVKs).NotTo(HaveKey(webhookGVK))
+		})
+
+		It("Should build the informer of the lazy GVK for GetInformer and IndexField", func() {
+			c := newTestCSCache()
+			c.lazyGVKs = map[schema.GroupVersionKind]*lazyInformer{
+				webhookGVK:   {timeout: 5 * time.Second},
+				configMapGVK: {timeout: 5 * time.Second},
+			}
+			// The fallback builds no informer for the lazy GVKs
+			c.fallback = nil
+
+			informer, err := c.GetInformer(context.TODO(), &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(err).NotTo(HaveOccurred())
+			registered, ok := c.getInformer(webhookGVK)
+			Expect(ok).To(BeTrue())
+			Expect(informer.(toolscache.SharedIndexInformer).GetStore()).To(BeIdenticalTo(registered.GetStore()))
+			kindInformer, err := c.GetInformerForKind(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kindInformer.(toolscache.SharedIndexInformer).GetStore()).To(BeIdenticalTo(registered.GetStore()))
+
+			Expect(c.IndexField(context.TODO(), &corev1.ConfigMap{}, "data", func(obj client.Object) []string { return nil })).To(Succeed())
+			registered, ok = c.getInformer(configMapGVK)
+			Expect(ok).To(BeTrue())
+			Expect(registered.GetIndexer().GetIndexers()).To(HaveKey("field:data"))
+			Expect(c.lazyGVKs).To(BeEmpty())
+		})
+
+		It("Should not block the other GVKs while a lazy informer syncs", func() {
+			unblock := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-unblock:
+				}
+			}))
+			defer server.Close()
+			defer close(unblock)
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme, newConfigMap("ns", "a"))
+			c.options = buildCSCacheOptions([]CSCacheOption{WithLazyGVK(webhookGVK, 5*time.Second)})
+			Expect(c.options.LazyInitTimeouts).To(Equal(map[schema.GroupVersionKind]time.Duration{webhookGVK: 5 * time.Second}))
+			_, c.lazyGVKs = splitLazyGVKs(nil, c.options.LazyGVKs, c.options.LazyInitTimeouts)
+			c.startCtx = ctx
+
+			getCtx, getCancel := context.WithTimeout(ctx, 5*time.Second)
+			defer getCancel()
+			go func() {
+				defer GinkgoRecover()
+				_ = c.Get(getCtx, types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{})
+			}()
+			Eventually(func() bool {
+				_, ok := c.getInformer(webhookGVK)
+				return ok
+			}).Should(BeTrue())
+
+			start := time.Now()
+			Expect(c.Get(ctx, types.NamespacedName{Namespace: "ns", Name: "a"}, &corev1.ConfigMap{})).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+	})
+
+	Context("WarmUpProgress", func() {
+		It("Should report each synced informer and close after full sync", func() {
+			c := newTestCSCache(webhookGVK, configMapGVK)
+			c.fallback = newFakeCache(c.Scheme)
+			progress := c.WarmUpProgress()
+
+			c.prepopulated = map[schema.GroupVersionKind]bool{webhookGVK: true}
+			ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+			defer cancel()
+			Expect(c.WaitForCacheSync(ctx)).To(BeFalse())
+			Expect(<-progress).To(Equal(WarmUpEvent{GVK: webhookGVK, Synced: true, Total: 2, SyncedCount: 1}))
+
+			c.prepopulated[configMapGVK] = true
+			Expect(c.WaitForCacheSync(context.TODO())).To(BeTrue())
+			Expect(<-progress).To(Equal(WarmUpEvent{GVK: configMapGVK, Synced: true, Total: 2, SyncedCount: 2}))
+			_, open := <-progress
+			Expect(open).To(BeFalse())
+		})
+	})
+
+	Context("Observe", func() {
+		It("Should fan out the events to all the observers", func() {
+			server := newListWatchServer(newWebhookConfig("a"))
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			Expect(c.Observe(configMapGVK, toolscache.ResourceEventHandlerFuncs{})).NotTo(Succeed())
+
+			added := make(chan string, 2)
+			for _, observer := range []string{"first", "second"} {
+				observer := observer
+				Expect(c.Observe(webhookGVK, toolscache.ResourceEventHandlerFuncs{
+					AddFunc: func(obj interface{}) {
+						added <- observer + "/" + obj.(client.Object).GetName()
+					},
+				})).To(Succeed())
+			}
+			Expect(c.observers).To(HaveLen(2))
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			var received []string
+			Eventually(func() []string {
+				select {
+				case event := <-added:
+					received = append(received, event)
+				default:
+				}
+				return received
+			}).Should(ConsistOf("first/a", "second/a"))
+		})
+	})
+
+	Context("Type mismatch", func() {
+		It("Should return an error instead of panicking", func() {
+			c := newTestCSCache(webhookGVK)
+			informer := c.informerMap[webhookGVK]
+			mutating := &admissionv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: "1"},
+			}
+			Expect(informer.GetStore().Add(mutating)).To(Succeed())
+
+			key := types.NamespacedName{Name: "a"}
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			err := c.getFromStore(informer, key, obj, webhookGVK)
+			Expect(err).To(MatchError(ContainSubstring("was asked for")))
+
+			Expect(func() {
+				_ = c.Get(context.TODO(), key, obj)
+			}).NotTo(Panic())
+		})
+
+		It("Should return an error for a Secret requested from the ConfigMap store", func() {
+			c := newTestCSCache(configMapGVK)
+			informer := c.informerMap[configMapGVK]
+			Expect(informer.GetStore().Add(newConfigMap("ns", "a"))).To(Succeed())
+
+			var err error
+			Expect(func() {
+				err = c.getFromStore(informer, types.NamespacedName{Namespace: "ns", Name: "a"}, &corev1.Secret{}, configMapGVK)
+			}).NotTo(Panic())
+			Expect(err).To(MatchError("cache had type *v1.ConfigMap, but *v1.Secret was asked for"))
+		})
+	})
+
+	Context("Snapshot", func() {
+		It("Should return a copy of the informer stores", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("a"))).To(Succeed())
+
+			snapshot, err := c.Snapshot(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot).To(HaveLen(1))
+			Expect(snapshot[webhookGVK]).To(HaveLen(1))
+			snapshot[webhookGVK][0].(*admissionv1.ValidatingWebhookConfiguration).Labels = map[string]string{"changed": "true"}
+
+			item, exists, err := store.GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			Expect(item.(*admissionv1.ValidatingWebhookConfiguration).Labels).To(BeEmpty())
+
+			data, err := c.SnapshotJSON(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`"kind":"ValidatingWebhookConfiguration"`))
+			Expect(string(data)).To(ContainSubstring(`"name":"a"`))
+		})
+
+		It("Should redact the secret fields unless the context allows reading them", func() {
+			secretGVK := corev1.SchemeGroupVersion.WithKind("Secret")
+			c := newTestCSCache(secretGVK)
+			c.options.SecretFields = map[schema.GroupVersionKind][]string{secretGVK: {"data"}}
+			Expect(c.informerMap[secretGVK].GetStore().Add(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: "1"},
+				Data:       map[string][]byte{"token": []byte("plaintext")},
+			})).To(Succeed())
+
+			snapshot, err := c.Snapshot(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot[secretGVK][0].(*corev1.Secret).Data).To(Equal(map[string][]byte{"token": []byte(RedactionMarker)}))
+			data, err := c.SnapshotJSON(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).NotTo(ContainSubstring(base64.StdEncoding.EncodeToString([]byte("plaintext"))))
+
+			snapshot, err = c.Snapshot(WithSecretAccess(context.TODO(), secretGVK))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot[secretGVK][0].(*corev1.Secret).Data).To(Equal(map[string][]byte{"token": []byte("plaintext")}))
+		})
+	})
+
+	Context("Impersonation", func() {
+		It("Should impersonate the user set in the context", func() {
+			received := make(chan http.Header, 2)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received <- r.Header
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			key := types.NamespacedName{Name: "a"}
+
+			ctx := WithImpersonation(context.TODO(), rest.ImpersonationConfig{UserName: "system:serviceaccount:tenant:operator"})
+			Expect(c.getFromClient(ctx, key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)).NotTo(Succeed())
+			Expect((<-received).Get("Impersonate-User")).To(Equal("system:serviceaccount:tenant:operator"))
+
+			Expect(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)).NotTo(Succeed())
+			Expect((<-received).Get("Impersonate-User")).To(BeEmpty())
+		})
+	})
+
+	Context("ProjectFields", func() {
+		It("Should only populate the projected fields of the listed objects", func() {
+			c := newTestCSCache(configMapGVK)
+			cm := newConfigMap("ns", "a")
+			cm.Labels = map[string]string{"app": "a"}
+			cm.Annotations = map[string]string{"keep": "yes", "drop": "no"}
+			cm.Data = map[string]string{"key": "value", "other": "value"}
+			Expect(c.informerMap[configMapGVK].GetStore().Add(cm)).To(Succeed())
+
+			list := &corev1.ConfigMapList{}
+			Expect(c.List(context.TODO(), list, ProjectFields("metadata.name", "metadata.annotations.keep", "data.key"))).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			item := list.Items[0]
+			Expect(item.Name).To(Equal("a"))
+			Expect(item.Namespace).To(BeEmpty())
+			Expect(item.ResourceVersion).To(BeEmpty())
+			Expect(item.Labels).To(BeNil())
+			Expect(item.Annotations).To(Equal(map[string]string{"keep": "yes"}))
+			Expect(item.Data).To(Equal(map[string]string{"key": "value"}))
+
+			// The store object is left untouched
+			Expect(cm.Annotations).To(HaveLen(2))
+		})
+
+		It("Should project nested spec fields", func() {
+			obj := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns"},
+				Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: "sa"},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+			projected, err := project(obj, []string{"spec.nodeName", "status.phase"})
+			Expect(err).NotTo(HaveOccurred())
+			pod := projected.(*corev1.Pod)
+			Expect(pod.Name).To(BeEmpty())
+			Expect(pod.Spec.NodeName).To(Equal("node"))
+			Expect(pod.Spec.ServiceAccountName).To(BeEmpty())
+			Expect(pod.Status.Phase).To(Equal(corev1.PodRunning))
+		})
+
+		It("Should fail on unknown fields", func() {
+			_, err := project(newConfigMap("ns", "a"), []string{"spec.unknown"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("EventMultiplexer", func() {
+		It("Should send the same event to every subscriber", func() {
+			informer := &controllertest.FakeInformer{}
+			m := NewEventMultiplexer(informer, 1)
+			first, second := m.Subscribe("first"), m.Subscribe("second")
+
+			cm := newConfigMap("ns", "a")
+			informer.Add(cm)
+			Expect(<-first).To(Equal(WatchEvent{Type: AddEvent, Object: cm}))
+			Expect(<-second).To(Equal(WatchEvent{Type: AddEvent, Object: cm}))
+		})
+
+		It("Should not block the other subscribers on a slow subscriber", func() {
+			informer := &controllertest.FakeInformer{}
+			m := NewEventMultiplexer(informer, 1)
+			slow, fast := m.Subscribe("slow"), m.Subscribe("fast")
+
+			for _, name := range []string{"a", "b", "c"} {
+				informer.Add(newConfigMap("ns", name))
+				Expect((<-fast).Object.(*corev1.ConfigMap).Name).To(Equal(name))
+			}
+			Expect((<-slow).Object.(*corev1.ConfigMap).Name).To(Equal("a"))
+			Expect(slow).To(BeEmpty())
+			Expect(m.Dropped()).To(Equal(uint64(2)))
+		})
+
+		It("Should close the channel on Unsubscribe", func() {
+			informer := &controllertest.FakeInformer{}
+			m := NewEventMultiplexer(informer, 1)
+			ch := m.Subscribe("a")
+			m.Unsubscribe("a")
+			Eventually(ch).Should(BeClosed())
+			informer.Add(newConfigMap("ns", "a"))
+		})
+	})
+
+	Context("TLS rotation", func() {
+		It("Should pick up the rotated client certificate without rebuilding the client", func() {
+			received := make(chan string, 2)
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received <- r.TLS.PeerCertificates[0].Subject.CommonName
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+			// Every request opens a new connection
+			server.Config.SetKeepAlivesEnabled(false)
+			server.StartTLS()
+			defer server.Close()
+
+			dir, err := ioutil.TempDir("", "cs-cache-tls")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+			writeClientCert(certFile, keyFile, "client-a")
+
+			caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			csOpts := buildCSCacheOptions([]CSCacheOption{WithTLSRotation()})
+			config, err := buildClientConfig(&rest.Config{
+				Host:            server.URL,
+				TLSClientConfig: rest.TLSClientConfig{CAData: caData, CertFile: certFile, KeyFile: keyFile},
+			}, csOpts)
+			Expect(err).NotTo(HaveOccurred())
+			c := newTestCSCache(webhookGVK)
+			c.config = config
+			key := types.NamespacedName{Name: "a"}
+
+			Expect(apierrors.IsNotFound(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK))).To(BeTrue())
+			Expect(<-received).To(Equal("client-a"))
+
+			writeClientCert(certFile, keyFile, "client-b")
+			Expect(apierrors.IsNotFound(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK))).To(BeTrue())
+			Expect(<-received).To(Equal("client-b"))
+		})
+
+		It("Should require the certificate files", func() {
+			_, err := NewRotatingTLSConfig(&rest.Config{Host: "https://localhost:6443"}).RESTConfig()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("ListenAndSync", func() {
+		It("Should write the objects out of line with the ones listed from the api server", func() {
+			changed := newWebhookConfig("changed")
+			changed.ResourceVersion = "2"
+			var items atomic.Value
+			items.Store([]*admissionv1.ValidatingWebhookConfiguration{newWebhookConfig("unchanged"), newWebhookConfig("changed"), newWebhookConfig("deleted")})
+			server := newMutableListWatchServer(func() []*admissionv1.ValidatingWebhookConfiguration {
+				return items.Load().([]*admissionv1.ValidatingWebhookConfiguration)
+			})
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			var mu sync.Mutex
+			var events []string
+			record := func(event string, obj interface{}) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, event+" "+obj.(client.Object).GetName())
+			}
+			informer, err := c.GetInformer(context.TODO(), &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(err).NotTo(HaveOccurred())
+			informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+				AddFunc:    func(obj interface{}) { record("add", obj) },
+				UpdateFunc: func(_, obj interface{}) { record("update", obj) },
+				DeleteFunc: func(obj interface{}) { record("delete", obj) },
+			})
+			recorded := func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([]string{}, events...)
+			}
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			store := c.informerMap[webhookGVK].GetStore()
+			Eventually(recorded).Should(ConsistOf("add unchanged", "add changed", "add deleted"))
+
+			// The watch misses the changes on the api server
+			items.Store([]*admissionv1.ValidatingWebhookConfiguration{newWebhookConfig("unchanged"), changed, newWebhookConfig("added")})
+			go func() {
+				defer GinkgoRecover()
+				Expect(c.ListenAndSync(ctx, 10*time.Millisecond)).To(Succeed())
+			}()
+
+			Eventually(store.ListKeys, 5*time.Second).Should(ConsistOf("unchanged", "changed", "added"))
+			obj, _, err := store.GetByKey("changed")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.(*admissionv1.ValidatingWebhookConfiguration).ResourceVersion).To(Equal("2"))
+			Consistently(recorded, 100*time.Millisecond).Should(ConsistOf(
+				"add unchanged", "add changed", "add deleted", "update changed", "add added", "delete deleted"))
+		})
+
+		It("Should keep the stored objects newer than the list", func() {
+			server := newMutableListWatchServer(func() []*admissionv1.ValidatingWebhookConfiguration {
+				return []*admissionv1.ValidatingWebhookConfiguration{newWebhookConfig("a")}
+			})
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			informer := c.informerMap[webhookGVK]
+			newer := newWebhookConfig("a")
+			newer.ResourceVersion = "5"
+			created := newWebhookConfig("created")
+			created.ResourceVersion = "11"
+			Expect(informer.GetStore().Add(newer)).To(Succeed())
+			Expect(informer.GetStore().Add(created)).To(Succeed())
+
+			Expect(c.syncStore(context.TODO(), webhookGVK, informer)).To(Succeed())
+			Expect(informer.GetStore().ListKeys()).To(ConsistOf("a", "created"))
+			obj, _, err := informer.GetStore().GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.(*admissionv1.ValidatingWebhookConfiguration).ResourceVersion).To(Equal("5"))
+		})
+
+		It("Should reject a non-positive interval", func() {
+			Expect(newTestCSCache(webhookGVK).ListenAndSync(context.TODO(), 0)).NotTo(Succeed())
+		})
+	})
+
+	Context("ForEach", func() {
+		It("Should stop iterating when the function returns false", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			for _, name := range []string{"a", "b", "c"} {
+				Expect(store.Add(newWebhookConfig(name))).To(Succeed())
+			}
+
+			visited := 0
+			Expect(c.ForEach(context.TODO(), webhookGVK, func(runtime.Object) bool {
+				visited++
+				return visited < 2
+			})).To(Succeed())
+			Expect(visited).To(Equal(2))
+		})
+
+		It("Should not let the function mutate the store", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("a"))).To(Succeed())
+
+			Expect(c.ForEach(context.TODO(), webhookGVK, func(obj runtime.Object) bool {
+				obj.(*admissionv1.ValidatingWebhookConfiguration).Labels = map[string]string{"mutated": "true"}
+				return true
+			})).To(Succeed())
+			obj, _, err := store.GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.(*admissionv1.ValidatingWebhookConfiguration).Labels).To(BeEmpty())
+		})
+
+		It("Should fail for the GVK not in the cache", func() {
+			c := newTestCSCache(webhookGVK)
+			Expect(c.ForEach(context.TODO(), configMapGVK, func(runtime.Object) bool { return true })).NotTo(Succeed())
+		})
+	})
+
+	Context("GVKs", func() {
+		It("Should list the registered GVKs without the List kinds", func() {
+			c := newTestCSCache(webhookGVK)
+			Expect(c.GVKs()).To(Equal([]schema.GroupVersionKind{webhookGVK}))
+			Expect(c.HasGVK(configMapGVK)).To(BeFalse())
+
+			Expect(c.Register(configMapGVK)).To(Succeed())
+			Expect(c.GVKs()).To(Equal([]schema.GroupVersionKind{configMapGVK, webhookGVK}))
+			Expect(c.HasGVK(configMapGVK)).To(BeTrue())
+			Expect(c.HasGVK(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMapList"})).To(BeFalse())
+
+			Expect(c.Deregister(configMapGVK)).To(Succeed())
+			Expect(c.GVKs()).To(Equal([]schema.GroupVersionKind{webhookGVK}))
+			Expect(c.HasGVK(configMapGVK)).To(BeFalse())
+		})
+	})
+
+	Context("SyncedGVKs", func() {
+		It("Should split the GVKs into the synced and pending ones", func() {
+			c := newTestCSCache(webhookGVK, configMapGVK)
+			Expect(c.SyncedGVKs()).To(BeEmpty())
+			Expect(c.PendingGVKs()).To(Equal([]schema.GroupVersionKind{configMapGVK, webhookGVK}))
+
+			c.prepopulated = map[schema.GroupVersionKind]bool{configMapGVK: true}
+			Expect(c.SyncedGVKs()).To(Equal([]schema.GroupVersionKind{configMapGVK}))
+			Expect(c.PendingGVKs()).To(Equal([]schema.GroupVersionKind{webhookGVK}))
+		})
+	})
+
+	Context("GVKResourceVersion", func() {
+		It("Should return the list resourceVersion of the informer once it has synced", func() {
+			server := newListWatchServer(newWebhookConfig("a"))
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			_, err := c.GVKResourceVersion(webhookGVK)
+			Expect(err).To(Equal(ErrNotSynced))
+			_, err = c.GVKResourceVersion(configMapGVK)
+			Expect(err).To(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go c.informerMap[webhookGVK].Run(ctx.Done())
+			Eventually(c.informerMap[webhookGVK].HasSynced).Should(BeTrue())
+			Expect(c.GVKResourceVersion(webhookGVK)).To(Equal("1"))
+		})
+	})
+
+	Context("NewTypedEventHandler", func() {
+		It("Should convert the informer events of the type to controller-runtime events", func() {
+			createCh := make(chan event.CreateEvent, 1)
+			updateCh := make(chan event.UpdateEvent, 1)
+			deleteCh := make(chan event.DeleteEvent, 1)
+			handler := NewTypedEventHandler[*corev1.ConfigMap](createCh, updateCh, deleteCh)
+
+			cm := newConfigMap("ns", "a")
+			handler.OnAdd(cm)
+			Expect(<-createCh).To(Equal(event.CreateEvent{Object: cm}))
+
+			updated := cm.DeepCopy()
+			updated.ResourceVersion = "2"
+			handler.OnUpdate(cm, updated)
+			Expect(<-updateCh).To(Equal(event.UpdateEvent{ObjectOld: cm, ObjectNew: updated}))
+
+			handler.OnDelete(toolscache.DeletedFinalStateUnknown{Key: "ns/a", Obj: cm})
+			Expect(<-deleteCh).To(Equal(event.DeleteEvent{Object: cm, DeleteStateUnknown: true}))
+
+			// The events of the other types are skipped
+			handler.OnAdd(newWebhookConfig("a"))
+			Expect(createCh).To(BeEmpty())
+		})
+	})
+
+	Context("DiscoverCRDGVKs", func() {
+		It("Should return the served GVKs of the cluster-scoped CRDs in the scheme", func() {
+			newCRD := func(group, kind string, scope apiextensionsv1.ResourceScope, versions ...apiextensionsv1.CustomResourceDefinitionVersion) apiextensionsv1.CustomResourceDefinition {
+				return apiextensionsv1.CustomResourceDefinition{Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Group:    group,
+					Names:    apiextensionsv1.CustomResourceDefinitionNames{Kind: kind},
+					Scope:    scope,
+					Versions: versions,
+				}}
+			}
+			labelSelector := make(chan string, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				labelSelector <- r.URL.Query().Get("labelSelector")
+				list := &apiextensionsv1.CustomResourceDefinitionList{Items: []apiextensionsv1.CustomResourceDefinition{
+					newCRD("admissionregistration.k8s.io", "ValidatingWebhookConfiguration", apiextensionsv1.ClusterScoped,
+						apiextensionsv1.CustomResourceDefinitionVersion{Name: "v1", Served: true},
+						apiextensionsv1.CustomResourceDefinitionVersion{Name: "v1beta1", Served: false}),
+					newCRD("", "ConfigMap", apiextensionsv1.NamespaceScoped,
+						apiextensionsv1.CustomResourceDefinitionVersion{Name: "v1", Served: true}),
+					newCRD("example.com", "Unknown", apiextensionsv1.ClusterScoped,
+						apiextensionsv1.CustomResourceDefinitionVersion{Name: "v1", Served: true}),
+				}}
+				list.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinitionList"))
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(list)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache()
+			gvks, err := DiscoverCRDGVKs(context.TODO(), &rest.Config{Host: server.URL}, c.Scheme, "operator=cs")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(<-labelSelector).To(Equal("operator=cs"))
+			Expect(gvks).To(Equal([]schema.GroupVersionKind{webhookGVK}))
+			Expect(mergeGVKs([]schema.GroupVersionKind{configMapGVK, webhookGVK}, gvks)).To(Equal([]schema.GroupVersionKind{configMapGVK, webhookGVK}))
+		})
+	})
+
+	Context("Label index", func() {
+		It("Should list the objects with the label value from the label index", func() {
+			c := newTestCSCache(configMapGVK)
+			ctx := context.TODO()
+			Expect(c.IndexField(ctx, &corev1.ConfigMap{}, LabelIndexField("app"), func(obj client.Object) []string {
+				if value, ok := obj.GetLabels()["app"]; ok {
+					return []string{value}
+				}
+				return nil
+			})).To(Succeed())
+			Expect(c.hasLabelIndex(c.informerMap[configMapGVK], configMapGVK, "app")).To(BeTrue())
+			Expect(c.hasLabelIndex(c.informerMap[configMapGVK], configMapGVK, "tier")).To(BeFalse())
+
+			store := c.informerMap[configMapGVK].GetStore()
+			for _, key := range [][]string{{"ns1", "a", "a"}, {"ns2", "b", "a"}, {"ns1", "c", "c"}} {
+				cm := newConfigMap(key[0], key[1])
+				cm.Labels = map[string]string{"app": key[2]}
+				Expect(store.Add(cm)).To(Succeed())
+			}
+
+			for _, raw := range []string{"app=a", "app in (a)"} {
+				sel, err := labels.Parse(raw)
+				Expect(err).NotTo(HaveOccurred())
+				list := &corev1.ConfigMapList{}
+				Expect(c.List(ctx, list, client.MatchingLabelsSelector{Selector: sel}, SortedListOption{})).To(Succeed())
+				Expect(list.Items).To(HaveLen(2))
+				Expect(list.Items[0].Name).To(Equal("a"))
+				Expect(list.Items[1].Name).To(Equal("b"))
+
+				nsList := &corev1.ConfigMapList{}
+				Expect(c.List(ctx, nsList, client.MatchingLabelsSelector{Selector: sel}, client.InNamespace("ns2"))).To(Succeed())
+				Expect(nsList.Items).To(HaveLen(1))
+				Expect(nsList.Items[0].Name).To(Equal("b"))
+			}
+		})
+
+		It("Should only use the label index for a single value requirement", func() {
+			for raw, indexed := range map[string]bool{
+				"app=a":           true,
+				"app==a":          true,
+				"app in (a)":      true,
+				"app in (a,b)":    false,
+				"app!=a":          false,
+				"!app":            false,
+				"app=a,tier=back": false,
+			} {
+				sel, err := labels.Parse(raw)
+				Expect(err).NotTo(HaveOccurred())
+				_, _, ok := singleLabelValue(sel)
+				Expect(ok).To(Equal(indexed), raw)
+			}
+		})
+	})
+
+	Context("APIServerTimeout", func() {
+		It("Should time out the requests to a slow api server", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			}))
+			defer server.Close()
+
+			config, err := buildClientConfig(&rest.Config{Host: server.URL}, buildCSCacheOptions([]CSCacheOption{WithAPIServerTimeout(50 * time.Millisecond)}))
+			Expect(err).NotTo(HaveOccurred())
+			c := newTestCSCache(webhookGVK)
+			c.config = config
+
+			start := time.Now()
+			err = c.getFromClient(context.TODO(), types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue(), err.Error())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("Should not time out the requests answered in time", func() {
+			server := newListWatchServer(newWebhookConfig("a"))
+			defer server.Close()
+
+			config, err := buildClientConfig(&rest.Config{Host: server.URL}, buildCSCacheOptions([]CSCacheOption{WithAPIServerTimeout(time.Second)}))
+			Expect(err).NotTo(HaveOccurred())
+			c := newTestCSCache(webhookGVK)
+			c.config = config
+			items, err := c.listFromClient(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items).To(HaveLen(1))
+		})
+	})
+
+	Context("ObjectExists", func() {
+		It("Should check the store and then the api server", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/server"):
+					obj := newWebhookConfig("server")
+					obj.SetGroupVersionKind(webhookGVK)
+					Expect(json.NewEncoder(w).Encode(obj)).To(Succeed())
+				case strings.HasSuffix(r.URL.Path, "/broken"):
+					w.WriteHeader(http.StatusInternalServerError)
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"InternalError","code":500}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+				}
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("store"))).To(Succeed())
+
+			for name, expected := range map[string]bool{"store": true, "server": true, "missing": false} {
+				exists, err := c.ObjectExists(context.TODO(), types.NamespacedName{Name: name}, webhookGVK)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(Equal(expected), name)
+			}
+
+			exists, err := c.ObjectExists(context.TODO(), types.NamespacedName{Name: "broken"}, webhookGVK)
+			Expect(apierrors.IsInternalError(err)).To(BeTrue())
+			Expect(exists).To(BeFalse())
+		})
+	})
+
+	Context("GetOrFetch", func() {
+		It("Should only fetch the objects not found in the cache", func() {
+			server := newListWatchServer()
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.options.WriteThroughExpiry = time.Minute
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("cached"))).To(Succeed())
+
+			fetched := 0
+			fetch := func(ctx context.Context, key client.ObjectKey) (client.Object, error) {
+				fetched++
+				obj := newWebhookConfig(key.Name)
+				obj.Labels = map[string]string{"fetched": "true"}
+				return obj, nil
+			}
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.GetOrFetch(context.TODO(), types.NamespacedName{Name: "cached"}, obj, fetch)).To(Succeed())
+			Expect(fetched).To(Equal(0))
+
+			for i := 0; i < 2; i++ {
+				obj := &admissionv1.ValidatingWebhookConfiguration{}
+				Expect(c.GetOrFetch(context.TODO(), types.NamespacedName{Name: "missing"}, obj, fetch)).To(Succeed())
+				Expect(obj.Name).To(Equal("missing"))
+				Expect(obj.Labels).To(HaveKey("fetched"))
+			}
+			Expect(fetched).To(Equal(1))
+		})
+
+		It("Should return the error of the fetch", func() {
+			server := newListWatchServer()
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			err := c.GetOrFetch(context.TODO(), types.NamespacedName{Name: "missing"}, &admissionv1.ValidatingWebhookConfiguration{}, func(ctx context.Context, key client.ObjectKey) (client.Object, error) {
+				return nil, apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+			})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("ScopeToNamespace", func() {
+		It("Should scope the reads to the namespace", func() {
+			c := newTestCSCache(configMapGVK)
+			store := c.informerMap[configMapGVK].GetStore()
+			for _, cm := range []*corev1.ConfigMap{newConfigMap("ns-a", "a"), newConfigMap("ns-b", "b")} {
+				Expect(store.Add(cm)).To(Succeed())
+			}
+			var scoped cache.Cache = c.ScopeToNamespace("ns-a")
+
+			cm := &corev1.ConfigMap{}
+			Expect(scoped.Get(context.TODO(), types.NamespacedName{Name: "a"}, cm)).To(Succeed())
+			Expect(cm.Namespace).To(Equal("ns-a"))
+			err := scoped.Get(context.TODO(), types.NamespacedName{Namespace: "ns-b", Name: "b"}, &corev1.ConfigMap{})
+			Expect(errors.Is(err, ErrNamespaceForbidden)).To(BeTrue())
+
+			list := &corev1.ConfigMapList{}
+			Expect(scoped.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Name).To(Equal("a"))
+			Expect(scoped.List(context.TODO(), list, client.InNamespace("ns-a"))).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			err = scoped.List(context.TODO(), list, client.InNamespace("ns-b"))
+			Expect(errors.Is(err, ErrNamespaceForbidden)).To(BeTrue())
+		})
+
+		It("Should not scope the cluster-scoped objects", func() {
+			c := newTestCSCache(webhookGVK)
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			mapper.Add(webhookGVK, apimeta.RESTScopeRoot)
+			c.cacheOpts.Mapper = mapper
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("a"))).To(Succeed())
+			scoped := c.ScopeToNamespace("ns-a")
+
+			Expect(scoped.Get(context.TODO(), types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			list := &admissionv1.ValidatingWebhookConfigurationList{}
+			Expect(scoped.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+		})
+	})
+
+	Context("Collector", func() {
+		It("Should collect the metrics of the cache", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			for _, name := range []string{"a", "b"} {
+				Expect(store.Add(newWebhookConfig(name))).To(Succeed())
+			}
+			c.getHits, c.getMisses = 3, 1
+			c.syncDurations = map[schema.GroupVersionKind]time.Duration{webhookGVK: 2 * time.Second}
+
+			expected := `
+# HELP cs_cache_get_hits_total Number of Get requests served from the CSCache informer stores
+# TYPE cs_cache_get_hits_total counter
+cs_cache_get_hits_total 3
+# HELP cs_cache_get_misses_total Number of Get requests sent to the api server by the CSCache
+# TYPE cs_cache_get_misses_total counter
+cs_cache_get_misses_total 1
+# HELP cs_cache_informer_sync_latency_seconds Time from the start of the CSCache until the informer synced
+# TYPE cs_cache_informer_sync_latency_seconds gauge
+cs_cache_informer_sync_latency_seconds{gvk="admissionregistration.k8s.io/v1, Kind=ValidatingWebhookConfiguration"} 2
+# HELP cs_cache_store_objects Number of objects held in the CSCache informer store
+# TYPE cs_cache_store_objects gauge
+cs_cache_store_objects{gvk="admissionregistration.k8s.io/v1, Kind=ValidatingWebhookConfiguration"} 2
+`
+			Expect(testutil.CollectAndCompare(c, strings.NewReader(expected))).To(Succeed())
+			Expect(prometheus.NewRegistry().Register(c)).To(Succeed())
+		})
+
+		It("Should fail to register the metrics of a second CSCache", func() {
+			first, second := newTestCSCache(), newTestCSCache()
+			Expect(registerCacheMetrics(first)).To(Succeed())
+			defer metrics.Registry.Unregister(first)
+			Expect(registerCacheMetrics(second)).To(HaveOccurred())
+		})
+	})
+
+	Context("DryRunAPIClient", func() {
+		It("Should send the requests with dryRun and not cache the results", func() {
+			dryRun := make(chan string, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				dryRun <- r.URL.Query().Get("dryRun")
+				obj := newWebhookConfig("a")
+				obj.SetGroupVersionKind(webhookGVK)
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(obj)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.options.DryRunAPIClient = true
+			c.options.WriteThroughExpiry = time.Minute
+			key := types.NamespacedName{Name: "a"}
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.getFromClient(context.TODO(), key, obj, webhookGVK)).To(Succeed())
+			Expect(<-dryRun).To(Equal("All"))
+			Expect(obj.Name).To(Equal("a"))
+			found, err := c.getFromWriteThrough(webhookGVK, key, &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Context("Refresh", func() {
+		It("Should update the older object in the store", func() {
+			var items atomic.Value
+			items.Store([]*admissionv1.ValidatingWebhookConfiguration{newWebhookConfig("a")})
+			server := newMutableListWatchServer(func() []*admissionv1.ValidatingWebhookConfiguration {
+				return items.Load().([]*admissionv1.ValidatingWebhookConfiguration)
+			})
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			updated := make(chan string, 1)
+			informer, err := c.GetInformer(context.TODO(), &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(err).NotTo(HaveOccurred())
+			informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					updated <- "add " + obj.(client.Object).GetResourceVersion()
+				},
+				UpdateFunc: func(oldObj, obj interface{}) {
+					updated <- oldObj.(client.Object).GetResourceVersion() + "->" + obj.(client.Object).GetResourceVersion()
+				},
+			})
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Eventually(updated).Should(Receive(Equal("add 1")))
+
+			newer := newWebhookConfig("a")
+			newer.ResourceVersion = "2"
+			items.Store([]*admissionv1.ValidatingWebhookConfiguration{newer})
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.Refresh(context.TODO(), types.NamespacedName{Name: "a"}, obj)).To(Succeed())
+			Expect(obj.ResourceVersion).To(Equal("2"))
+
+			stored, _, err := c.informerMap[webhookGVK].GetStore().GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.(*admissionv1.ValidatingWebhookConfiguration).ResourceVersion).To(Equal("2"))
+			Expect(stored).NotTo(BeIdenticalTo(obj))
+			Expect(updated).To(Receive(Equal("1->2")))
+
+			// The object in the store isn't older than the one on the api server anymore
+			Expect(c.Refresh(context.TODO(), types.NamespacedName{Name: "a"}, obj)).To(Succeed())
+			Consistently(updated, 100*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	Context("getFromClient errors", func() {
+		It("Should wrap the errors with the resource identity and the cluster", func() {
+			server := newListWatchServer()
+			defer server.Close()
+
+			c := newTestCSCache(configMapGVK)
+			c.config = &rest.Config{Host: server.URL}
+			err := c.getFromClient(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "a"}, &corev1.ConfigMap{}, configMapGVK)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			var statusErr *apierrors.StatusError
+			Expect(errors.As(err, &statusErr)).To(BeTrue())
+			Expect(err.Error()).To(HavePrefix("getFromClient GVK=/v1, Kind=ConfigMap namespace=ns name=a cluster=" + server.URL + ": "))
+		})
+	})
+
+	Context("SyncDuration", func() {
+		It("Should record how long the informer took to sync", func() {
+			server := newListWatchServer(newWebhookConfig("a"))
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			_, ok := c.SyncDuration(webhookGVK)
+			Expect(ok).To(BeFalse())
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+
+			Eventually(func() bool {
+				_, ok := c.SyncDuration(webhookGVK)
+				return ok
+			}).Should(BeTrue())
+			d, _ := c.SyncDuration(webhookGVK)
+			Expect(d).To(BeNumerically(">", 0))
+			Expect(d).To(BeNumerically("<", time.Second))
+		})
+	})
+
+	Context("Prefetch", func() {
+		It("Should add the objects missing in the store", func() {
+			fieldSelectors := make(chan string, 2)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fieldSelectors <- r.URL.Query().Get("fieldSelector")
+				list := &admissionv1.ValidatingWebhookConfigurationList{}
+				list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+				for _, name := range []string{"cached", "b", "c", "other"} {
+					list.Items = append(list.Items, *newWebhookConfig(name))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(list)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("cached"))).To(Succeed())
+
+			keys := []client.ObjectKey{{Name: "cached"}, {Name: "b"}, {Name: "c"}}
+			Expect(c.Prefetch(context.TODO(), keys, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			Expect(<-fieldSelectors).To(BeEmpty())
+			Expect(store.ListKeys()).To(ConsistOf("cached", "b", "c"))
+
+			// All the keys are in the store
+			Expect(c.Prefetch(context.TODO(), keys, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			Expect(fieldSelectors).To(BeEmpty())
+
+			// A single missing key is listed by name
+			Expect(c.Prefetch(context.TODO(), []client.ObjectKey{{Name: "other"}}, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			Expect(<-fieldSelectors).To(Equal("metadata.name=other"))
+			Expect(store.ListKeys()).To(HaveLen(4))
+		})
+	})
+
+	Context("Unstructured", func() {
+		It("Should get an unstructured object from the typed store", func() {
+			c := newTestCSCache(webhookGVK)
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("a"))).To(Succeed())
+
+			u := &unstructured.Unstructured{}
+			u.SetGroupVersionKind(webhookGVK)
+			Expect(c.Get(context.TODO(), client.ObjectKey{Name: "a"}, u)).To(Succeed())
+			Expect(u.GetName()).To(Equal("a"))
+			Expect(u.GetObjectKind().GroupVersionKind()).To(Equal(webhookGVK))
+		})
+
+		It("Should list the typed store into an unstructured list", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			for _, name := range []string{"a", "b"} {
+				Expect(store.Add(newWebhookConfig(name))).To(Succeed())
+			}
+
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(2))
+			Expect([]string{list.Items[0].GetName(), list.Items[1].GetName()}).To(ConsistOf("a", "b"))
+		})
+
+		It("Should decode the GVKs not in the scheme as unstructured objects", func() {
+			widgetGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"a","resourceVersion":"1"}}`))
+			}))
+			defer server.Close()
+
+			obj, err := newInformerObject(runtime.NewScheme(), widgetGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj).To(BeAssignableToTypeOf(&unstructured.Unstructured{}))
+
+			restClient, err := getClientForGVK(context.TODO(), widgetGVK, &rest.Config{Host: server.URL}, runtime.NewScheme(), nil)
+			Expect(err).NotTo(HaveOccurred())
+			u := &unstructured.Unstructured{}
+			Expect(restClient.Get().Resource("widgets").Name("a").Do(context.TODO()).Into(u)).To(Succeed())
+			Expect(u.GetName()).To(Equal("a"))
+			Expect(u.GroupVersionKind()).To(Equal(widgetGVK))
+		})
+	})
+
+	Context("RegisterLabelIndex", func() {
+		It("Should list the objects by the label value from the index", func() {
+			c := newTestCSCache(configMapGVK)
+			_, err := c.ListByLabel(context.TODO(), configMapGVK, "app.kubernetes.io/managed-by", "operator1")
+			Expect(err).To(HaveOccurred())
+
+			// The indexers are added before the store is filled
+			Expect(c.RegisterLabelIndex(context.TODO(), configMapGVK, "app.kubernetes.io/managed-by")).To(Succeed())
+			Expect(c.RegisterLabelIndex(context.TODO(), configMapGVK, "app.kubernetes.io/managed-by")).To(Succeed())
+
+			store := c.informerMap[configMapGVK].GetStore()
+			for i := 0; i < 10000; i++ {
+				cm := newConfigMap(fmt.Sprintf("ns%d", i%10), fmt.Sprintf("cm%d", i))
+				cm.Labels = map[string]string{"app.kubernetes.io/managed-by": fmt.Sprintf("operator%d", i%1000)}
+				Expect(store.Add(cm)).To(Succeed())
+			}
+
+			start := time.Now()
+			objs, err := c.ListByLabel(context.TODO(), configMapGVK, "app.kubernetes.io/managed-by", "operator1")
+			indexed := time.Since(start)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(10))
+			for _, obj := range objs {
+				Expect(obj.(*corev1.ConfigMap).Labels["app.kubernetes.io/managed-by"]).To(Equal("operator1"))
+				Expect(obj.GetObjectKind().GroupVersionKind()).To(Equal(configMapGVK))
+			}
+
+			// The same lookup scanning the store
+			var scanned []runtime.Object
+			start = time.Now()
+			Expect(c.ForEach(context.TODO(), configMapGVK, func(obj runtime.Object) bool {
+				if obj.(*corev1.ConfigMap).Labels["app.kubernetes.io/managed-by"] == "operator1" {
+					scanned = append(scanned, obj)
+				}
+				return true
+			})).To(Succeed())
+			Expect(scanned).To(HaveLen(10))
+			Expect(indexed).To(BeNumerically("<", time.Since(start)))
+		})
+	})
+
+	Context("EventRecorder", func() {
+		It("Should emit a CacheError event after the consecutive getFromClient failures", func() {
+			var failing int32 = 1
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if atomic.LoadInt32(&failing) == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"InternalError","code":500}`))
+					return
+				}
+				obj := newWebhookConfig("a")
+				obj.SetGroupVersionKind(webhookGVK)
+				Expect(json.NewEncoder(w).Encode(obj)).To(Succeed())
+			}))
+			defer server.Close()
+
+			recorder := record.NewFakeRecorder(10)
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "operator"}}
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.options = buildCSCacheOptions([]CSCacheOption{WithEventRecorder(recorder, pod, 3)})
+
+			key := types.NamespacedName{Name: "a"}
+			for i := 0; i < 2; i++ {
+				Expect(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)).NotTo(Succeed())
+			}
+			Expect(recorder.Events).To(BeEmpty())
+
+			Expect(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)).NotTo(Succeed())
+			Expect(recorder.Events).To(Receive(HavePrefix("Warning CacheError 3 consecutive failures")))
+			Expect(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)).NotTo(Succeed())
+			Expect(recorder.Events).To(BeEmpty())
+
+			// A success resets the count
+			atomic.StoreInt32(&failing, 0)
+			Expect(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)).To(Succeed())
+			atomic.StoreInt32(&failing, 1)
+			for i := 0; i < 2; i++ {
+				Expect(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)).NotTo(Succeed())
+			}
+			Expect(recorder.Events).To(BeEmpty())
+			Expect(c.getFromClient(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)).NotTo(Succeed())
+			Expect(recorder.Events).To(Receive())
+		})
+	})
+
+	Context("WatchWithContext", func() {
+		It("Should stop delivering the events once the context is cancelled", func() {
+			c := newTestCSCache(webhookGVK)
+			ctx, cancel := context.WithCancel(context.TODO())
+			Expect(c.WatchWithContext(ctx, configMapGVK, toolscache.ResourceEventHandlerFuncs{})).NotTo(Succeed())
+
+			var added, deleted []string
+			Expect(c.WatchWithContext(ctx, webhookGVK, toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					added = append(added, obj.(client.Object).GetName())
+				},
+				DeleteFunc: func(obj interface{}) {
+					deleted = append(deleted, obj.(client.Object).GetName())
+				},
+			})).To(Succeed())
+			Expect(c.observers).To(HaveLen(1))
+			handler := c.observers[0].handler
+
+			handler.OnAdd(newWebhookConfig("a"))
+			Expect(added).To(Equal([]string{"a"}))
+
+			cancel()
+			Eventually(func() bool {
+				delivered := len(added)
+				handler.OnAdd(newWebhookConfig("b"))
+				return len(added) == delivered
+			}).Should(BeTrue())
+			added = nil
+			Consistently(func() []string {
+				handler.OnAdd(newWebhookConfig("c"))
+				handler.OnDelete(newWebhookConfig("a"))
+				return append(added, deleted...)
+			}).Should(BeEmpty())
+		})
+	})
+
+	Context("Drain", func() {
+		It("Should empty the store and fall through to the api server", func() {
+			requests := make(chan string, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests <- r.URL.Path
+				obj := newWebhookConfig("a")
+				obj.SetGroupVersionKind(webhookGVK)
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(obj)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			store := c.informerMap[webhookGVK].GetStore()
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				Expect(store.Add(newWebhookConfig(name))).To(Succeed())
+			}
+			Expect(c.Drain(context.TODO(), configMapGVK)).NotTo(Succeed())
+
+			Expect(c.Drain(context.TODO(), webhookGVK)).To(Succeed())
+			Expect(store.ListKeys()).To(BeEmpty())
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.Get(context.TODO(), client.ObjectKey{Name: "a"}, obj)).To(Succeed())
+			Expect(<-requests).To(HaveSuffix("/validatingwebhookconfigurations/a"))
+			Expect(obj.Name).To(Equal("a"))
+		})
+	})
+
+	Context("ForbiddenGVKs", func() {
+		It("Should record the GVKs forbidden by the api server", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if strings.Contains(r.URL.Path, "/configmaps/") {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+					return
+				}
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"Forbidden","code":403}`))
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK, configMapGVK)
+			c.config = &rest.Config{Host: server.URL}
+			Expect(c.ForbiddenGVKs()).To(BeEmpty())
+
+			for i := 0; i < 2; i++ {
+				err := c.getFromClient(context.TODO(), types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{}, webhookGVK)
+				Expect(apierrors.IsForbidden(err)).To(BeTrue())
+			}
+			err := c.getFromClient(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "a"}, &corev1.ConfigMap{}, configMapGVK)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(c.ForbiddenGVKs()).To(Equal([]schema.GroupVersionKind{webhookGVK}))
+
+			expected := `
+# HELP cs_cache_forbidden_total Number of CSCache requests to the api server forbidden by the RBAC
+# TYPE cs_cache_forbidden_total counter
+cs_cache_forbidden_total{gvk="admissionregistration.k8s.io/v1, Kind=ValidatingWebhookConfiguration"} 2
+`
+			Expect(testutil.CollectAndCompare(c, strings.NewReader(expected), "cs_cache_forbidden_total")).To(Succeed())
+		})
+	})
+
+	Context("FieldIndexes", func() {
+		It("Should register the field indexes of the options", func() {
+			byData := func(obj client.Object) []string {
+				return []string{obj.(*corev1.ConfigMap).Data["key"]}
+			}
+			byWebhookName := func(obj client.Object) []string {
+				return []string{obj.GetName()}
+			}
+			c := newTestCSCache(configMapGVK)
+			c.fallback = newFakeCache(c.Scheme)
+			c.options = buildCSCacheOptions([]CSCacheOption{
+				WithFieldIndex(configMapGVK, "data.key", byData),
+				WithFieldIndex(webhookGVK, "webhookName", byWebhookName),
+			})
+			Expect(c.registerFieldIndexes()).To(Succeed())
+
+			store := c.informerMap[configMapGVK].GetStore()
+			for _, name := range []string{"a", "b"} {
+				cm := newConfigMap("ns", name)
+				cm.Data = map[string]string{"key": name}
+				Expect(store.Add(cm)).To(Succeed())
+			}
+			list := &corev1.ConfigMapList{}
+			Expect(c.List(context.TODO(), list, client.MatchingFields{"data.key": "b"})).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Name).To(Equal("b"))
+
+			// The GVK registered after the cache is built is indexed as well
+			server := newListWatchServer()
+			defer server.Close()
+			c.config = &rest.Config{Host: server.URL}
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			Expect(c.informerMap[webhookGVK].GetIndexer().GetIndexers()).To(HaveKey(FieldIndexName("webhookName")))
+		})
+	})
+
+	Context("informerNamespace", func() {
+		It("Should watch the cluster-scoped GVKs in all the namespaces", func() {
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			mapper.Add(webhookGVK, apimeta.RESTScopeRoot)
+			mapper.Add(configMapGVK, apimeta.RESTScopeNamespace)
+			unknownGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}
+
+			opts := cache.Options{Namespace: "ns", Mapper: mapper}
+			Expect(informerNamespace(opts, webhookGVK)).To(BeEmpty())
+			Expect(informerNamespace(opts, configMapGVK)).To(Equal("ns"))
+			Expect(informerNamespace(opts, unknownGVK)).To(Equal("ns"))
+			Expect(informerNamespace(cache.Options{Namespace: "ns"}, webhookGVK)).To(Equal("ns"))
+		})
+
+		It("Should list the resources in the watched namespace", func() {
+			paths := make(chan string, 4)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("watch") == "true" {
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+					<-r.Context().Done()
+					return
+				}
+				paths <- r.URL.Path
+				if strings.HasSuffix(r.URL.Path, "/configmaps") {
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"ConfigMapList","metadata":{"resourceVersion":"1"},"items":[]}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"apiVersion":"admissionregistration.k8s.io/v1","kind":"ValidatingWebhookConfigurationList","metadata":{"resourceVersion":"1"},"items":[]}`))
+			}))
+			defer server.Close()
+
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			mapper.Add(webhookGVK, apimeta.RESTScopeRoot)
+			mapper.Add(configMapGVK, apimeta.RESTScopeNamespace)
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			informerMap, err := buildInformerMap(&rest.Config{Host: server.URL}, cache.Options{Scheme: scheme, Namespace: "ns", Mapper: mapper}, 0,
+				[]schema.GroupVersionKind{webhookGVK, configMapGVK}, nil, CSCacheOptions{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go informerMap[webhookGVK].Run(ctx.Done())
+			go informerMap[configMapGVK].Run(ctx.Done())
+			Expect([]string{<-paths, <-paths}).To(ConsistOf(
+				"/apis/admissionregistration.k8s.io/v1/validatingwebhookconfigurations",
+				"/api/v1/namespaces/ns/configmaps",
+			))
+		})
+	})
+
+	Context("GVKStatus", func() {
+		It("Should report the state of each informer", func() {
+			server := newListWatchServer(newWebhookConfig("a"))
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			Expect(c.Register(configMapGVK)).To(Succeed())
+			Expect(c.GVKStatus()).To(Equal(map[schema.GroupVersionKind]InformerStatus{
+				webhookGVK:   {},
+				configMapGVK: {},
+			}))
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Eventually(func() bool {
+				status := c.GVKStatus()[webhookGVK]
+				return status.Running && status.Synced && !status.LastSyncTime.IsZero()
+			}).Should(BeTrue())
+			Expect(c.GVKStatus()[webhookGVK].ObjectCount).To(Equal(1))
+			Expect(c.GVKStatus()[webhookGVK].LastError).NotTo(HaveOccurred())
+			Eventually(func() error {
+				return c.GVKStatus()[configMapGVK].LastError
+			}).Should(HaveOccurred())
+			Expect(c.GVKStatus()[configMapGVK].Synced).To(BeFalse())
+
+			rec := httptest.NewRecorder()
+			c.debugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DebugCachePath, nil))
+			body := map[string]map[string]interface{}{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+			Expect(body[webhookGVK.String()]).To(HaveKeyWithValue("synced", true))
+			Expect(body[webhookGVK.String()]).To(HaveKeyWithValue("objectCount", 1.0))
+			Expect(body[configMapGVK.String()]).To(HaveKey("lastError"))
+
+			cancel()
+			Eventually(func() bool {
+				return c.GVKStatus()[webhookGVK].Running
+			}).Should(BeFalse())
+		})
+	})
+
+	Context("CompareAndSwap", func() {
+		It("Should replace the object only if the resourceVersion matches", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("a"))).To(Succeed())
+
+			stale := newWebhookConfig("a")
+			stale.ResourceVersion = "0"
+			swapped, err := c.CompareAndSwap(context.TODO(), webhookGVK, stale, newWebhookConfig("a"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeFalse())
+
+			swapped, err = c.CompareAndSwap(context.TODO(), webhookGVK, newWebhookConfig("missing"), newWebhookConfig("missing"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeFalse())
+
+			_, err = c.CompareAndSwap(context.TODO(), webhookGVK, newWebhookConfig("a"), newWebhookConfig("b"))
+			Expect(err).To(HaveOccurred())
+			_, err = c.CompareAndSwap(context.TODO(), configMapGVK, newConfigMap("ns", "a"), newConfigMap("ns", "a"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should let only one of the concurrent swaps win", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("a"))).To(Succeed())
+
+			var wins int32
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					updated := newWebhookConfig("a")
+					updated.ResourceVersion = fmt.Sprintf("%d", i+2)
+					swapped, err := c.CompareAndSwap(context.TODO(), webhookGVK, newWebhookConfig("a"), updated)
+					Expect(err).NotTo(HaveOccurred())
+					if swapped {
+						atomic.AddInt32(&wins, 1)
+					}
+				}(i)
+			}
+			wg.Wait()
+			Expect(wins).To(Equal(int32(1)))
+
+			item, _, err := store.GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item.(*admissionv1.ValidatingWebhookConfiguration).ResourceVersion).NotTo(Equal("1"))
+		})
+	})
+
+	Context("InformerTweakOptions", func() {
+		It("Should list the objects with the tweaked options", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("watch") == "true" {
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+					<-r.Context().Done()
+					return
+				}
+				list := &admissionv1.ValidatingWebhookConfigurationList{}
+				list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+				list.ResourceVersion = "1"
+				selector := fields.ParseSelectorOrDie(r.URL.Query().Get("fieldSelector"))
+				for _, name := range []string{"a", "b"} {
+					if selector.Matches(fields.Set{"metadata.name": name}) {
+						list.Items = append(list.Items, *newWebhookConfig(name))
+					}
+				}
+				Expect(json.NewEncoder(w).Encode(list)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			c.options = buildCSCacheOptions([]CSCacheOption{WithInformerTweakOptions(webhookGVK, func(options *metav1.ListOptions) {
+				options.FieldSelector = "metadata.name=a"
+			})})
+			Expect(c.Register(webhookGVK)).To(Succeed())
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Expect(c.WaitForCacheSync(ctx)).To(BeTrue())
+			Expect(c.informerMap[webhookGVK].GetStore().ListKeys()).To(Equal([]string{"a"}))
+		})
+	})
+
+	Context("TouchedSince", func() {
+		It("Should return the keys of the objects changed since the time", func() {
+			server := newListWatchServer(newWebhookConfig("a"))
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			Expect(c.Register(webhookGVK)).To(Succeed())
+
+			before := time.Now()
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Eventually(func() []string {
+				return c.TouchedSince(webhookGVK, before)
+			}).Should(Equal([]string{"a"}))
+			Expect(c.TouchedSince(webhookGVK, time.Now())).To(BeEmpty())
+			Expect(c.TouchedSince(configMapGVK, before)).To(BeEmpty())
+		})
+
+		It("Should purge the changes older than the retention", func() {
+			c := newTestCSCache(webhookGVK)
+			c.options = buildCSCacheOptions([]CSCacheOption{WithAccessLogRetention(time.Minute)})
+			c.recordTouch(webhookGVK, "old", time.Now().Add(-2*time.Minute))
+			c.recordTouch(webhookGVK, "new", time.Now())
+
+			Expect(c.TouchedSince(webhookGVK, time.Time{})).To(Equal([]string{"new"}))
+			Expect(c.accessLog[webhookGVK]).NotTo(HaveKey("old"))
+		})
+	})
+
+	Context("VerifyConsistency", func() {
+		It("Should pass when Get returns every listed object", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			for _, name := range []string{"a", "b"} {
+				Expect(store.Add(newWebhookConfig(name))).To(Succeed())
+			}
+			Expect(c.VerifyConsistency(context.TODO(), webhookGVK)).To(Succeed())
+		})
+
+		It("Should report the duplicated, missing and mismatched objects", func() {
+			server := newListWatchServer()
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.options = buildCSCacheOptions([]CSCacheOption{WithWriteThroughPolicy(time.Minute)})
+			informer := c.informerMap[webhookGVK]
+			for _, name := range []string{"a", "b", "gone"} {
+				Expect(informer.GetStore().Add(newWebhookConfig(name))).To(Succeed())
+			}
+			wrapped := inconsistentInformer{SharedIndexInformer: informer, indexer: inconsistentIndexer{Indexer: informer.GetIndexer(), missing: "gone"}}
+			c.informerMap[webhookGVK] = wrapped
+			c.informerMap[schema.GroupVersionKind{Group: webhookGVK.Group, Version: webhookGVK.Version, Kind: webhookGVK.Kind + "List"}] = wrapped
+			newer := newWebhookConfig("b")
+			newer.ResourceVersion = "2"
+			c.storeWriteThrough(webhookGVK, client.ObjectKey{Name: "b"}, newer)
+
+			err := c.VerifyConsistency(context.TODO(), webhookGVK)
+			Expect(err).To(HaveOccurred())
+			var agg utilerrors.Aggregate
+			Expect(errors.As(err, &agg)).To(BeTrue())
+			Expect(agg.Errors()).To(HaveLen(5))
+			Expect(err.Error()).To(ContainSubstring("/a is listed more than once"))
+			Expect(err.Error()).To(ContainSubstring("/gone is listed but not found by Get"))
+			Expect(err.Error()).To(ContainSubstring("/b has resourceVersion 1 in List but 2 in Get"))
+		})
+	})
+
+	Context("GVKKeyFuncs", func() {
+		It("Should get the object by the default and the custom key", func() {
+			byUID := func(obj interface{}) (string, error) {
+				return string(obj.(client.Object).GetUID()), nil
+			}
+			c := newTestCSCache(webhookGVK)
+			c.options = buildCSCacheOptions([]CSCacheOption{WithKeyFunc(webhookGVK, byUID)})
+			informer := c.informerMap[webhookGVK]
+			Expect(c.addKeyFuncIndex(webhookGVK, informer)).To(Succeed())
+			Expect(c.addKeyFuncIndex(configMapGVK, informer)).To(Succeed())
+
+			webhook := newWebhookConfig("a")
+			webhook.UID = "uid-a"
+			Expect(informer.GetStore().Add(webhook)).To(Succeed())
+
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.Get(context.TODO(), client.ObjectKey{Name: "a"}, obj)).To(Succeed())
+			Expect(obj.UID).To(BeEquivalentTo("uid-a"))
+
+			obj = &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.GetByCustomKey(context.TODO(), webhookGVK, "uid-a", obj)).To(Succeed())
+			Expect(obj.Name).To(Equal("a"))
+			Expect(obj).NotTo(BeIdenticalTo(webhook))
+
+			Expect(apierrors.IsNotFound(c.GetByCustomKey(context.TODO(), webhookGVK, "uid-b", obj))).To(BeTrue())
+			Expect(c.GetByCustomKey(context.TODO(), configMapGVK, "uid-a", &corev1.ConfigMap{})).NotTo(Succeed())
+		})
+	})
+
+	Context("InjectEventSource", func() {
+		It("Should enqueue the events of the informer", func() {
+			c := newTestCSCache()
+			c.fallback = newFakeCache(c.Scheme)
+			fakeClient := fake.NewClientBuilder().WithScheme(c.Scheme).Build()
+			informer := toolscache.NewSharedIndexInformer(&toolscache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					list := &admissionv1.ValidatingWebhookConfigurationList{}
+					return list, fakeClient.List(context.TODO(), list, &client.ListOptions{Raw: &options})
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					return fakeClient.Watch(context.TODO(), &admissionv1.ValidatingWebhookConfigurationList{}, &client.ListOptions{Raw: &options})
+				},
+			}, &admissionv1.ValidatingWebhookConfiguration{}, 0, toolscache.Indexers{})
+			c.informerMap[webhookGVK] = informer
+
+			_, err := c.InjectEventSource(schema.GroupVersionKind{Group: webhookGVK.Group, Version: webhookGVK.Version, Kind: webhookGVK.Kind + "List"})
+			Expect(err).To(HaveOccurred())
+			src, err := c.InjectEventSource(webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			queue := controllertest.Queue{Interface: workqueue.New()}
+			defer queue.ShutDown()
+			Expect(src.Start(ctx, &handler.EnqueueRequestForObject{}, queue)).To(Succeed())
+			go informer.Run(ctx.Done())
+			Expect(toolscache.WaitForCacheSync(ctx.Done(), informer.HasSynced)).To(BeTrue())
+
+			Expect(fakeClient.Create(context.TODO(), &admissionv1.ValidatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: "a"}})).To(Succeed())
+			item, _ := queue.Get()
+			Expect(item).To(Equal(reconcile.Request{NamespacedName: types.NamespacedName{Name: "a"}}))
+		})
+	})
+
+	Context("Core API group", func() {
+		It("Should get the resources of the core group under the /api path", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path != "/api/v1/namespaces/ns/configmaps/a" {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+					return
+				}
+				cm := newConfigMap("ns", "a")
+				cm.SetGroupVersionKind(configMapGVK)
+				Expect(json.NewEncoder(w).Encode(cm)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(configMapGVK)
+			c.config = &rest.Config{Host: server.URL}
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "a"}, cm)).To(Succeed())
+			Expect(cm.Name).To(Equal("a"))
+		})
+	})
+
+	Context("Cluster-scoped keys", func() {
+		It("Should ignore the namespace of the key of a cluster-scoped resource", func() {
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			mapper.Add(webhookGVK, apimeta.RESTScopeRoot)
+			mapper.Add(configMapGVK, apimeta.RESTScopeNamespace)
+
+			c := newTestCSCache(webhookGVK, configMapGVK)
+			c.cacheOpts.Mapper = mapper
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("a"))).To(Succeed())
+			Expect(c.informerMap[configMapGVK].GetStore().Add(newConfigMap("ns", "a"))).To(Succeed())
+
+			webhook := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.getFromStore(c.informerMap[webhookGVK], client.ObjectKey{Namespace: "ns", Name: "a"}, webhook, webhookGVK)).To(Succeed())
+			Expect(webhook.Name).To(Equal("a"))
+
+			err := c.getFromStore(c.informerMap[configMapGVK], client.ObjectKey{Name: "a"}, &corev1.ConfigMap{}, configMapGVK)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("GetAll", func() {
+		It("Should return a copy of the objects of a registered GVK", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			for _, name := range []string{"a", "b"} {
+				Expect(store.Add(newWebhookConfig(name))).To(Succeed())
+			}
+
+			objs, err := c.GetAll(context.TODO(), webhookGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(2))
+			for _, obj := range objs {
+				Expect(obj).To(BeAssignableToTypeOf(&admissionv1.ValidatingWebhookConfiguration{}))
+				Expect(obj.GetObjectKind().GroupVersionKind()).To(Equal(webhookGVK))
+				stored, _, err := store.GetByKey(obj.(client.Object).GetName())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(obj).NotTo(BeIdenticalTo(stored))
+			}
+
+			_, err = c.GetAll(context.TODO(), configMapGVK)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should return the objects of a GVK not in the scheme", func() {
+			widgetGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+			objType, err := newInformerObject(runtime.NewScheme(), widgetGVK)
+			Expect(err).NotTo(HaveOccurred())
+			c := newTestCSCache()
+			c.informerMap[widgetGVK] = toolscache.NewSharedIndexInformer(&toolscache.ListWatch{}, objType, 0, toolscache.Indexers{})
+			widget := &unstructured.Unstructured{}
+			widget.SetGroupVersionKind(widgetGVK)
+			widget.SetName("a")
+			Expect(c.informerMap[widgetGVK].GetStore().Add(widget)).To(Succeed())
+
+			objs, err := c.GetAll(context.TODO(), widgetGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			Expect(objs[0].(*unstructured.Unstructured).GetName()).To(Equal("a"))
+			Expect(objs[0].GetObjectKind().GroupVersionKind()).To(Equal(widgetGVK))
+		})
+	})
+
+	Context("Watchdog", func() {
+		var (
+			lists     int32
+			streaming int32
+			server    *httptest.Server
+		)
+
+		BeforeEach(func() {
+			atomic.StoreInt32(&lists, 0)
+			atomic.StoreInt32(&streaming, 0)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("watch") == "true" {
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+					// The watch stays open, sending the updates of the object while streaming
+					for rv := 2; ; rv++ {
+						select {
+						case <-r.Context().Done():
+							return
+						case <-time.After(20 * time.Millisecond):
+						}
+						if atomic.LoadInt32(&streaming) == 0 {
+							continue
+						}
+						obj := newWebhookConfig("a")
+						obj.SetGroupVersionKind(webhookGVK)
+						obj.ResourceVersion = strconv.Itoa(rv)
+						_ = json.NewEncoder(w).Encode(map[string]interface{}{"type": "MODIFIED", "object": obj})
+						w.(http.Flusher).Flush()
+					}
+				}
+				atomic.AddInt32(&lists, 1)
+				list := &admissionv1.ValidatingWebhookConfigurationList{}
+				list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+				list.ResourceVersion = "1"
+				list.Items = []admissionv1.ValidatingWebhookConfiguration{*newWebhookConfig("a")}
+				_ = json.NewEncoder(w).Encode(list)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		newWatchdogCSCache := func(recorder record.EventRecorder) *CSCache {
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			c.options = buildCSCacheOptions([]CSCacheOption{
+				WithWatchdog(50 * time.Millisecond),
+				WithEventRecorder(recorder, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "operator"}}, 0),
+			})
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			return c
+		}
+
+		It("Should not restart the informers receiving events", func() {
+			atomic.StoreInt32(&streaming, 1)
+			recorder := record.NewFakeRecorder(10)
+			c := newWatchdogCSCache(recorder)
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Eventually(c.informerMap[webhookGVK].HasSynced).Should(BeTrue())
+			Consistently(recorder.Events, 300*time.Millisecond).ShouldNot(Receive())
+			Expect(atomic.LoadInt32(&lists)).To(Equal(int32(1)))
+			Expect(c.watchdogRestartsSnapshot()[webhookGVK]).To(BeZero())
+		})
+
+		It("Should restart the informers without events with their handlers and indexes", func() {
+			recorder := record.NewFakeRecorder(10)
+			c := newWatchdogCSCache(recorder)
+			informer := c.informerMap[webhookGVK]
+			Expect(c.IndexField(context.TODO(), &admissionv1.ValidatingWebhookConfiguration{}, "metadata.name", func(obj client.Object) []string {
+				return []string{obj.GetName()}
+			})).To(Succeed())
+			var adds int32
+			controllerInformer, err := c.GetInformer(context.TODO(), &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(err).NotTo(HaveOccurred())
+			controllerInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					atomic.AddInt32(&adds, 1)
+				},
+			})
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Eventually(func() int32 { return atomic.LoadInt32(&adds) }).Should(Equal(int32(1)))
+
+			// The watch stays open without any event, as if it had silently died
+			Eventually(recorder.Events, 5*time.Second).Should(Receive(HavePrefix("Warning InformerStalled")))
+			Expect(c.watchdogRestartsSnapshot()[webhookGVK]).To(BeNumerically(">=", 1))
+			Expect(atomic.LoadInt32(&lists)).To(BeNumerically(">=", 2))
+
+			Expect(c.informerMap[webhookGVK]).To(BeIdenticalTo(informer))
+			Eventually(func() int32 { return atomic.LoadInt32(&adds) }, 5*time.Second).Should(BeNumerically(">=", 2))
+			Eventually(func() bool {
+				return c.GVKStatus()[webhookGVK].Running && c.GVKStatus()[webhookGVK].Synced
+			}, 5*time.Second).Should(BeTrue())
+
+			list := &admissionv1.ValidatingWebhookConfigurationList{}
+			Expect(c.List(context.TODO(), list, client.MatchingFields{"metadata.name": "a"})).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+		})
+	})
+
+	Context("InformerForKey", func() {
+		It("Should return the informer of the GVKs watched by the cache", func() {
+			c := newTestCSCache(webhookGVK)
+
+			informer, ok := c.InformerForKey(client.ObjectKey{Name: "a"}, webhookGVK)
+			Expect(ok).To(BeTrue())
+			Expect(informer).To(Equal(c.informerMap[webhookGVK]))
+
+			informer, ok = c.InformerForKey(client.ObjectKey{Namespace: "ns", Name: "a"}, configMapGVK)
+			Expect(ok).To(BeFalse())
+			Expect(informer).To(BeNil())
+		})
+	})
+
+	Context("WriteThroughUpdates", func() {
+		It("Should update the swapped object on the api server", func() {
+			requests := make(chan string, 2)
+			var conflict int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests <- r.Method + " " + r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				if atomic.LoadInt32(&conflict) == 1 {
+					w.WriteHeader(http.StatusConflict)
+					_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"Conflict","code":409}`))
+					return
+				}
+				obj := &admissionv1.ValidatingWebhookConfiguration{}
+				Expect(json.NewDecoder(r.Body).Decode(obj)).To(Succeed())
+				obj.ResourceVersion = "3"
+				Expect(json.NewEncoder(w).Encode(obj)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.options = buildCSCacheOptions([]CSCacheOption{WithWriteThroughUpdates()})
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("a"))).To(Succeed())
+
+			updated := newWebhookConfig("a")
+			updated.Labels = map[string]string{"updated": "true"}
+			swapped, err := c.CompareAndSwap(context.TODO(), webhookGVK, newWebhookConfig("a"), updated)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeTrue())
+			Expect(<-requests).To(Equal("PUT /apis/admissionregistration.k8s.io/v1/validatingwebhookconfigurations/a"))
+			item, _, err := store.GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item.(*admissionv1.ValidatingWebhookConfiguration).ResourceVersion).To(Equal("3"))
+			Expect(item.(*admissionv1.ValidatingWebhookConfiguration).Labels).To(HaveKeyWithValue("updated", "true"))
+
+			// The store is kept when the api server rejects the update
+			atomic.StoreInt32(&conflict, 1)
+			expected := item.(*admissionv1.ValidatingWebhookConfiguration).DeepCopy()
+			rejected := expected.DeepCopy()
+			rejected.Labels = map[string]string{"updated": "again"}
+			swapped, err = c.CompareAndSwap(context.TODO(), webhookGVK, expected, rejected)
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+			Expect(swapped).To(BeFalse())
+			Expect(<-requests).To(HavePrefix("PUT "))
+			item, _, err = store.GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item).To(Equal(expected))
+		})
+
+		It("Should update the store only once the api server has accepted the update", func() {
+			received := make(chan string, 1)
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				obj := &admissionv1.ValidatingWebhookConfiguration{}
+				Expect(json.NewDecoder(r.Body).Decode(obj)).To(Succeed())
+				received <- obj.ResourceVersion
+				select {
+				case <-release:
+				case <-r.Context().Done():
+					return
+				}
+				obj.ResourceVersion = "3"
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(obj)).To(Succeed())
+			}))
+			defer server.Close()
+
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.options = buildCSCacheOptions([]CSCacheOption{WithWriteThroughUpdates()})
+			store := c.informerMap[webhookGVK].GetStore()
+			Expect(store.Add(newWebhookConfig("a"))).To(Succeed())
+
+			// The update is sent with the resourceVersion of expected, and cancelled with the context
+			ctx, cancel := context.WithCancel(context.TODO())
+			updated := newWebhookConfig("a")
+			updated.ResourceVersion = ""
+			done := make(chan error, 1)
+			go func() {
+				_, err := c.CompareAndSwap(ctx, webhookGVK, newWebhookConfig("a"), updated)
+				done <- err
+			}()
+			Expect(<-received).To(Equal("1"))
+			cancel()
+			Expect(<-done).To(HaveOccurred())
+
+			updated = newWebhookConfig("a")
+			updated.Labels = map[string]string{"updated": "true"}
+			go func() {
+				_, err := c.CompareAndSwap(context.TODO(), webhookGVK, newWebhookConfig("a"), updated)
+				done <- err
+			}()
+			Eventually(received).Should(Receive())
+			item, _, err := store.GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item.(*admissionv1.ValidatingWebhookConfiguration).Labels).To(BeEmpty())
+			// The other swaps are not held back by the update in flight
+			swapped, err := c.CompareAndSwap(context.TODO(), webhookGVK, newWebhookConfig("missing"), newWebhookConfig("missing"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeFalse())
+
+			close(release)
+			Expect(<-done).NotTo(HaveOccurred())
+			item, _, err = store.GetByKey("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item.(*admissionv1.ValidatingWebhookConfiguration).ResourceVersion).To(Equal("3"))
+			Expect(item.(*admissionv1.ValidatingWebhookConfiguration).Labels).To(HaveKeyWithValue("updated", "true"))
+		})
+	})
+
+	Context("ListChunked", func() {
+		It("Should call fn with each chunk of the objects", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			for i := 0; i < 100; i++ {
+				Expect(store.Add(newWebhookConfig(fmt.Sprintf("webhook%d", i)))).To(Succeed())
+			}
+
+			calls := 0
+			seen := make(map[string]bool)
+			Expect(c.ListChunked(context.TODO(), webhookGVK, 10, func(chunk []runtime.Object) error {
+				calls++
+				Expect(chunk).To(HaveLen(10))
+				for _, obj := range chunk {
+					name := obj.(client.Object).GetName()
+					Expect(seen).NotTo(HaveKey(name))
+					seen[name] = true
+				}
+				return nil
+			})).To(Succeed())
+			Expect(calls).To(Equal(10))
+			Expect(seen).To(HaveLen(100))
+
+			Expect(c.ListChunked(context.TODO(), webhookGVK, 0, func([]runtime.Object) error { return nil })).NotTo(Succeed())
+		})
+
+		It("Should stop at the first error of fn", func() {
+			c := newTestCSCache(webhookGVK)
+			store := c.informerMap[webhookGVK].GetStore()
+			for i := 0; i < 25; i++ {
+				Expect(store.Add(newWebhookConfig(fmt.Sprintf("webhook%d", i)))).To(Succeed())
+			}
+
+			calls := 0
+			errStop := errors.New("stop")
+			Expect(c.ListChunked(context.TODO(), webhookGVK, 10, func(chunk []runtime.Object) error {
+				calls++
+				return errStop
+			})).To(MatchError(errStop))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Context("FallbackPolicy", func() {
+		var server *httptest.Server
+		key := types.NamespacedName{Name: "a"}
+
+		BeforeEach(func() {
+			server = newListWatchServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		newFallbackCSCache := func(policy FallbackPolicy, delay time.Duration) *CSCache {
+			c := newTestCSCache(webhookGVK)
+			c.config = &rest.Config{Host: server.URL}
+			c.options = buildCSCacheOptions([]CSCacheOption{WithFallbackPolicy(policy, delay)})
+			return c
+		}
+
+		It("Should get the object from the api server on a store miss with FallbackAlways", func() {
+			c := newFallbackCSCache(FallbackAlways, 0)
+			err := c.Get(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(c.Stats().TotalGetMisses).To(Equal(uint64(1)))
+		})
+
+		It("Should return NotFound without calling the api server with FallbackNever", func() {
+			c := newFallbackCSCache(FallbackNever, 0)
+			err := c.Get(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(c.Stats().TotalGetMisses).To(BeZero())
+		})
+
+		It("Should wait for the store before calling the api server with FallbackOnTimeout", func() {
+			c := newFallbackCSCache(FallbackOnTimeout, time.Second)
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				_ = c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("a"))
+			}()
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.Get(context.TODO(), key, obj)).To(Succeed())
+			Expect(obj.Name).To(Equal("a"))
+			Expect(c.Stats().TotalGetHits).To(Equal(uint64(1)))
+			Expect(c.Stats().TotalGetMisses).To(BeZero())
+
+			c = newFallbackCSCache(FallbackOnTimeout, 100*time.Millisecond)
+			start := time.Now()
+			err := c.Get(context.TODO(), key, &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+			Expect(c.Stats().TotalGetMisses).To(Equal(uint64(1)))
+		})
+	})
+
+	Context("NewCSCacheFromScheme", func() {
+		It("Should watch the GVKs registered in the scheme for the cluster-scoped groups", func() {
+			scheme := runtime.NewScheme()
+			Expect(admissionv1.AddToScheme(scheme)).To(Succeed())
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			scheme.AddKnownTypes(rbacv1.SchemeGroupVersion, &rbacv1.ClusterRole{}, &rbacv1.ClusterRoleList{},
+				&rbacv1.ClusterRoleBinding{}, &rbacv1.ClusterRoleBindingList{}, &rbacv1.Role{}, &rbacv1.RoleList{})
+			metav1.AddToGroupVersion(scheme, rbacv1.SchemeGroupVersion)
+
+			gvks := []schema.GroupVersionKind{
+				webhookGVK,
+				admissionv1.SchemeGroupVersion.WithKind("MutatingWebhookConfiguration"),
+				rbacv1.SchemeGroupVersion.WithKind("ClusterRole"),
+				rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"),
+				rbacv1.SchemeGroupVersion.WithKind("Role"),
+			}
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			for _, gvk := range gvks {
+				mapper.Add(gvk, apimeta.RESTScopeRoot)
+			}
+
+			newCache := NewCSCacheFromScheme(scheme, []string{admissionv1.GroupName, rbacv1.GroupName}, nil, []string{""})
+			csCache, err := newCache(&rest.Config{Host: "http://localhost"}, cache.Options{Scheme: scheme, Mapper: mapper})
+			Expect(err).NotTo(HaveOccurred())
+
+			c := csCache.(*CSCache)
+			for _, gvk := range gvks {
+				Expect(c.informerMap).To(HaveKey(gvk))
+			}
+			Expect(c.informerMap).NotTo(HaveKey(configMapGVK))
+			Expect(c.informerMap).NotTo(HaveKey(rbacv1.SchemeGroupVersion.WithKind("ListOptions")))
+		})
+	})
+
+	Context("AnnotationIndex", func() {
+		It("Should list the objects by the annotation value from the index", func() {
+			c := newTestCSCache(configMapGVK)
+			_, err := c.ListByAnnotation(context.TODO(), configMapGVK, "tenant-id", "ibm-ns-1")
+			Expect(err).To(HaveOccurred())
+
+			Expect(c.AnnotationIndex(configMapGVK, "tenant-id")).To(Succeed())
+			Expect(c.AnnotationIndex(configMapGVK, "tenant-id")).To(Succeed())
+			Expect(c.AnnotationIndex(configMapGVK, "operator.ibm.com/tenant-id")).To(Succeed())
+			Expect(AnnotationIndexField("operator.ibm.com/tenant-id")).To(Equal("metadata.annotations.operator.ibm.com%2Ftenant-id"))
+
+			store := c.informerMap[configMapGVK].GetStore()
+			for i, tenant := range []string{"ibm-ns-1", "ibm-ns-2", "ibm-ns-1", ""} {
+				cm := newConfigMap("ns", fmt.Sprintf("cm%d", i))
+				if tenant != "" {
+					cm.Annotations = map[string]string{"tenant-id": tenant, "operator.ibm.com/tenant-id": tenant}
+				}
+				Expect(store.Add(cm)).To(Succeed())
+			}
+
+			objs, err := c.ListByAnnotation(context.TODO(), configMapGVK, "tenant-id", "ibm-ns-1")
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			for _, obj := range objs {
+				names = append(names, obj.(*corev1.ConfigMap).Name)
+				Expect(obj.GetObjectKind().GroupVersionKind()).To(Equal(configMapGVK))
+			}
+			Expect(names).To(ConsistOf("cm0", "cm2"))
+
+			objs, err = c.ListByAnnotation(context.TODO(), configMapGVK, "operator.ibm.com/tenant-id", "ibm-ns-2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			Expect(objs[0].(*corev1.ConfigMap).Name).To(Equal("cm1"))
+		})
+	})
+
+	Context("ExcludeNamespaces", func() {
+		It("Should not list and watch the objects of the excluded namespaces", func() {
+			var mu sync.Mutex
+			selectors := make(map[string]string)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				mu.Lock()
+				selectors[path.Base(r.URL.Path)] = r.URL.Query().Get("fieldSelector")
+				mu.Unlock()
+				if r.URL.Query().Get("watch") == "true" {
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+					<-r.Context().Done()
+					return
+				}
+				if strings.HasSuffix(r.URL.Path, "/validatingwebhookconfigurations") {
+					list := &admissionv1.ValidatingWebhookConfigurationList{}
+					list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+					list.ResourceVersion = "1"
+					_ = json.NewEncoder(w).Encode(list)
+					return
+				}
+				selector, err := fields.ParseSelector(r.URL.Query().Get("fieldSelector"))
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				list := &corev1.ConfigMapList{}
+				list.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMapList"))
+				list.ResourceVersion = "1"
+				for _, ns := range []string{"kube-system", "ns", "kube-public"} {
+					if selector.Matches(fields.Set{"metadata.namespace": ns}) {
+						list.Items = append(list.Items, *newConfigMap(ns, "a"))
+					}
+				}
+				_ = json.NewEncoder(w).Encode(list)
+			}))
+			defer server.Close()
+
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			mapper.Add(webhookGVK, apimeta.RESTScopeRoot)
+			mapper.Add(configMapGVK, apimeta.RESTScopeNamespace)
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.cacheOpts.Mapper = mapper
+			c.options.ExcludeNamespaces = []string{"kube-system", "kube-public"}
+			informerMap, err := buildInformerMap(c.config, c.cacheOpts, 0,
+				[]schema.GroupVersionKind{configMapGVK, webhookGVK}, nil, c.options, nil)
+			Expect(err).NotTo(HaveOccurred())
+			c.informerMap = informerMap
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go informerMap[configMapGVK].Run(ctx.Done())
+			go informerMap[webhookGVK].Run(ctx.Done())
+			Expect(toolscache.WaitForCacheSync(ctx.Done(), informerMap[configMapGVK].HasSynced, informerMap[webhookGVK].HasSynced)).To(BeTrue())
+
+			list := &corev1.ConfigMapList{}
+			Expect(c.List(context.TODO(), list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Namespace).To(Equal("ns"))
+
+			mu.Lock()
+			Expect(selectors["configmaps"]).To(Equal("metadata.namespace!=kube-system,metadata.namespace!=kube-public"))
+			Expect(selectors["validatingwebhookconfigurations"]).To(BeEmpty())
+			mu.Unlock()
+
+			// The objects listed from the api server are selected the same way
+			items, err := c.listFromClient(context.TODO(), configMapGVK)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items).To(HaveLen(1))
+		})
+	})
+
+	Context("InformerFactory", func() {
+		It("Should share the informers of the factory between the caches", func() {
+			var lists, watches int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("watch") == "true" {
+					atomic.AddInt32(&watches, 1)
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+					<-r.Context().Done()
+					return
+				}
+				atomic.AddInt32(&lists, 1)
+				list := &admissionv1.ValidatingWebhookConfigurationList{}
+				list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+				list.ResourceVersion = "1"
+				list.Items = append(list.Items, *newWebhookConfig("a"))
+				_ = json.NewEncoder(w).Encode(list)
+			}))
+			defer server.Close()
+
+			config := &rest.Config{Host: server.URL}
+			clientset, err := kubernetes.NewForConfig(config)
+			Expect(err).NotTo(HaveOccurred())
+			factory := informers.NewSharedInformerFactory(clientset, 0)
+			unknownGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			var caches []*CSCache
+			for i := 0; i < 2; i++ {
+				c := newTestCSCache()
+				c.options = buildCSCacheOptions([]CSCacheOption{WithInformerFactory(factory)})
+				informerMap, err := buildInformerMap(config, cache.Options{Scheme: c.Scheme}, 0, []schema.GroupVersionKind{webhookGVK, unknownGVK}, nil, c.options, nil)
+				Expect(err).NotTo(HaveOccurred())
+				c.informerMap = informerMap
+				caches = append(caches, c)
+			}
+			Expect(caches[0].informerMap[webhookGVK]).To(BeIdenticalTo(caches[1].informerMap[webhookGVK]))
+			Expect(caches[0].informerMap[unknownGVK]).NotTo(BeIdenticalTo(caches[1].informerMap[unknownGVK]))
+
+			for _, c := range caches {
+				c.mu.Lock()
+				c.startCtx = ctx
+				c.startInformerLocked(webhookGVK, c.informerMap[webhookGVK])
+				c.mu.Unlock()
+			}
+			for _, c := range caches {
+				Eventually(c.informerMap[webhookGVK].HasSynced).Should(BeTrue())
+				Expect(c.Get(context.TODO(), types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{})).To(Succeed())
+			}
+			Eventually(func() int32 { return atomic.LoadInt32(&watches) }).Should(Equal(int32(1)))
+			Consistently(func() int32 { return atomic.LoadInt32(&watches) }, 200*time.Millisecond).Should(Equal(int32(1)))
+			Expect(atomic.LoadInt32(&lists)).To(Equal(int32(1)))
+		})
+	})
+
+	Context("ObjectGenerationSince", func() {
+		seed := func(c *CSCache) {
+			store := c.informerMap[configMapGVK].GetStore()
+			for i, generation := range []int64{1, 3, 2, 5, 3} {
+				cm := newConfigMap(fmt.Sprintf("ns%d", i%2), fmt.Sprintf("cm%d", i))
+				cm.Generation = generation
+				Expect(store.Add(cm)).To(Succeed())
+			}
+		}
+		names := func(objs []runtime.Object) []string {
+			var names []string
+			for _, obj := range objs {
+				names = append(names, obj.(*corev1.ConfigMap).Name)
+				Expect(obj.GetObjectKind().GroupVersionKind()).To(Equal(configMapGVK))
+			}
+			return names
+		}
+
+		It("Should return the objects with a generation above the threshold from the store", func() {
+			c := newTestCSCache(configMapGVK)
+			seed(c)
+			objs, err := c.ObjectGenerationSince(context.TODO(), configMapGVK, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(objs)).To(ConsistOf("cm1", "cm3", "cm4"))
+
+			_, err = c.ObjectGenerationSince(context.TODO(), webhookGVK, 2)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should return the objects with a generation above the threshold from the index", func() {
+			c := newTestCSCache(configMapGVK)
+			Expect(c.RegisterGenerationIndex(configMapGVK)).To(Succeed())
+			Expect(c.RegisterGenerationIndex(configMapGVK)).To(Succeed())
+			seed(c)
+
+			objs, err := c.ObjectGenerationSince(context.TODO(), configMapGVK, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(objs)).To(ConsistOf("cm1", "cm3", "cm4"))
+			objs, err = c.ObjectGenerationSince(context.TODO(), configMapGVK, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(5))
+			objs, err = c.ObjectGenerationSince(context.TODO(), configMapGVK, 5)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(BeEmpty())
+		})
+	})
+
+	Context("ResourceVersionMap", func() {
+		It("Should return the resourceVersion of the objects by their key", func() {
+			c := newTestCSCache(configMapGVK, webhookGVK)
+			cmStore := c.informerMap[configMapGVK].GetStore()
+			for i, ns := range []string{"ns1", "ns2"} {
+				cm := newConfigMap(ns, "a")
+				cm.ResourceVersion = fmt.Sprintf("%d", i+10)
+				Expect(cmStore.Add(cm)).To(Succeed())
+			}
+			Expect(c.informerMap[webhookGVK].GetStore().Add(newWebhookConfig("a"))).To(Succeed())
+
+			Expect(c.ResourceVersionMap(configMapGVK)).To(Equal(map[string]string{"ns1/a": "10", "ns2/a": "11"}))
+			Expect(c.ResourceVersionMap(webhookGVK)).To(Equal(map[string]string{"a": "1"}))
+			_, err := c.ResourceVersionMap(schema.GroupVersionKind{Group: webhookGVK.Group, Version: webhookGVK.Version, Kind: webhookGVK.Kind + "List"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("DynamicClient", func() {
+		badConfig := &rest.Config{Host: "http://%zz"}
+
+		It("Should get the object with the dynamic client when the REST client can't be built", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme, newWebhookConfig("a"))
+
+			c := newTestCSCache(webhookGVK)
+			c.config = badConfig
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: "a"}, &admissionv1.ValidatingWebhookConfiguration{})).NotTo(Succeed())
+
+			c = newTestCSCache(webhookGVK)
+			c.config = badConfig
+			c.options = buildCSCacheOptions([]CSCacheOption{WithDynamicClient(dynamicClient)})
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: "a"}, obj)).To(Succeed())
+			Expect(obj.Name).To(Equal("a"))
+			Expect(obj.GroupVersionKind()).To(Equal(webhookGVK))
+			Expect(dynamicClient.Actions()).To(HaveLen(1))
+			Expect(dynamicClient.Actions()[0].GetVerb()).To(Equal("get"))
+
+			err := c.Get(context.TODO(), types.NamespacedName{Name: "b"}, &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should watch the GVK with the dynamic client when the REST client can't be built", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme, newWebhookConfig("a"))
+
+			informerMap, err := buildInformerMap(badConfig, cache.Options{Scheme: scheme}, 0, []schema.GroupVersionKind{webhookGVK}, nil, CSCacheOptions{DynamicClient: dynamicClient}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go informerMap[webhookGVK].Run(ctx.Done())
+			Expect(toolscache.WaitForCacheSync(ctx.Done(), informerMap[webhookGVK].HasSynced)).To(BeTrue())
+			Expect(informerMap[webhookGVK].GetStore().ListKeys()).To(Equal([]string{"a"}))
+
+			c := newTestCSCache()
+			c.informerMap = informerMap
+			obj := &admissionv1.ValidatingWebhookConfiguration{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: "a"}, obj)).To(Succeed())
+			Expect(obj.Name).To(Equal("a"))
+		})
+
+		It("Should prepopulate the GVK with the dynamic client when the REST client can't be built", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme, newWebhookConfig("a"))
+
+			c := newTestCSCache(webhookGVK)
+			c.config = badConfig
+			c.options = buildCSCacheOptions([]CSCacheOption{WithDynamicClient(dynamicClient)})
+			Expect(c.Prepopulate(context.TODO())).To(Succeed())
+			Expect(c.informerMap[webhookGVK].GetStore().ListKeys()).To(Equal([]string{"a"}))
+		})
+	})
+
+	Context("Cost model", func() {
+		It("Should record the handler time of every event and sample the allocations", func() {
+			registry := prometheus.NewRegistry()
+			model, err := newCostModel(registry, 2)
+			Expect(err).NotTo(HaveOccurred())
+			for i := 0; i < 4; i++ {
+				model.measure(webhookGVK, func() {})
+			}
+
+			families, err := registry.Gather()
+			Expect(err).NotTo(HaveOccurred())
+			samples := make(map[string]uint64)
+			for _, family := range families {
+				metric := family.GetMetric()[0]
+				Expect(metric.GetLabel()).To(HaveLen(1))
+				Expect(metric.GetLabel()[0].GetName()).To(Equal("gvk"))
+				samples[family.GetName()] = metric.GetHistogram().GetSampleCount()
+			}
+			Expect(samples).To(Equal(map[string]uint64{
+				"cs_cache_event_cpu_seconds": 4,
+				"cs_cache_event_alloc_bytes": 2,
+			}))
+		})
+
+		It("Should sample the allocations by default", func() {
+			registry := prometheus.NewRegistry()
+			model, err := newCostModel(registry, 0)
+			Expect(err).NotTo(HaveOccurred())
+			model.measure(webhookGVK, func() {})
+
+			families, err := registry.Gather()
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			for _, family := range families {
+				names = append(names, family.GetName())
+			}
+			Expect(names).To(ConsistOf("cs_cache_event_cpu_seconds", "cs_cache_event_alloc_bytes"))
+		})
+
+		It("Should not read the heap allocation with the sampling disabled", func() {
+			registry := prometheus.NewRegistry()
+			model, err := newCostModel(registry, -1)
+			Expect(err).NotTo(HaveOccurred())
+			model.measure(webhookGVK, func() {})
+
+			families, err := registry.Gather()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(families).To(HaveLen(1))
+			Expect(families[0].GetName()).To(Equal("cs_cache_event_cpu_seconds"))
+		})
+	})
+
+	Context("ResyncHook", func() {
+		It("Should call the hook with the resyncs of the GVKs registered after the start", func() {
+			server := newListWatchServer(newWebhookConfig("a"), newWebhookConfig("b"))
+			defer server.Close()
+
+			resynced := make(chan schema.GroupVersionKind, 10)
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			c.resync = 100 * time.Millisecond
+			c.options = buildCSCacheOptions([]CSCacheOption{WithGlobalResyncHook(func(ctx context.Context, gvk schema.GroupVersionKind, count int) {
+				resynced <- gvk
+			})})
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Eventually(func() bool {
+				c.mu.RLock()
+				defer c.mu.RUnlock()
+				return c.startCtx != nil
+			}).Should(BeTrue())
+
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			Eventually(resynced, 5*time.Second).Should(Receive(Equal(webhookGVK)))
+
+			Expect(c.Deregister(webhookGVK)).To(Succeed())
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+			Expect(c.resyncCounts).NotTo(HaveKey(webhookGVK))
+		})
+	})
+
+	Context("Auto recovery", func() {
+		It("Should rebuild the exited informer with its event handlers and indexes", func() {
+			var built []*handlerCountingInformer
+			rebuildable, err := newRebuildableInformer(func() (toolscache.SharedIndexInformer, *watchHealth, error) {
+				informer := &handlerCountingInformer{exitingInformer: exitingInformer{
+					toolscache.NewSharedIndexInformer(&toolscache.ListWatch{}, &admissionv1.ValidatingWebhookConfiguration{}, 0, toolscache.Indexers{}),
+				}}
+				built = append(built, informer)
+				return informer, &watchHealth{}, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			rebuildable.AddEventHandler(toolscache.ResourceEventHandlerFuncs{})
+			Expect(rebuildable.AddIndexers(toolscache.Indexers{"byName": toolscache.MetaNamespaceIndexFunc})).To(Succeed())
+
+			c := newTestCSCache()
+			c.options = buildCSCacheOptions([]CSCacheOption{
+				WithAutoRecovery(webhookGVK, 2, wait.Backoff{Duration: time.Millisecond, Steps: 2}),
+			})
+			c.runInformer(context.TODO(), webhookGVK, rebuildable)
+
+			Expect(built).To(HaveLen(3))
+			for _, informer := range built {
+				Expect(informer.handlers).To(Equal(1))
+				Expect(informer.GetIndexer().GetIndexers()).To(HaveKey("byName"))
+			}
+			Expect(rebuildable.informer()).To(BeIdenticalTo(built[2]))
+		})
+
+		It("Should not recover the informer which can't be rebuilt", func() {
+			c := newTestCSCache(webhookGVK)
+			c.options = buildCSCacheOptions([]CSCacheOption{
+				WithAutoRecovery(webhookGVK, 2, wait.Backoff{Duration: time.Millisecond, Steps: 2}),
+			})
+			informer := c.informerMap[webhookGVK]
+			c.runInformer(context.TODO(), webhookGVK, exitingInformer{informer})
+
+			Expect(c.informerMap[webhookGVK]).To(BeIdenticalTo(informer))
+			Expect(c.LivenessChecker()(nil)).To(HaveOccurred())
+		})
+
+		It("Should rebuild the running informer whose list and watch keep failing", func() {
+			var failing int32
+			broken := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if atomic.LoadInt32(&failing) == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if r.URL.Query().Get("watch") == "true" {
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+					select {
+					case <-r.Context().Done():
+					case <-broken:
+					}
+					return
+				}
+				list := &admissionv1.ValidatingWebhookConfigurationList{}
+				list.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+				list.ResourceVersion = "1"
+				list.Items = []admissionv1.ValidatingWebhookConfiguration{*newWebhookConfig("a")}
+				_ = json.NewEncoder(w).Encode(list)
+			}))
+			defer server.Close()
+
+			c := newTestCSCache()
+			c.config = &rest.Config{Host: server.URL}
+			c.fallback = newFakeCache(c.Scheme)
+			c.options = buildCSCacheOptions([]CSCacheOption{
+				WithAutoRecovery(webhookGVK, 5, wait.Backoff{Duration: 50 * time.Millisecond, Factor: 1, Steps: 5}),
+			})
+			Expect(c.Register(webhookGVK)).To(Succeed())
+			rebuildable := c.informerMap[webhookGVK].(*rebuildableInformer)
+			first := rebuildable.informer()
+			var adds int32
+			informer, err := c.GetInformer(context.TODO(), &admissionv1.ValidatingWebhookConfiguration{})
+			Expect(err).NotTo(HaveOccurred())
+			informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					atomic.AddInt32(&adds, 1)
+				},
+			})
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				_ = c.Start(ctx)
+			}()
+			Eventually(func() int32 { return atomic.LoadInt32(&adds) }).Should(Equal(int32(1)))
+
+			// The reflector of the informer retries the failed watch without exiting
+			atomic.StoreInt32(&failing, 1)
+			close(broken)
+			Eventually(rebuildable.informer, 5*time.Second).ShouldNot(BeIdenticalTo(first))
+			atomic.StoreInt32(&failing, 0)
+
+			Eventually(func() int32 { return atomic.LoadInt32(&adds) }, 5*time.Second).Should(BeNumerically(">=", 2))
+			Eventually(rebuildable.HasSynced, 5*time.Second).Should(BeTrue())
+			Expect(c.LivenessChecker()(nil)).To(Succeed())
+		})
+	})
+
+	Context("Event queue depth", func() {
+		It("Should set the gauge to the events queued for the handlers", func() {
+			c := newTestCSCache(webhookGVK)
+			c.queueDepthGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_event_queue_depth"}, []string{"gvk"})
+			fake := &controllertest.FakeInformer{}
+			informer := c.informerFor(webhookGVK, fake)
+			release := make(chan struct{})
+			var handled int32
+			informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					<-release
+					atomic.AddInt32(&handled, 1)
+				},
+			})
+			for _, name := range []string{"a", "b", "c"} {
+				fake.Add(newWebhookConfig(name))
+			}
+
+			c.updateQueueDepth(context.TODO())
+			Expect(testutil.ToFloat64(c.queueDepthGauge.WithLabelValues(webhookGVK.String()))).To(Equal(3.0))
+
+			close(release)
+			Eventually(func() int32 { return atomic.LoadInt32(&handled) }).Should(Equal(int32(3)))
+			Eventually(func() float64 {
+				c.updateQueueDepth(context.TODO())
+				return testutil.ToFloat64(c.queueDepthGauge.WithLabelValues(webhookGVK.String()))
+			}).Should(Equal(0.0))
+
+			Expect(c.Deregister(webhookGVK)).To(Succeed())
+			Expect(c.queueDepths).NotTo(HaveKey(webhookGVK))
+		})
+
+		It("Should deliver the queued events in order", func() {
+			c := newTestCSCache(webhookGVK)
+			c.queueDepthGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_event_queue_depth"}, []string{"gvk"})
+			fake := &controllertest.FakeInformer{}
+			var mu sync.Mutex
+			var events []string
+			c.informerFor(webhookGVK, fake).AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					mu.Lock()
+					defer mu.Unlock()
+					events = append(events, "add "+obj.(client.Object).GetName())
+				},
+				DeleteFunc: func(obj interface{}) {
+					mu.Lock()
+					defer mu.Unlock()
+					events = append(events, "delete "+obj.(client.Object).GetName())
+				},
+			})
+			var expected []string
+			for i := 0; i < 50; i++ {
+				name := fmt.Sprintf("obj-%d", i)
+				fake.Add(newWebhookConfig(name))
+				fake.Delete(newWebhookConfig(name))
+				expected = append(expected, "add "+name, "delete "+name)
+			}
+			Eventually(func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([]string{}, events...)
+			}).Should(Equal(expected))
+		})
+	})
+})
+
+// writeClientCert writes a self-signed client certificate with the common name
+func writeClientCert(certFile, keyFile, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)).To(Succeed())
+}
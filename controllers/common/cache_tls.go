@@ -0,0 +1,77 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+)
+
+// RotatingTLSConfig wraps a rest.Config authenticating with a client certificate file, so that
+// the certificate is reloaded from disk on every new connection. A rotated certificate is
+// picked up without restarting the operator.
+type RotatingTLSConfig struct {
+	config *rest.Config
+}
+
+// NewRotatingTLSConfig wraps the config, which must have the CertFile and KeyFile set
+func NewRotatingTLSConfig(config *rest.Config) *RotatingTLSConfig {
+	return &RotatingTLSConfig{config: config}
+}
+
+// GetClientCertificate loads the client certificate from CertFile and KeyFile
+func (r *RotatingTLSConfig) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the client certificate: %v", err)
+	}
+	return &cert, nil
+}
+
+// RESTConfig returns a copy of the config whose transport reloads the client certificate
+func (r *RotatingTLSConfig) RESTConfig() (*rest.Config, error) {
+	if r.config.CertFile == "" || r.config.KeyFile == "" {
+		return nil, fmt.Errorf("certificate rotation requires both the CertFile and KeyFile of the config")
+	}
+	tlsConfig, err := rest.TLSConfigFor(r.config)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.GetClientCertificate = r.GetClientCertificate
+
+	proxy := http.ProxyFromEnvironment
+	if r.config.Proxy != nil {
+		proxy = r.config.Proxy
+	}
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           proxy,
+	}
+	if r.config.Dial != nil {
+		transport.DialContext = r.config.Dial
+	}
+
+	// The TLS options cannot be set together with a custom transport
+	cfg := rest.CopyConfig(r.config)
+	cfg.TLSClientConfig = rest.TLSClientConfig{}
+	cfg.Transport = utilnet.SetTransportDefaults(transport)
+	return cfg, nil
+}
@@ -0,0 +1,100 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// buildClientConfig builds the config of the REST clients used by the informers and getFromClient
+func buildClientConfig(config *rest.Config, csOpts CSCacheOptions) (*rest.Config, error) {
+	cfg := rest.CopyConfig(config)
+	if csOpts.TLSRotationEnabled {
+		rotating, err := NewRotatingTLSConfig(cfg).RESTConfig()
+		if err != nil {
+			return nil, err
+		}
+		cfg = rotating
+	}
+	if csOpts.APIServerTimeout > 0 {
+		cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &timeoutRoundTripper{timeout: csOpts.APIServerTimeout, rt: rt}
+		})
+	}
+	if len(csOpts.Headers) > 0 {
+		cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &headerRoundTripper{headers: csOpts.Headers, rt: rt}
+		})
+	}
+	return cfg, nil
+}
+
+// headerRoundTripper injects the headers into every request
+type headerRoundTripper struct {
+	headers map[string]string
+	rt      http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (h *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	return h.rt.RoundTrip(req)
+}
+
+// timeoutRoundTripper bounds every request, except the watches, with the timeout
+type timeoutRoundTripper struct {
+	timeout time.Duration
+	rt      http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *timeoutRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The watches are long-running requests
+	if req.URL.Query().Get("watch") == "true" {
+		return t.rt.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The body is read after RoundTrip returns, so the timeout is released when it is closed
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnCloseBody cancels the context of the request when the response body is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,163 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// minTTLCheckInterval is the shortest interval between two checks of the expired objects
+const minTTLCheckInterval = time.Second
+
+// enableTTLEviction records the creation timestamp of the objects of the GVK
+func (c *CSCache) enableTTLEviction(gvk schema.GroupVersionKind) error {
+	informer, ok := c.getInformer(gvk)
+	if !ok {
+		return fmt.Errorf("failed to enable TTL eviction: %s is not in the cluster GVK list", gvk)
+	}
+
+	c.ttlMu.Lock()
+	if c.ttlEntries == nil {
+		c.ttlEntries = make(map[schema.GroupVersionKind]map[string]time.Time)
+	}
+	c.ttlEntries[gvk] = make(map[string]time.Time)
+	c.ttlMu.Unlock()
+
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			meta, err := apimeta.Accessor(obj)
+			if err != nil {
+				return
+			}
+			key, err := toolscache.MetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+			c.ttlMu.Lock()
+			defer c.ttlMu.Unlock()
+			// The entries of the GVK are cleared once it is deregistered
+			if entries, ok := c.ttlEntries[gvk]; ok {
+				entries[key] = meta.GetCreationTimestamp().Time
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			key, err := toolscache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+			c.ttlMu.Lock()
+			defer c.ttlMu.Unlock()
+			delete(c.ttlEntries[gvk], key)
+		},
+	})
+	return nil
+}
+
+// forgetTTLEntries forgets the creation timestamps of the objects of the GVK
+func (c *CSCache) forgetTTLEntries(gvk schema.GroupVersionKind) {
+	c.ttlMu.Lock()
+	defer c.ttlMu.Unlock()
+	delete(c.ttlEntries, gvk)
+}
+
+// runTTLEviction evicts the expired objects of the GVK until the context is done
+func (c *CSCache) runTTLEviction(ctx context.Context, gvk schema.GroupVersionKind, policy TTLPolicy) {
+	interval := policy.TTL / 2
+	if interval < minTTLCheckInterval {
+		interval = minTTLCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.evictExpired(ctx, gvk, policy)
+		}
+	}
+}
+
+// evictExpired evicts the objects of the GVK created longer than the TTL ago
+func (c *CSCache) evictExpired(ctx context.Context, gvk schema.GroupVersionKind, policy TTLPolicy) {
+	informer, ok := c.getInformer(gvk)
+	if !ok {
+		return
+	}
+
+	var expired []string
+	c.ttlMu.Lock()
+	for key, created := range c.ttlEntries[gvk] {
+		if time.Since(created) > policy.TTL {
+			expired = append(expired, key)
+			delete(c.ttlEntries[gvk], key)
+		}
+	}
+	c.ttlMu.Unlock()
+
+	store := informer.GetStore()
+	for _, key := range expired {
+		item, exists, err := store.GetByKey(key)
+		if err != nil || !exists {
+			continue
+		}
+		if err := store.Delete(item); err != nil {
+			klog.Errorf("Failed to evict %s %s from cache: %v", gvk.Kind, key, err)
+			continue
+		}
+		klog.V(2).Infof("Evicted %s %s from cache after %v", gvk.Kind, key, policy.TTL)
+
+		if policy.DeleteFromCluster {
+			if err := c.deleteFromClient(ctx, key, gvk); err != nil && !apierrors.IsNotFound(err) {
+				klog.Errorf("Failed to delete expired %s %s: %v", gvk.Kind, key, err)
+			}
+		}
+	}
+}
+
+// deleteFromClient deletes the resource by the k8s client
+func (c *CSCache) deleteFromClient(ctx context.Context, key string, gvk schema.GroupVersionKind) error {
+	if c.options.ReadOnly {
+		return ErrReadOnlyCache
+	}
+	namespace, name, err := toolscache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	client, err := c.clientForGVK(ctx, gvk)
+	if err != nil {
+		return err
+	}
+	req := client.
+		Delete().
+		NamespaceIfScoped(namespace, namespace != "").
+		Resource(kindToResource(gvk.Kind)).
+		Name(name)
+	if c.options.DryRunAPIClient {
+		req = req.Param("dryRun", metav1.DryRunAll)
+	}
+	return req.Do(ctx).Error()
+}
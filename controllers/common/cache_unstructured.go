@@ -0,0 +1,79 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+// unstructuredNegotiatedSerializer decodes the resources of the GVKs not registered in the
+// scheme, e.g. the dynamic CRDs, into unstructured objects
+var unstructuredNegotiatedSerializer = runtime.NewSimpleNegotiatedSerializer(runtime.SerializerInfo{
+	MediaType:        runtime.ContentTypeJSON,
+	MediaTypeType:    "application",
+	MediaTypeSubType: "json",
+	EncodesAsText:    true,
+	Serializer:       unstructured.UnstructuredJSONScheme,
+	StreamSerializer: &runtime.StreamSerializerInfo{
+		EncodesAsText: true,
+		Serializer:    unstructured.UnstructuredJSONScheme,
+		Framer:        json.Framer,
+	},
+})
+
+// newInformerObject returns the typed object of the GVK watched by the informer, or an
+// unstructured object if the GVK is not registered in the scheme
+func newInformerObject(scheme *runtime.Scheme, gvk schema.GroupVersionKind) (runtime.Object, error) {
+	if !scheme.Recognizes(gvk) {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		return u, nil
+	}
+	typed, err := scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	// Build typed runtime object for informer
+	objType := &unstructured.Unstructured{}
+	objType.GetObjectKind().SetGroupVersionKind(gvk)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objType.UnstructuredContent(), typed); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
+
+// convertUnstructured converts between the item and the obj when exactly one of them is
+// unstructured, and returns false when no conversion is needed
+func convertUnstructured(item runtime.Object, obj runtime.Object) (bool, error) {
+	_, itemIsUnstructured := item.(*unstructured.Unstructured)
+	u, objIsUnstructured := obj.(*unstructured.Unstructured)
+	switch {
+	case objIsUnstructured && !itemIsUnstructured:
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return true, err
+		}
+		u.SetUnstructuredContent(content)
+		return true, nil
+	case itemIsUnstructured && !objIsUnstructured:
+		return true, runtime.DefaultUnstructuredConverter.FromUnstructured(item.(*unstructured.Unstructured).UnstructuredContent(), obj)
+	}
+	return false, nil
+}
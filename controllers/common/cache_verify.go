@@ -0,0 +1,106 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// VerifyConsistency checks that every object of the GVK returned by List is returned by Get
+// with the same resourceVersion, and that List doesn't return an object twice. It is meant
+// for the diagnostics and the integration tests, all the inconsistencies are returned.
+func (c *CSCache) VerifyConsistency(ctx context.Context, gvk schema.GroupVersionKind) error {
+	if isListGVK(gvk) {
+		return fmt.Errorf("failed to verify %s: list kinds are verified with their item kinds", gvk)
+	}
+	listGVK := schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind + "List"}
+	list, err := c.newObject(listGVK)
+	if err != nil {
+		return err
+	}
+	objList, ok := list.(client.ObjectList)
+	if !ok {
+		return fmt.Errorf("failed to verify %s: %T is not an ObjectList", gvk, list)
+	}
+	if err := c.List(ctx, objList); err != nil {
+		return fmt.Errorf("failed to list %s: %v", gvk, err)
+	}
+	items, err := apimeta.ExtractList(objList)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	seen := make(map[client.ObjectKey]bool, len(items))
+	for _, item := range items {
+		listed, err := apimeta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		key := client.ObjectKey{Namespace: listed.GetNamespace(), Name: listed.GetName()}
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("%s %s is listed more than once", gvk, key))
+			continue
+		}
+		seen[key] = true
+
+		obj, err := c.newObject(gvk)
+		if err != nil {
+			return err
+		}
+		clientObj, ok := obj.(client.Object)
+		if !ok {
+			return fmt.Errorf("failed to verify %s: %T is not an Object", gvk, obj)
+		}
+		if err := c.Get(ctx, key, clientObj); err != nil {
+			if apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("%s %s is listed but not found by Get", gvk, key))
+			} else {
+				errs = append(errs, fmt.Errorf("failed to get %s %s: %v", gvk, key, err))
+			}
+			continue
+		}
+		if clientObj.GetResourceVersion() != listed.GetResourceVersion() {
+			errs = append(errs, fmt.Errorf("%s %s has resourceVersion %s in List but %s in Get", gvk, key, listed.GetResourceVersion(), clientObj.GetResourceVersion()))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// newObject returns a new object of the GVK, unstructured if the GVK is not in the scheme
+func (c *CSCache) newObject(gvk schema.GroupVersionKind) (runtime.Object, error) {
+	if c.Scheme.Recognizes(gvk) {
+		return c.Scheme.New(gvk)
+	}
+	if isListGVK(gvk) {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		return list, nil
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj, nil
+}
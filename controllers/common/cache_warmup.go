@@ -0,0 +1,92 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WarmUpEvent reports the informer of a GVK synced during WaitForCacheSync
+type WarmUpEvent struct {
+	// GVK is the GVK of the informer
+	GVK schema.GroupVersionKind
+	// Synced is true once the informer has synced
+	Synced bool
+	// Total is the number of informers
+	Total int
+	// SyncedCount is the number of informers synced so far
+	SyncedCount int
+}
+
+// warmUp tracks the informers synced since the cache was built
+type warmUp struct {
+	total  int
+	synced map[schema.GroupVersionKind]bool
+	ch     chan WarmUpEvent
+}
+
+// WarmUpProgress returns the channel receiving an event each time an informer has synced
+// during WaitForCacheSync, e.g. to log "8 of 12 informers synced". The channel is closed
+// once all the informers have synced. The informers registered after the first call are
+// not reported.
+func (c *CSCache) WarmUpProgress() <-chan WarmUpEvent {
+	c.warmUpMu.Lock()
+	defer c.warmUpMu.Unlock()
+	return c.warmUpLocked().ch
+}
+
+// warmUpLocked returns the warm-up progress, initialized with the informers in the cache.
+// The caller must hold c.warmUpMu.
+func (c *CSCache) warmUpLocked() *warmUp {
+	if c.warmUp != nil {
+		return c.warmUp
+	}
+	c.mu.RLock()
+	total := 0
+	for gvk := range c.informerMap {
+		if !isListGVK(gvk) {
+			total++
+		}
+	}
+	c.mu.RUnlock()
+
+	// The channel holds all the events, so that reporting never blocks
+	c.warmUp = &warmUp{
+		total:  total,
+		synced: make(map[schema.GroupVersionKind]bool),
+		ch:     make(chan WarmUpEvent, total),
+	}
+	if total == 0 {
+		close(c.warmUp.ch)
+	}
+	return c.warmUp
+}
+
+// reportWarmUp reports the informer of the GVK has synced
+func (c *CSCache) reportWarmUp(gvk schema.GroupVersionKind) {
+	c.warmUpMu.Lock()
+	defer c.warmUpMu.Unlock()
+	w := c.warmUpLocked()
+	if w.synced[gvk] || len(w.synced) == w.total {
+		return
+	}
+	w.synced[gvk] = true
+	w.ch <- WarmUpEvent{GVK: gvk, Synced: true, Total: w.total, SyncedCount: len(w.synced)}
+	if len(w.synced) == w.total {
+		close(w.ch)
+	}
+}
@@ -0,0 +1,57 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// WatchWithContext adds the handler to the informer of the GVK until the context is
+// cancelled. The informers of this client-go version can't remove a handler, so the
+// handler is unregistered by turning it into a no-op once the context is done.
+func (c *CSCache) WatchWithContext(ctx context.Context, gvk schema.GroupVersionKind, handler toolscache.ResourceEventHandlerFuncs) error {
+	var stopped int32
+	if err := c.Observe(gvk, toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if atomic.LoadInt32(&stopped) == 0 {
+				handler.OnAdd(obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if atomic.LoadInt32(&stopped) == 0 {
+				handler.OnUpdate(oldObj, newObj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if atomic.LoadInt32(&stopped) == 0 {
+				handler.OnDelete(obj)
+			}
+		},
+	}); err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		atomic.StoreInt32(&stopped, 1)
+	}()
+	return nil
+}
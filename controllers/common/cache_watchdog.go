@@ -0,0 +1,176 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// InformerStalledReason is the reason of the events emitted on the informers restarted by
+// the watchdog
+const InformerStalledReason = "InformerStalled"
+
+// runWatchdog restarts the informers whose event count hasn't changed for two intervals, until
+// the context is done. The events are counted by a handler added to each informer, so that an
+// informer whose watch has silently died is restarted. The informers shared from the
+// InformerFactory are left to the factory.
+func (c *CSCache) runWatchdog(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	counters := make(map[schema.GroupVersionKind]*eventCounter)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			informers := c.rebuildableInformers()
+			for gvk := range counters {
+				if _, ok := informers[gvk]; !ok {
+					delete(counters, gvk)
+				}
+			}
+			for gvk, informer := range informers {
+				counter, ok := counters[gvk]
+				if !ok || counter.informer != informer {
+					counters[gvk] = newEventCounter(informer, now)
+					continue
+				}
+				if counter.moved(now) || now.Sub(counter.lastChange) < 2*interval {
+					continue
+				}
+				klog.Warningf("Informer for %s has received no event for %v, restarting it", gvk, now.Sub(counter.lastChange))
+				if err := c.restartInformer(gvk); err != nil {
+					klog.Errorf("Failed to restart informer for %s: %v", gvk, err)
+					continue
+				}
+				counter.lastChange = now
+				c.recordWatchdogRestart(gvk, interval)
+			}
+		}
+	}
+}
+
+// eventCounter counts the events delivered by an informer, with the time the count last changed
+type eventCounter struct {
+	informer   *rebuildableInformer
+	count      uint64
+	last       uint64
+	lastChange time.Time
+}
+
+// newEventCounter adds the handler counting the events to the informer. The handler is kept
+// by the informer once restarted.
+func newEventCounter(informer *rebuildableInformer, now time.Time) *eventCounter {
+	counter := &eventCounter{informer: informer, lastChange: now}
+	increment := func() { atomic.AddUint64(&counter.count, 1) }
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { increment() },
+		UpdateFunc: func(interface{}, interface{}) { increment() },
+		DeleteFunc: func(interface{}) { increment() },
+	})
+	return counter
+}
+
+// moved checks if the count has changed since the last check
+func (e *eventCounter) moved(now time.Time) bool {
+	count := atomic.LoadUint64(&e.count)
+	if count == e.last {
+		return false
+	}
+	e.last = count
+	e.lastChange = now
+	return true
+}
+
+// rebuildableInformers returns the informers of the GVKs which can be rebuilt
+func (c *CSCache) rebuildableInformers() map[schema.GroupVersionKind]*rebuildableInformer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	informers := make(map[schema.GroupVersionKind]*rebuildableInformer)
+	for gvk, informer := range c.informerMap {
+		if rebuildable, ok := informer.(*rebuildableInformer); ok && !isListGVK(gvk) {
+			informers[gvk] = rebuildable
+		}
+	}
+	return informers
+}
+
+// restartInformer stops the informer of the GVK and replaces it by a new one, which keeps
+// the event handlers and indexes of the stopped informer
+func (c *CSCache) restartInformer(gvk schema.GroupVersionKind) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	informer, ok := c.informerMap[gvk]
+	if !ok {
+		return fmt.Errorf("%s is not in the cache", gvk)
+	}
+	rebuildable, ok := informer.(*rebuildableInformer)
+	if !ok {
+		return fmt.Errorf("the informer of %s can't be rebuilt", gvk)
+	}
+	if c.startCtx == nil || c.startCtx.Err() != nil {
+		return fmt.Errorf("the cache is not running")
+	}
+	next, health, err := rebuildable.rebuild()
+	if err != nil {
+		return err
+	}
+	// The informer is stopped before it is replaced, so that it isn't run again
+	if cancel, ok := c.informerCancels[gvk]; ok {
+		cancel()
+	}
+	if err := rebuildable.replace(next, health); err != nil {
+		return err
+	}
+	c.startInformerLocked(gvk, rebuildable)
+	return nil
+}
+
+// recordWatchdogRestart counts the restart of the informer of the GVK, and emits a Warning
+// event on the EventObject if the EventRecorder is set
+func (c *CSCache) recordWatchdogRestart(gvk schema.GroupVersionKind, interval time.Duration) {
+	c.statsMu.Lock()
+	if c.watchdogRestarts == nil {
+		c.watchdogRestarts = make(map[schema.GroupVersionKind]uint64)
+	}
+	c.watchdogRestarts[gvk]++
+	c.statsMu.Unlock()
+
+	if c.options.EventRecorder != nil && c.options.EventObject != nil {
+		c.options.EventRecorder.Eventf(c.options.EventObject, corev1.EventTypeWarning, InformerStalledReason,
+			"Restarted the informer for %s which received no event for %v", gvk, 2*interval)
+	}
+}
+
+// watchdogRestartsSnapshot returns the number of restarts of the informer of each GVK
+func (c *CSCache) watchdogRestartsSnapshot() map[schema.GroupVersionKind]uint64 {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	restarts := make(map[schema.GroupVersionKind]uint64, len(c.watchdogRestarts))
+	for gvk, count := range c.watchdogRestarts {
+		restarts[gvk] = count
+	}
+	return restarts
+}
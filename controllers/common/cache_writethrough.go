@@ -0,0 +1,85 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// writeThroughKey identifies an object in the write-through cache
+type writeThroughKey struct {
+	gvk schema.GroupVersionKind
+	key client.ObjectKey
+}
+
+// writeThroughEntry is an object fetched from the api server
+type writeThroughEntry struct {
+	obj     runtime.Object
+	expires time.Time
+}
+
+// storeWriteThrough keeps the object fetched from the api server until the write-through expiry
+func (c *CSCache) storeWriteThrough(gvk schema.GroupVersionKind, key client.ObjectKey, obj runtime.Object) {
+	if c.options.WriteThroughExpiry <= 0 || c.isExcludedNamespace(key.Namespace) {
+		return
+	}
+	k := writeThroughKey{gvk: gvk, key: key}
+	entry := &writeThroughEntry{obj: obj.DeepCopyObject(), expires: time.Now().Add(c.options.WriteThroughExpiry)}
+	c.writeThrough.Store(k, entry)
+	time.AfterFunc(c.options.WriteThroughExpiry, func() {
+		// Only delete the entry if it hasn't been replaced by a newer one
+		if current, ok := c.writeThrough.Load(k); ok && current == entry {
+			c.writeThrough.Delete(k)
+		}
+	})
+}
+
+// getFromWriteThrough gets the object from the write-through cache if it hasn't expired
+func (c *CSCache) getFromWriteThrough(gvk schema.GroupVersionKind, key client.ObjectKey, obj runtime.Object) (bool, error) {
+	if c.options.WriteThroughExpiry <= 0 {
+		return false, nil
+	}
+	value, ok := c.writeThrough.Load(writeThroughKey{gvk: gvk, key: key})
+	if !ok {
+		return false, nil
+	}
+	entry := value.(*writeThroughEntry)
+	if time.Now().After(entry.expires) {
+		return false, nil
+	}
+
+	if converted, err := convertUnstructured(entry.obj.DeepCopyObject(), obj); err != nil {
+		return false, err
+	} else if converted {
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		return true, nil
+	}
+	objVal := reflect.ValueOf(obj)
+	itemVal := reflect.ValueOf(entry.obj.DeepCopyObject())
+	if !itemVal.Type().AssignableTo(objVal.Type()) {
+		return false, fmt.Errorf("cache had type %s, but %s was asked for", itemVal.Type(), objVal.Type())
+	}
+	reflect.Indirect(objVal).Set(reflect.Indirect(itemVal))
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return true, nil
+}
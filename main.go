@@ -38,6 +38,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/IBM/controller-filtered-cache/filteredcache"
 	nssv1 "github.com/IBM/ibm-namespace-scope-operator/api/v1"
@@ -112,7 +113,10 @@ func main() {
 
 	var NewCache cache.NewCacheFunc
 	watchNamespaceList := strings.Split(watchNamespace, ",")
-	NewCache = util.NewCSCache(clusterGVKList, gvkLabelMap, watchNamespaceList)
+	// The events are suspended until the manager is elected, and the manager exits once it
+	// loses leadership, so the cache is not suspended again
+	electedCh := make(chan struct{})
+	NewCache = util.NewCSCache(clusterGVKList, gvkLabelMap, watchNamespaceList, util.WithLeaderElectionIntegration(electedCh, nil))
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -127,6 +131,14 @@ func main() {
 		klog.Errorf("Unable to start manager: %v", err)
 		os.Exit(1)
 	}
+	// The runnables needing leader election only run once the manager is elected
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		close(electedCh)
+		return nil
+	})); err != nil {
+		klog.Errorf("Unable to resume the cache on election: %v", err)
+		os.Exit(1)
+	}
 
 	operatorNs, err := util.GetOperatorNamespace()
 	klog.Infof("Identifying Common Service Operator Role in the namespace %s", operatorNs)